@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/acnil/acnil-bot/pkg/acnil"
@@ -30,11 +31,6 @@ func main() {
 		logrus.Fatal("AUDIT_SHEET_ID must be defined")
 	}
 
-	juegatronSheetID := os.Getenv("JUEGATRON_SHEET_ID")
-	if juegatronSheetID == "" {
-		logrus.Fatal("JUEGATRON_SHEET_ID must be defined")
-	}
-
 	pref := tele.Settings{
 		Token:  botToken,
 		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
@@ -48,9 +44,17 @@ func main() {
 
 	srv := recipes.SheetsService()
 
-	juegatronAudit := &acnil.JuegatronAudit{
-		AuditDB: acnil.NewJuegatronSheetAuditDatabase(srv, juegatronSheetID),
+	// Events are read from the registry in the main sheet, only the ones with start and end dates are used
+	events := acnil.NewEventDatabase(srv, sheetID)
+	openEvent := acnil.NewSheetEventOpener(srv)
+
+	// Group support is opt-in, announcements are only sent if GROUP_CHAT_ID is defined
+	groupChatID, _ := strconv.ParseInt(os.Getenv("GROUP_CHAT_ID"), 10, 64)
+	group := &acnil.GroupNotifier{
+		ChatID: groupChatID,
+		Bot:    b,
 	}
+	group.RunWeeklySummary(context.Background(), acnil.NewGameDatabase(srv, sheetID), acnil.NewMembersDatabase(srv, sheetID), time.Monday, 10)
 
 	if disableAudit == "" {
 		audit := &acnil.Audit{
@@ -58,27 +62,46 @@ func main() {
 			GameDB:    acnil.NewGameDatabase(srv, sheetID),
 			MembersDB: acnil.NewMembersDatabase(srv, sheetID),
 			Bot:       b,
+			Group:     group,
 		}
 		audit.Run(context.Background(), time.Hour)
 
-		// juegatronAudit.Run(context.Background(), time.Hour)
+		// Holders are computed from the Préstamos log, this only reports when the sheet disagrees
+		juegatronChecks := &acnil.JuegatronChecks{
+			Events:    events,
+			Open:      openEvent,
+			MembersDB: acnil.NewMembersDatabase(srv, sheetID),
+			Bot:       b,
+		}
+		juegatronChecks.Run(context.Background(), time.Hour)
 	}
-	// err = juegatronAudit.Do(context.Background())
-	// if err != nil {
-	// 	logrus.WithError(err).Error("Failed to update juegatron audit")
-	// }
 
 	auditQuery := &acnil.AuditQuery{
 		AuditDB: acnil.NewSheetAuditDatabase(srv, auditSheetID),
 	}
 
+	// Transfers are flagged after acnil.DefaultStaleTransferDays if not set
+	staleTransferDays, _ := strconv.Atoi(os.Getenv("STALE_TRANSFER_DAYS"))
+
+	// Attendees can take any number of games if JUEGATRON_MAX_GAMES is not set
+	juegatronMaxGames, _ := strconv.Atoi(os.Getenv("JUEGATRON_MAX_GAMES"))
+
 	handler := &acnil.Handler{
-		MembersDB:       acnil.NewMembersDatabase(srv, sheetID),
-		GameDB:          acnil.NewGameDatabase(srv, sheetID),
-		JuegatronGameDB: acnil.NewGameDatabase(srv, juegatronSheetID),
-		JuegatronAudit:  juegatronAudit,
-		Audit:           auditQuery,
-		Bot:             b,
+		MembersDB:         acnil.NewMembersDatabase(srv, sheetID),
+		GameDB:            acnil.NewGameDatabase(srv, sheetID),
+		LocationDB:        acnil.NewLocationDatabase(srv, sheetID),
+		Events:            events,
+		OpenEvent:         openEvent,
+		JuegatronMaxGames: juegatronMaxGames,
+		Audit:             auditQuery,
+		Bot:               b,
+		BotName:           b.Me.Username,
+		GroupChatID:       groupChatID,
+
+		StaleTransferDays: staleTransferDays,
+
+		// Buttons are not signed if PAYLOAD_SECRET is not set
+		PayloadSecret: os.Getenv("PAYLOAD_SECRET"),
 	}
 
 	handlerGroup := b.Group()
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/acnil/acnil-bot/pkg/acnil"
 	"github.com/acnil/acnil-bot/pkg/recipes"
@@ -29,6 +32,9 @@ func main() {
 		logrus.Fatal("AUDIT_SHEET_ID must be defined")
 	}
 
+	// Group support is opt-in, announcements are only sent if GROUP_CHAT_ID is defined
+	groupChatID, _ := strconv.ParseInt(os.Getenv("GROUP_CHAT_ID"), 10, 64)
+
 	srv := recipes.SheetsService()
 
 	pref := tele.Settings{
@@ -42,16 +48,54 @@ func main() {
 		return
 	}
 
+	group := &acnil.GroupNotifier{
+		ChatID: groupChatID,
+		Bot:    b,
+	}
 	audit := &acnil.Audit{
 		AuditDB:   acnil.NewSheetAuditDatabase(srv, auditSheetID),
 		GameDB:    acnil.NewGameDatabase(srv, sheetID),
 		MembersDB: acnil.NewMembersDatabase(srv, sheetID),
 		Bot:       b,
+		Group:     group,
+	}
+
+	// Events are read from the registry in the main sheet, only the ones with start and end dates are used
+	events := acnil.NewEventDatabase(srv, sheetID)
+	juegatronChecks := &acnil.JuegatronChecks{
+		Events:    events,
+		Open:      acnil.NewSheetEventOpener(srv),
+		MembersDB: audit.MembersDB,
+		Bot:       b,
 	}
 	logrus.Println("starting lambda")
-	lambda.Start(audit.Do)
+	lambda.Start(func(ctx context.Context, event Event) error {
+		switch event.Task {
+		case TaskAudit:
+			return audit.Do(ctx)
+		case TaskOverdueSummary:
+			return group.SendOverdueSummary(ctx, audit.GameDB, audit.MembersDB)
+		case TaskJuegatronChecks:
+			return juegatronChecks.Do(ctx)
+		default:
+			return fmt.Errorf("unknown task %q", event.Task)
+		}
+	})
 }
 
+// Event is the input of the lambda. The schedule rules set the task to run, the audit runs if it is empty
+type Event struct {
+	Task string `json:"task"`
+}
+
+const (
+	TaskAudit = ""
+	// TaskOverdueSummary posts the overdue games to the group, it is meant to be scheduled once a week
+	TaskOverdueSummary = "overdue-summary"
+	// TaskJuegatronChecks compares the sheet of the active events with their loan log and warns the volunteers
+	TaskJuegatronChecks = "juegatron-checks"
+)
+
 func GetEnv(key string, def string) string {
 	v, ok := os.LookupEnv(key)
 	if !ok {
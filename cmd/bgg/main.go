@@ -20,16 +20,21 @@ import (
 )
 
 type ExtendedData struct {
-	Name               string  `json:"name,omitempty"`
-	BGGID              string  `json:"bggid,omitempty"`
-	MinPlayers         int     `json:"min_players,omitempty"`
-	MaxPlayers         int     `json:"max_player,omitempty"`
-	Age                int     `json:"age,omitempty"`
-	Playingtime        float64 `json:"playingtime,omitempty"`
-	Yearpublished      int     `json:"yearpublished,omitempty"`
-	LanguageDependence string  `json:"language_dependence,omitempty"`
-	AvgRate            float64 `json:"avg_rate,omitempty"`
-	AvgWeight          float64 `json:"avg_weight,omitempty"`
+	Name               string   `json:"name,omitempty"`
+	BGGID              string   `json:"bggid,omitempty"`
+	MinPlayers         int      `json:"min_players,omitempty"`
+	MaxPlayers         int      `json:"max_player,omitempty"`
+	Age                int      `json:"age,omitempty"`
+	Playingtime        float64  `json:"playingtime,omitempty"`
+	Yearpublished      int      `json:"yearpublished,omitempty"`
+	LanguageDependence string   `json:"language_dependence,omitempty"`
+	AvgRate            float64  `json:"avg_rate,omitempty"`
+	AvgWeight          float64  `json:"avg_weight,omitempty"`
+	Categories         []string `json:"categories,omitempty"`
+	Mechanics          []string `json:"mechanics,omitempty"`
+	Designers          []string `json:"designers,omitempty"`
+	Families           []string `json:"families,omitempty"`
+	AlternateNames     []string `json:"alternate_names,omitempty"`
 }
 
 type ExtendedDataDB struct {
@@ -176,6 +181,11 @@ func FillInventory(ctx *cli.Context, GameDB acnil.GameDatabase, bggapi *bgg.Clie
 		games[i].Yearpublished = ex.Yearpublished
 		games[i].AvgRate = ex.AvgRate
 		games[i].AvgWeight = ex.AvgWeight
+		games[i].Categories = ex.Categories
+		games[i].Mechanics = ex.Mechanics
+		games[i].Designers = ex.Designers
+		games[i].Families = ex.Families
+		games[i].AlternateNames = ex.AlternateNames
 	}
 
 	return GameDB.Update(ctx.Context, games...)
@@ -191,7 +201,11 @@ func ExtendGameData(game *acnil.Game, ex ExtendedData) {
 	game.Yearpublished = ex.Yearpublished
 	game.AvgRate = ex.AvgRate
 	game.AvgWeight = ex.AvgWeight
-
+	game.Categories = ex.Categories
+	game.Mechanics = ex.Mechanics
+	game.Designers = ex.Designers
+	game.Families = ex.Families
+	game.AlternateNames = ex.AlternateNames
 }
 
 func Manual(ctx *cli.Context, GameDB acnil.GameDatabase, bggapi *bgg.Client, extended *ExtendedDataDB) error {
@@ -436,6 +450,24 @@ func NewExtendedDataFromBGGGame(bggGame bgg.Boardgame) ExtendedData {
 		v, _ := strconv.ParseFloat(s, 64)
 		return v
 	}
+
+	Texts := func(links []bgg.Link) []string {
+		texts := []string{}
+		for _, l := range links {
+			texts = append(texts, l.Text)
+		}
+		return texts
+	}
+
+	AlternateNames := func(names bgg.Names) []string {
+		texts := []string{}
+		for _, n := range names {
+			if n.Primary != "true" {
+				texts = append(texts, n.Text)
+			}
+		}
+		return texts
+	}
 	return ExtendedData{
 		Name:               bggGame.Name.Principal().Text,
 		BGGID:              bggGame.Objectid,
@@ -447,5 +479,10 @@ func NewExtendedDataFromBGGGame(bggGame bgg.Boardgame) ExtendedData {
 		Yearpublished:      MustAtoi(bggGame.Yearpublished),
 		AvgRate:            MustFloat(bggGame.Statistics.Ratings.Average),
 		AvgWeight:          MustFloat(bggGame.Statistics.Ratings.Averageweight),
+		Categories:         Texts(bggGame.Boardgamecategory),
+		Mechanics:          Texts(bggGame.Boardgamemechanic),
+		Designers:          Texts(bggGame.Boardgamedesigner),
+		Families:           Texts(bggGame.Boardgamefamily),
+		AlternateNames:     AlternateNames(bggGame.Name),
 	}
 }
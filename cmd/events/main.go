@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	"github.com/acnil/acnil-bot/pkg/recipes"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+
+	sheetID := os.Getenv("SHEET_ID")
+	if sheetID == "" {
+		logrus.Fatal("SHEET_ID must be defined")
+	}
+
+	srv := recipes.SheetsService()
+
+	GameDB := acnil.NewGameDatabase(srv, sheetID)
+	EventDB := acnil.NewEventDatabase(srv, sheetID)
+	LocationDB := acnil.NewLocationDatabase(srv, sheetID)
+	openEvent := acnil.NewSheetEventOpener(srv)
+
+	app := cli.App{
+		Name:  "acnil-events",
+		Usage: "manage the games lent in events like the Juegatron",
+		Commands: []*cli.Command{
+			{
+				Name:  "publish",
+				Usage: "copy games from the inventory to the catalogue of an event and reserve them for the event dates",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "event",
+						Usage:    "ID of the event in the Eventos sheet",
+						Required: true,
+					},
+					&cli.StringSliceFlag{
+						Name:  "id",
+						Usage: "publish the games with these IDs",
+					},
+					&cli.StringFlag{
+						Name:  "location",
+						Usage: "publish the games in this location",
+					},
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "publish the games that contain this text in the name",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "only print the games that would be published",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					return Publish(ctx, GameDB, EventDB, LocationDB, openEvent)
+				},
+			},
+		},
+	}
+	if err := app.Run(os.Args); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+func Publish(ctx *cli.Context, GameDB acnil.GameDatabase, EventDB acnil.EventDatabase, LocationDB acnil.LocationDatabase, openEvent acnil.EventOpener) error {
+	events, err := EventDB.List(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("couldn't list events, %w", err)
+	}
+	info, ok := acnil.Events(events).Get(ctx.String("event"))
+	if !ok {
+		return fmt.Errorf("%w, %s", acnil.ErrEventNotFound, ctx.String("event"))
+	}
+
+	selection := acnil.GameSelection{
+		IDs:  ctx.StringSlice("id"),
+		Name: ctx.String("name"),
+	}
+	if location := ctx.String("location"); location != "" {
+		locations, err := LocationDB.List(ctx.Context)
+		if err != nil {
+			return fmt.Errorf("couldn't list locations, %w", err)
+		}
+		l, ok := acnil.Locations(locations).Find(location)
+		if !ok {
+			return fmt.Errorf("unknown location %s", location)
+		}
+		selection.Location = l.Name
+	}
+	if selection.IsEmpty() {
+		return fmt.Errorf("select the games with --id, --location or --name")
+	}
+
+	games, err := GameDB.List(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("couldn't list games, %w", err)
+	}
+	selected := selection.Select(games)
+	for _, g := range selected {
+		fmt.Println(g.Line())
+	}
+	logrus.Infof("%d games selected for %s", len(selected), info.Name)
+
+	if ctx.Bool("dry-run") || len(selected) == 0 {
+		return nil
+	}
+
+	result, err := acnil.PublishToEvent(ctx.Context, GameDB, openEvent(info), selected)
+	if err != nil {
+		return err
+	}
+	fmt.Println(result.String())
+	return nil
+}
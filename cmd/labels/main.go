@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	"github.com/acnil/acnil-bot/pkg/labels"
+	"github.com/acnil/acnil-bot/pkg/recipes"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+)
+
+func main() {
+
+	sheetID := os.Getenv("SHEET_ID")
+	if sheetID == "" {
+		logrus.Fatal("SHEET_ID must be defined")
+	}
+
+	srv := recipes.SheetsService()
+
+	GameDB := acnil.NewGameDatabase(srv, sheetID)
+
+	app := cli.App{
+		Name:  "acnil-labels",
+		Usage: "generate printable label sheets with a QR code that opens the game in the bot",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "bot",
+				Usage:    "telegram username of the bot, used to build the links",
+				EnvVars:  []string{"BOT_NAME"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "location",
+				Usage: "only print the games in this location",
+			},
+			&cli.StringSliceFlag{
+				Name:  "id",
+				Usage: "only print the games with these IDs",
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "only print the games that contain this text in the name",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "pdf or png",
+				Value: "pdf",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "output file. For png, one file is created per page using this name as prefix",
+				Value: "etiquetas",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			return Generate(ctx, GameDB)
+		},
+	}
+	if err := app.Run(os.Args); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+func Generate(ctx *cli.Context, GameDB acnil.GameDatabase) error {
+	games, err := GameDB.List(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("couldn't list games, %w", err)
+	}
+
+	if name := ctx.String("name"); name != "" {
+		games = acnil.Games(games).Find(name)
+	}
+
+	gameLabels := []labels.Label{}
+	for _, g := range games {
+		if location := ctx.String("location"); location != "" && !g.IsInLocation(acnil.Location(location)) {
+			continue
+		}
+		if ids := ctx.StringSlice("id"); len(ids) > 0 && !slices.Contains(ids, g.ID) {
+			continue
+		}
+		gameLabels = append(gameLabels, g.Label(ctx.String("bot")))
+	}
+	logrus.Infof("Generating %d labels", len(gameLabels))
+
+	switch ctx.String("format") {
+	case "pdf":
+		f, err := os.Create(ctx.String("output") + ".pdf")
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return labels.PDF(f, gameLabels)
+	case "png":
+		pages, err := labels.PNG(gameLabels)
+		if err != nil {
+			return err
+		}
+		for i, page := range pages {
+			filename := fmt.Sprintf("%s-%02d.png", ctx.String("output"), i+1)
+			if err := os.WriteFile(filename, page, 0644); err != nil {
+				return err
+			}
+			logrus.Infof("Written %s", filename)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %s", ctx.String("format"))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"strconv"
 
 	"github.com/acnil/acnil-bot/pkg/acnil"
 	httplambda "github.com/acnil/acnil-bot/pkg/httpLambda"
@@ -60,10 +61,6 @@ func main() {
 	if auditSheetID == "" {
 		logrus.Fatal("AUDIT_SHEET_ID must be defined")
 	}
-	juegatronSheetID := os.Getenv("JUEGATRON_SHEET_ID")
-	if auditSheetID == "" {
-		logrus.Fatal("JUEGATRON_SHEET_ID must be defined")
-	}
 
 	srv := recipes.SheetsService()
 
@@ -82,17 +79,34 @@ func main() {
 		AuditDB: acnil.NewSheetAuditDatabase(srv, auditSheetID),
 	}
 
-	juegatronAudit := &acnil.JuegatronAudit{
-		AuditDB: acnil.NewJuegatronSheetAuditDatabase(srv, juegatronSheetID),
-	}
+	// Events are read from the registry in the main sheet, only the ones with start and end dates are used
+	events := acnil.NewEventDatabase(srv, sheetID)
+	openEvent := acnil.NewSheetEventOpener(srv)
+
+	groupChatID, _ := strconv.ParseInt(os.Getenv("GROUP_CHAT_ID"), 10, 64)
+
+	// Transfers are flagged after acnil.DefaultStaleTransferDays if not set
+	staleTransferDays, _ := strconv.Atoi(os.Getenv("STALE_TRANSFER_DAYS"))
+
+	// Attendees can take any number of games if JUEGATRON_MAX_GAMES is not set
+	juegatronMaxGames, _ := strconv.Atoi(os.Getenv("JUEGATRON_MAX_GAMES"))
 
 	handler := &acnil.Handler{
-		MembersDB:       acnil.NewMembersDatabase(srv, sheetID),
-		GameDB:          acnil.NewGameDatabase(srv, sheetID),
-		JuegatronGameDB: acnil.NewGameDatabase(srv, juegatronSheetID),
-		JuegatronAudit:  juegatronAudit,
-		Audit:           auditQuery,
-		Bot:             b,
+		MembersDB:         acnil.NewMembersDatabase(srv, sheetID),
+		GameDB:            acnil.NewGameDatabase(srv, sheetID),
+		LocationDB:        acnil.NewLocationDatabase(srv, sheetID),
+		Events:            events,
+		OpenEvent:         openEvent,
+		JuegatronMaxGames: juegatronMaxGames,
+		Audit:             auditQuery,
+		Bot:               b,
+		BotName:           b.Me.Username,
+		GroupChatID:       groupChatID,
+
+		StaleTransferDays: staleTransferDays,
+
+		// Buttons are not signed if PAYLOAD_SECRET is not set
+		PayloadSecret: os.Getenv("PAYLOAD_SECRET"),
 	}
 
 	handlerGroup := b.Group()
@@ -0,0 +1,31 @@
+// Package accents removes the accents of the text, to compare names written by people and to draw them with ascii fonts
+package accents
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Remove returns the text without accents, "Catán" becomes "Catan"
+func Remove(in string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	out, _, err := transform.String(t, in)
+	if err != nil {
+		return in
+	}
+	return out
+}
+
+// ASCII removes the accents and replaces the characters that are not ascii with '?', as the bitmap fonts only contain ascii characters
+func ASCII(in string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return '?'
+		}
+		return r
+	}, Remove(in))
+}
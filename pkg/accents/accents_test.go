@@ -0,0 +1,22 @@
+package accents
+
+import "testing"
+
+func TestRemove(t *testing.T) {
+	for in, want := range map[string]string{
+		"Catán":           "Catan",
+		"Ñandú":           "Nandu",
+		"Aventureros":     "Aventureros",
+		"Pingüinos & Cía": "Pinguinos & Cia",
+	} {
+		if got := Remove(in); got != want {
+			t.Errorf("Remove(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestASCII(t *testing.T) {
+	if got := ASCII("Catán 🎲"); got != "Catan ?" {
+		t.Errorf("ASCII = %q", got)
+	}
+}
@@ -56,6 +56,9 @@ type Audit struct {
 
 	MembersDB MembersDatabase
 	Bot       Sender
+
+	// Group receives announcements about new and returned games, optional
+	Group *GroupNotifier
 }
 
 func (a *Audit) Run(ctx context.Context, interval time.Duration) {
@@ -120,6 +123,8 @@ func (a *Audit) Do(ctx context.Context) error {
 		return fmt.Errorf("Failed to list game database, %w", err)
 	}
 
+	before := append(Snapshot{}, a.snapshot...)
+
 	newEntries, err := a.calculateEntries(games)
 	if err != nil {
 		return fmt.Errorf("Could not calculate entries, %w", err)
@@ -137,6 +142,10 @@ func (a *Audit) Do(ctx context.Context) error {
 		a.snapshot = nil
 		return fmt.Errorf("Failed to post audit update, %w", err)
 	}
+
+	if err := a.Group.AnnounceChanges(before, newEntries); err != nil {
+		log.WithError(err).Error("Failed to announce changes to the group")
+	}
 	return nil
 }
 
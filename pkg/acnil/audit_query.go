@@ -18,7 +18,8 @@ type Query struct {
 	From, To time.Time
 	Game     *Game
 	Limit    int
-	Member   *Member
+	// Member finds the entries of the games held by the member, with the current or a previous nickname
+	Member *Member
 }
 
 func (a *AuditQuery) Find(ctx context.Context, query Query) ([]AuditEntry, error) {
@@ -43,7 +44,7 @@ func (a *AuditQuery) Find(ctx context.Context, query Query) ([]AuditEntry, error
 			continue
 		}
 
-		if query.Member != nil && !e.Game().IsHeldBy(*query.Member) {
+		if query.Member != nil && !e.Game().WasHeldBy(*query.Member) {
 			continue
 		}
 
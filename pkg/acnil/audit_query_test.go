@@ -154,6 +154,21 @@ var _ = Describe("AuditQuery: ", func() {
 				auditedEntries[5],
 			))
 		})
+		It("Must display data for a member under previous nicknames", func() {
+			list, err := audit.Find(context.Background(), acnil.Query{
+				Member: &acnil.Member{
+					Nickname:          "Blueberry",
+					PreviousNicknames: []string{"MetalBlueberry"},
+				},
+			})
+			Expect(err).To(BeNil())
+
+			Expect(list).To(HaveLen(2))
+			Expect(list).To(ContainElements(
+				auditedEntries[1],
+				auditedEntries[5],
+			))
+		})
 
 	})
 })
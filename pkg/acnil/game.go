@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/acnil/acnil-bot/pkg/bgg"
+	"github.com/acnil/acnil-bot/pkg/labels"
 	"github.com/acnil/acnil-bot/pkg/sheetsparser"
 	"github.com/sirupsen/logrus"
 	tele "gopkg.in/telebot.v3"
@@ -23,12 +24,15 @@ var (
 			}
 			return ""
 		},
+		"join": func(items []string) string {
+			return strings.Join(items, ", ")
+		},
 	}).Parse(`
 {{ define "card" }}
-{{ .Line }}
+{{ .Line }}{{ template "alias" . }}
 
 {{ .Location }}
-{{ if .IsAvailable -}}
+{{ template "transit" . }}{{ if .IsAvailable -}}
 🟢 Disponible
 {{- else -}}
 🔴 Ocupado: {{ .Holder }}{{ if not .TakeDate.IsZero }} {{ .TakeDate.Format "2006-01-02" }} ({{ .LeaseDays }} días){{ end }}
@@ -44,18 +48,21 @@ Notas:
 {{ end }}
 
 {{ define "morecard" }}
-{{ .Line }}
+{{ .Line }}{{ template "alias" . }}
 
 {{ .Publisher}} {{if .Price}}({{ .Price }}){{end}}
 {{ .Location }}
-{{- if .ContainsBGGData }}
+{{ template "transit" . }}{{- if .ContainsBGGData }}
 Puntuación: {{ .AvgRate }}
 Dificultad: {{ .AvgWeight }}
 Edad: {{ .Age }}
 Nº Jugadores: {{ .MinPlayers }}-{{.MaxPlayers}}
 Tiempo de juego : {{ .Playingtime }}m
 {{ if .LanguageDependence}}Dependencia del idioma:  {{ .LanguageDependence }} {{ end }}
-{{ end }}
+{{ if .Categories }}Categorías: {{ join .Categories }}
+{{ end }}{{ if .Mechanics }}Mecánicas: {{ join .Mechanics }}
+{{ end }}{{ if .Designers }}Diseñadores: {{ join .Designers }}
+{{ end }}{{ end }}
 {{ if .IsAvailable -}}
 🟢 Disponible
 {{- else -}}
@@ -72,6 +79,31 @@ Notas:
 {{- end }}
 {{ end }}
 
+{{ define "transit" }}
+{{- if .IsInTransit -}}
+🚚 En tránsito → {{ .TransferTo }}{{ if not .TransferDate.IsZero }} desde el {{ .TransferDate.Format "2006-01-02" }} ({{ .TransferDays }} días){{ end }}
+{{ end -}}
+{{- if .IsReserved -}}
+📅 Reservado para {{ .ReservedFor }}{{ if not .ReservedUntil.IsZero }} hasta el {{ .ReservedUntil.Format "2006-01-02" }}{{ end }}
+{{ end -}}
+{{ end }}
+
+{{ define "inline" }}
+{{ .Line }}{{ template "alias" . }}
+📍 {{ .Location }}
+{{ template "transit" . }}{{ if .IsAvailable -}}
+🟢 Disponible
+{{- else -}}
+🔴 Prestado a {{ .Holder }}
+{{- end }}
+{{ end }}
+
+{{ define "alias" }}
+{{- if .MatchedAlias }}
+🔤 Encontrado como "{{ .MatchedAlias }}"
+{{- end }}
+{{- end }}
+
 {{ define "juegatron" }}
 {{ .Line }}
 {{ if .Comments }}
@@ -108,6 +140,36 @@ type Game struct {
 	Playingtime        float64 `col:"15"`
 	Yearpublished      int     `col:"16"`
 	LanguageDependence string  `col:"17"`
+
+	// TransferTo is the location the game is being moved to, empty if it is not in transit
+	TransferTo   string    `col:"18"`
+	TransferDate time.Time `col:"19"`
+
+	// ReservedFor is the event the game has been published to, it can't be taken until ReservedUntil
+	ReservedFor   string    `col:"20"`
+	ReservedFrom  time.Time `col:"21"`
+	ReservedUntil time.Time `col:"22"`
+
+	// Categories, Mechanics, Designers and Families are the names given by BGG, separated by ";" in the sheet
+	Categories []string `col:"23"`
+	Mechanics  []string `col:"24"`
+	Designers  []string `col:"25"`
+	Families   []string `col:"26"`
+
+	// AlternateNames are the other names of the game in BGG, they are replaced on every import
+	AlternateNames []string `col:"27"`
+	// Aliases are the names added by the admins, the import never changes them
+	Aliases []string `col:"28"`
+
+	// HolderID is the telegram ID of the member that holds the game. Holder is kept as the display name.
+	// It is empty for the loans made before it existed and for holders that are not members, see HolderResolver
+	HolderID string `col:"29"`
+
+	// TransferBy is the telegram ID of the member that requested the transfer, it can cancel it along with the custodians of the destination
+	TransferBy string `col:"30"`
+
+	// MatchedAlias is set by the search when the game was found by one of its other names
+	MatchedAlias string
 }
 
 func NewGameFromLineData(data string) Game {
@@ -155,6 +217,24 @@ func (g Game) String() string {
 	return g.Line()
 }
 
+// StartPayloadGamePrefix is the prefix of the /start payload that opens a game card
+const StartPayloadGamePrefix = "game_"
+
+// DeepLink returns a telegram link that opens the game card in the bot
+func (g Game) DeepLink(botName string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", botName, StartPayloadGamePrefix, g.ID)
+}
+
+// Label returns the information printed on the label of the game box
+func (g Game) Label(botName string) labels.Label {
+	return labels.Label{
+		ID:       g.ID,
+		Name:     g.Name,
+		Location: g.Location,
+		URL:      g.DeepLink(botName),
+	}
+}
+
 func (g Game) ContainsBGGData() bool {
 	return g.BGG != "-" && g.BGG != ""
 }
@@ -190,8 +270,23 @@ func (g Game) IsTheSameGame(game Game) bool {
 	return g.IsTheSame(game.ID, game.Name)
 }
 
+// IsHeldBy compares the HolderID with the member, or the Holder with the nickname if the loan is not linked to a member.
+// The sheet can be edited by hand, so the HolderID is only trusted while the Holder is still one of the names of the member.
+// Loans linked to other members are never held by the member, HolderResolver checks if those links are still valid
 func (g Game) IsHeldBy(member Member) bool {
-	return strings.TrimSpace(Norm(g.Holder)) == Norm(member.Nickname)
+	switch g.HolderID {
+	case "":
+		return strings.TrimSpace(Norm(g.Holder)) == Norm(member.Nickname)
+	case member.TelegramID:
+		return member.HasName(g.Holder)
+	default:
+		return false
+	}
+}
+
+// WasHeldBy returns true if the holder is the member under the current or a previous nickname
+func (g Game) WasHeldBy(member Member) bool {
+	return g.Holder != "" && member.HasName(g.Holder)
 }
 
 func (g Game) LeaseDays() int {
@@ -228,16 +323,18 @@ func (g Game) LineData() string {
 	return data
 }
 
-func (g Game) JuegatronButtons() *tele.ReplyMarkup {
+// JuegatronButtons returns the buttons of the card of the game in an event, every button carries the payload of the game and the event ID
+func (g Game) JuegatronButtons(eventID string, secret string) *tele.ReplyMarkup {
 	selector := &tele.ReplyMarkup{}
 	rows := []tele.Row{}
 	if g.IsAvailable() {
 		rows = append(rows, selector.Row(
-			selector.Data("Prestar", "juegatron-take"),
+			gameButton(selector, secret, g, "Prestar", "juegatron-take", eventID),
 		))
 	} else {
 		rows = append(rows, selector.Row(
-			selector.Data("Devolver", "juegatron-return"),
+			gameButton(selector, secret, g, "Devolver", "juegatron-return", eventID),
+			gameButton(selector, secret, g, "Corregir", "juegatron-correct", eventID),
 		))
 	}
 
@@ -246,63 +343,133 @@ func (g Game) JuegatronButtons() *tele.ReplyMarkup {
 	return selector
 }
 
-func (g Game) Buttons(member Member) *tele.ReplyMarkup {
-	return g.ButtonsForPage(member, 1)
+// JuegatronUndoButtons reverts the last change made by the member on the game
+func (g Game) JuegatronUndoButtons(eventID string, secret string) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	selector.Inline(selector.Row(
+		gameButton(selector, secret, g, "Deshacer", "undo-juegatron", eventID),
+	))
+	return selector
+}
+
+func (g Game) Buttons(member Member, secret string) *tele.ReplyMarkup {
+	return g.ButtonsForPage(member, 1, secret)
 }
 
-func (g Game) ButtonsForPage(member Member, page int) *tele.ReplyMarkup {
+// ButtonsForPage returns the buttons of the card, every button carries the payload of the game signed with the secret
+func (g Game) ButtonsForPage(member Member, page int, secret string) *tele.ReplyMarkup {
 	selector := &tele.ReplyMarkup{}
 	rows := []tele.Row{}
 
 	switch page {
 	default:
 		if g.IsAvailable() {
-			rows = append(rows, selector.Row(
-				selector.Data("Tomar Prestado", "take"),
-			))
+			if !g.IsInTransit() && !g.IsReserved() {
+				rows = append(rows, selector.Row(
+					gameButton(selector, secret, g, "Tomar Prestado", "take"),
+				))
+			}
 		} else {
 			rows = append(rows, selector.Row(
-				selector.Data("Devolver", "return"),
+				gameButton(selector, secret, g, "Devolver", "return"),
 			))
 		}
 
 		if g.ContainsBGGData() {
 			rows = append(rows, selector.Row(
-				selector.Data("Mas información", "more"),
+				gameButton(selector, secret, g, "Mas información", "more"),
 			))
 		}
 
 		rows = append(rows, selector.Row(
-			selector.Data("Historial", "history"),
+			gameButton(selector, secret, g, "Historial", "history"),
 		))
 
 		if (g.IsHeldBy(member) || (member.Permissions == PermissionAdmin && !g.IsAvailable())) && g.IsLeaseExpired() {
 			rows = append(rows, selector.Row(
-				selector.Data("Dar mas tiempo", "extendLease"),
+				gameButton(selector, secret, g, "Dar mas tiempo", "extendLease"),
 			))
 		}
 
 		rows = append(rows, selector.Row(
-			selector.Data(">", "game-page-2"),
+			gameButton(selector, secret, g, ">", "game-page-2"),
 		))
 	case 2:
-		switch {
-		case g.IsInLocation(LocationCentro):
+		if g.IsInTransit() {
 			rows = append(rows, selector.Row(
-				selector.Data("Mover a Gamonal", "switch-location"),
+				gameButton(selector, secret, g, "Confirmar llegada a "+g.TransferTo, "transfer-confirm"),
 			))
-		default:
 			rows = append(rows, selector.Row(
-				selector.Data("Mover al Centro", "switch-location"),
+				gameButton(selector, secret, g, "Cancelar traslado", "transfer-cancel"),
+			))
+		} else {
+			rows = append(rows, selector.Row(
+				gameButton(selector, secret, g, "Mover de ubicación", "select-location"),
+			))
+		}
+		rows = append(rows, selector.Row(
+			gameButton(selector, secret, g, "Actualizar comentario", "update-comment"),
+		))
+		if member.Permissions == PermissionAdmin {
+			rows = append(rows, selector.Row(
+				gameButton(selector, secret, g, "Añadir alias", "add-alias"),
 			))
+			if strings.TrimSpace(g.ReservedFor) != "" {
+				rows = append(rows, selector.Row(
+					gameButton(selector, secret, g, "Cancelar reserva", "cancel-reservation"),
+				))
+			}
+			if !g.IsAvailable() && g.HolderID == "" {
+				rows = append(rows, selector.Row(
+					gameButton(selector, secret, g, "Vincular socio", "link-holder"),
+				))
+			}
 		}
 		rows = append(rows, selector.Row(
-			selector.Data("Actualizar comentario", "update-comment"),
+			gameButton(selector, secret, g, "<", "game-page-1"),
+		))
+	}
+
+	selector.Inline(rows...)
+
+	return selector
+}
+
+// ReturnButtons asks the member where the game has been dropped off
+func (g Game) ReturnButtons(locations Locations, secret string) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{}
+
+	for _, location := range locations {
+		rows = append(rows, selector.Row(
+			gameButton(selector, secret, g, "Devuelto en "+string(location.Name), "return-location", location.Key()),
 		))
+	}
+	rows = append(rows, selector.Row(
+		gameButton(selector, secret, g, "<", "game-page-1"),
+	))
+
+	selector.Inline(rows...)
+
+	return selector
+}
+
+// LocationButtons lists the locations where the game can be moved to
+func (g Game) LocationButtons(locations Locations, secret string) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{}
+
+	for _, location := range locations {
+		if g.IsInLocation(location.Name) {
+			continue
+		}
 		rows = append(rows, selector.Row(
-			selector.Data("<", "game-page-1"),
+			gameButton(selector, secret, g, "Mover a "+string(location.Name), "switch-location", location.Key()),
 		))
 	}
+	rows = append(rows, selector.Row(
+		gameButton(selector, secret, g, "<", "game-page-2"),
+	))
 
 	selector.Inline(rows...)
 
@@ -318,6 +485,28 @@ func (g Game) JuegatronCard() string {
 	return b.String()
 }
 
+// InlineCard is a compact card posted in group chats through inline queries
+func (g Game) InlineCard() string {
+	b := &bytes.Buffer{}
+	err := tmpl.ExecuteTemplate(b, "inline", g)
+	if err != nil {
+		logrus.Error("Unable to render template!!, ", err)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Status is a one line summary of the availability of the game
+func (g Game) Status() string {
+	switch {
+	case !g.IsAvailable():
+		return fmt.Sprintf("🔴 Prestado a %s", g.Holder)
+	case g.IsInTransit():
+		return fmt.Sprintf("🚚 En tránsito → %s", g.TransferTo)
+	default:
+		return fmt.Sprintf("🟢 Disponible en %s", g.Location)
+	}
+}
+
 func (g Game) Card() string {
 	b := &bytes.Buffer{}
 	err := tmpl.ExecuteTemplate(b, "card", g)
@@ -360,16 +549,95 @@ func (g Game) Equals(other Game) bool {
 // Take sets the game holder to the given user and registers the take date
 func (g *Game) Take(holder string) {
 	g.Holder = holder
+	g.HolderID = ""
 	g.TakeDate = time.Now().Round(time.Hour * 24)
 	g.SetLeaseTimeDays(21)
 }
 
+// TakeBy is Take for a member, the loan is linked to the member
+func (g *Game) TakeBy(member Member) {
+	g.Take(member.Nickname)
+	g.HolderID = member.TelegramID
+}
+
 // Return marks the game as returned
 func (g *Game) Return() {
 	g.Holder = ""
+	g.HolderID = ""
 	g.TakeDate = time.Time{}
 }
 
+// ReturnTo marks the game as returned and records the location where it was dropped off
+func (g *Game) ReturnTo(location Location) {
+	g.Return()
+	g.CancelTransfer()
+	g.Location = string(location)
+}
+
+// IsInTransit returns true if the game is being moved to another location.
+// A game in transit can't be taken until the destination confirms the arrival
+func (g Game) IsInTransit() bool {
+	return strings.TrimSpace(g.TransferTo) != ""
+}
+
+// RequestTransfer marks the game as in transit to the given location on behalf of the member
+func (g *Game) RequestTransfer(to Location, by Member) {
+	g.TransferTo = string(to)
+	g.TransferDate = time.Now().Round(time.Hour * 24)
+	g.TransferBy = by.TelegramID
+}
+
+// CompleteTransfer moves the game to the destination of the transfer
+func (g *Game) CompleteTransfer() {
+	g.Location = g.TransferTo
+	g.CancelTransfer()
+}
+
+// CancelTransfer keeps the game in its current location
+func (g *Game) CancelTransfer() {
+	g.TransferTo = ""
+	g.TransferDate = time.Time{}
+	g.TransferBy = ""
+}
+
+// TransferDays is the number of days since the transfer was requested
+func (g Game) TransferDays() int {
+	return int(time.Since(g.TransferDate).Round(time.Hour*24).Hours()) / 24
+}
+
+// IsTransferStale returns true if the game has been in transit for longer than the given days
+func (g Game) IsTransferStale(days int) bool {
+	return g.IsInTransit() && !g.TransferDate.IsZero() && g.TransferDays() > days
+}
+
+// IsReserved returns true if the game has been published to an event that hasn't finished yet
+func (g Game) IsReserved() bool {
+	return g.IsReservedAt(time.Now())
+}
+
+// IsReservedAt returns true if the reservation is still in place at the given time.
+// The game is blocked from the moment it is published, so it is at the club when the event starts
+func (g Game) IsReservedAt(now time.Time) bool {
+	if strings.TrimSpace(g.ReservedFor) == "" {
+		return false
+	}
+	return g.ReservedUntil.IsZero() || now.Before(g.ReservedUntil.AddDate(0, 0, 1))
+}
+
+// Reserve blocks the game for the dates of the event
+func (g *Game) Reserve(event EventInfo) {
+	g.ReservedFor = event.Name
+	g.ReservedFrom = event.Start
+	g.ReservedUntil = event.End
+}
+
+// CancelReservation makes the game available for regular loans again
+func (g *Game) CancelReservation() {
+	g.ReservedFor = ""
+	g.ReservedFrom = time.Time{}
+	g.ReservedUntil = time.Time{}
+}
+
 type Games []Game
 
 type MultipleMatchesError struct {
@@ -401,21 +669,32 @@ func (games Games) Get(id string, name string) (*Game, error) {
 	return &matches[0], nil
 }
 
+// Find returns the games whose name or aliases contain the text, best matches first
 func (games Games) Find(name string) []Game {
-
 	matches := []Game{}
-
-	for _, g := range games {
-		if strings.Contains(
-			Norm(g.Name),
-			Norm(name),
-		) {
-			matches = append(matches, g)
+	for _, result := range games.Search(name) {
+		if !result.IsFuzzy() {
+			matches = append(matches, result.Game)
 		}
 	}
 	return matches
 }
 
+// FindSimilar works like Find, but if nothing contains the text it returns the games with a similar name, so typos still find the game.
+// It is meant for searches typed by people, bulk selections must use Find
+func (games Games) FindSimilar(name string) []Game {
+	if matches := games.Find(name); len(matches) > 0 {
+		return matches
+	}
+	similar := []Game{}
+	for _, result := range games.Search(name) {
+		if result.Score >= scoreFuzzy*fuzzyMatchSimilarity {
+			similar = append(similar, result.Game)
+		}
+	}
+	return similar
+}
+
 // CanReturn returns true if at least one game of the list can be returned
 func (games Games) CanReturn() bool {
 	for i := range games {
@@ -429,7 +708,7 @@ func (games Games) CanReturn() bool {
 // CanTake returns true if at least one game of the list can be taken
 func (games Games) CanTake() bool {
 	for i := range games {
-		if games[i].Holder == "" {
+		if games[i].Holder == "" && !games[i].IsInTransit() && !games[i].IsReserved() {
 			return true
 		}
 	}
@@ -448,14 +727,3 @@ func (games Games) FindDuplicates() (duplicate Games, unique Games) {
 	}
 	return duplicate, unique
 }
-
-type Location string
-
-const (
-	LocationGamonal Location = "Gamonal"
-	LocationCentro  Location = "Centro"
-)
-
-func (g Game) IsInLocation(location Location) bool {
-	return strings.EqualFold(strings.TrimSpace(g.Location), string(location))
-}
@@ -0,0 +1,124 @@
+package acnil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v3"
+)
+
+// CategoryCount is a BGG category and the number of games that have it
+type CategoryCount struct {
+	Name  string
+	Count int
+}
+
+// Categories returns the categories of the games, the most common first
+func (games Games) Categories() []CategoryCount {
+	counts := map[string]int{}
+	for _, g := range games {
+		for _, c := range g.Categories {
+			counts[c]++
+		}
+	}
+	categories := []CategoryCount{}
+	for name, count := range counts {
+		categories = append(categories, CategoryCount{Name: name, Count: count})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Count != categories[j].Count {
+			return categories[i].Count > categories[j].Count
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	return categories
+}
+
+// HasCategory returns true if the game has exactly the category, ignoring accents, case and punctuation
+func (g Game) HasCategory(category string) bool {
+	key := textKey(category)
+	for _, c := range g.Categories {
+		if textKey(c) == key {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// maxCategoryButtons is the number of categories offered in the keyboard
+	maxCategoryButtons = 20
+	// maxCategoryData leaves room for the unique of the callback in the 64 bytes telegram allows
+	maxCategoryData = 50
+)
+
+// categoryButtons builds a keyboard with the most common categories, two per row
+func categoryButtons(categories []CategoryCount) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	buttons := []tele.Btn{}
+	for _, c := range categories {
+		if len(buttons) == maxCategoryButtons {
+			break
+		}
+		if len(c.Name) > maxCategoryData {
+			continue
+		}
+		buttons = append(buttons, selector.Data(fmt.Sprintf("%s (%d)", c.Name, c.Count), "category", c.Name))
+	}
+	selector.Inline(selector.Split(2, buttons)...)
+	return selector
+}
+
+func (h *Handler) OnCategories(c tele.Context) error {
+	return h.IsAuthorized(h.onCategories)(c)
+}
+
+// onCategories sends the keyboard to browse the games by category
+func (h *Handler) onCategories(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Categories"), c.Sender())
+
+	games, err := h.GameDB.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	categories := Games(games).Categories()
+	if len(categories) == 0 {
+		return c.Send("Todavía no hay categorías, hay que completar los datos de BGG del inventario", h.mainMenu(c, member))
+	}
+	return c.Send("📚 Elige una categoría. También puedes buscar por categoría, mecánica o diseñador, por ejemplo \"mecanica:deckbuilding\" o \"diseñador:feld\"", categoryButtons(categories))
+}
+
+func (h *Handler) OnCategory(c tele.Context) error {
+	return h.IsAuthorized(h.onCategory)(c)
+}
+
+// onCategory lists the games of the category selected in the keyboard
+func (h *Handler) onCategory(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	defer c.Respond()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Category"), c.Sender()).WithField("Category", c.Data())
+
+	games, err := h.GameDB.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	list := []Game{}
+	for _, g := range games {
+		if g.HasCategory(c.Data()) {
+			list = append(list, g)
+		}
+	}
+	if len(list) == 0 {
+		return c.Send(fmt.Sprintf("No hay ningún juego de la categoría %s", c.Data()), h.mainMenu(c, member))
+	}
+	return h.sendSearchResults(c, log, member, GameList{Source: ListSourceCategory, Arg: c.Data()}, list)
+}
@@ -0,0 +1,31 @@
+package acnil_test
+
+import (
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Game categories", func() {
+	games := acnil.Games{
+		{ID: "1", Name: "Dominion", Categories: []string{"Card Game", "Medieval"}},
+		{ID: "2", Name: "Castillos de Borgoña", Categories: []string{"Dice", "Medieval"}},
+		{ID: "3", Name: "Virus", Categories: []string{"Card Game", "Medical"}},
+		{ID: "4", Name: "Sin datos"},
+	}
+
+	It("Must count the categories, the most common first", func() {
+		Expect(games.Categories()).To(Equal([]acnil.CategoryCount{
+			{Name: "Card Game", Count: 2},
+			{Name: "Medieval", Count: 2},
+			{Name: "Dice", Count: 1},
+			{Name: "Medical", Count: 1},
+		}))
+	})
+
+	It("Must match the exact category", func() {
+		Expect(games[0].HasCategory("card game")).To(BeTrue())
+		Expect(games[0].HasCategory("Card")).To(BeFalse())
+		Expect(games[3].HasCategory("Card Game")).To(BeFalse())
+	})
+})
@@ -0,0 +1,321 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v3"
+)
+
+// ListSource tells where the games of a paginated list come from, so they can be loaded again on every page
+type ListSource string
+
+const (
+	// ListSourceLocation are the games in the location given in Arg
+	ListSourceLocation ListSource = "l"
+	// ListSourceSearch are the games found by name with the text in Arg
+	ListSourceSearch ListSource = "s"
+	// ListSourceQuery are the games that match the query in Arg, see ParseGameQuery
+	ListSourceQuery ListSource = "q"
+	// ListSourceCategory are the games of the category in Arg
+	ListSourceCategory ListSource = "c"
+	// ListSourceJuegatron are the games of the event the member is in, found by name with Arg if it is not empty
+	ListSourceJuegatron ListSource = "j"
+)
+
+const (
+	// listPageSize is the number of games in each page
+	listPageSize = 10
+	// maxListArg keeps the callback data under the 64 bytes telegram allows
+	maxListArg = 40
+
+	listFlagByName    = "n"
+	listFlagAvailable = "d"
+	listNoFlags       = "-"
+)
+
+// GameList is the state of a paginated list of games. It is encoded in the callback data of its buttons
+type GameList struct {
+	Source ListSource
+	Arg    string
+	// Page starts at 0
+	Page int
+	// ByName sorts the games by name instead of keeping the order of the source
+	ByName bool
+	// Available only shows the games that can be taken now
+	Available bool
+}
+
+// CanPaginate returns false if the argument doesn't fit in the callback data
+func (l GameList) CanPaginate() bool {
+	return len(l.Arg) <= maxListArg && !strings.Contains(l.Arg, "\n")
+}
+
+// Data encodes the list as "source|page|flags|arg"
+func (l GameList) Data() string {
+	flags := ""
+	if l.ByName {
+		flags += listFlagByName
+	}
+	if l.Available {
+		flags += listFlagAvailable
+	}
+	if flags == "" {
+		flags = listNoFlags
+	}
+	return strings.Join([]string{string(l.Source), strconv.Itoa(l.Page), flags, l.Arg}, "|")
+}
+
+// ParseGameList reads the data written by Data
+func ParseGameList(data string) (GameList, error) {
+	fields := strings.SplitN(data, "|", 4)
+	if len(fields) < 4 {
+		return GameList{}, fmt.Errorf("invalid list data %q", data)
+	}
+	page, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return GameList{}, fmt.Errorf("invalid list page %q, %w", fields[1], err)
+	}
+	return GameList{
+		Source:    ListSource(fields[0]),
+		Page:      page,
+		ByName:    strings.Contains(fields[2], listFlagByName),
+		Available: strings.Contains(fields[2], listFlagAvailable),
+		Arg:       fields[3],
+	}, nil
+}
+
+// Title describes the games of the list
+func (l GameList) Title() string {
+	switch l.Source {
+	case ListSourceLocation:
+		return fmt.Sprintf("📍 Juegos en %s", l.Arg)
+	case ListSourceCategory:
+		return fmt.Sprintf("📚 Juegos de la categoría %s", l.Arg)
+	case ListSourceJuegatron:
+		if l.Arg != "" {
+			return fmt.Sprintf("🔎 Juegos de Juegatron para \"%s\"", l.Arg)
+		}
+		return "Juegos de Juegatron"
+	default:
+		return fmt.Sprintf("🔎 Juegos para \"%s\"", l.Arg)
+	}
+}
+
+// Apply filters and sorts the games as set in the list
+func (l GameList) Apply(games []Game) []Game {
+	result := []Game{}
+	for _, g := range games {
+		if l.Available && (!g.IsAvailable() || g.IsInTransit() || g.IsReserved()) {
+			continue
+		}
+		result = append(result, g)
+	}
+	if l.ByName {
+		sort.SliceStable(result, func(i, j int) bool { return searchNorm(result[i].Name) < searchNorm(result[j].Name) })
+	}
+	return result
+}
+
+// Render returns the text and the buttons of the current page. The page is moved to the last one if it is out of range
+func (l GameList) Render(games []Game) (string, *tele.ReplyMarkup) {
+	games = l.Apply(games)
+	pages := max(1, (len(games)+listPageSize-1)/listPageSize)
+	l.Page = min(max(l.Page, 0), pages-1)
+
+	start := l.Page * listPageSize
+	end := min(start+listPageSize, len(games))
+	page := games[start:end]
+
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{}
+	lines := []string{fmt.Sprintf("%s (%d)", l.Title(), len(games))}
+	if pages > 1 {
+		lines = append(lines, fmt.Sprintf("Página %d de %d", l.Page+1, pages))
+	}
+	switch {
+	case len(page) > 0:
+	case l.Available:
+		lines = append(lines, "", "No hay ningún juego disponible")
+	default:
+		lines = append(lines, "", "No se han encontrado juegos")
+	}
+	for _, g := range page {
+		rows = append(rows, selector.Row(selector.Data(g.Line(), "list-open", string(l.Source), g.ID)))
+	}
+
+	move := func(text string, page int) tele.Btn {
+		next := l
+		next.Page = page
+		return selector.Data(text, "list", next.Data())
+	}
+	navigation := tele.Row{}
+	if l.Page > 0 {
+		navigation = append(navigation, move("◀️", l.Page-1))
+	}
+	if l.Page < pages-1 {
+		navigation = append(navigation, move("▶️", l.Page+1))
+	}
+	if len(navigation) > 0 {
+		rows = append(rows, navigation)
+	}
+
+	byName := l
+	byName.Page = 0
+	byName.ByName = !l.ByName
+	sortText := "🔤 Ordenar por nombre"
+	if l.ByName {
+		sortText = "↩️ Orden original"
+	}
+	available := l
+	available.Page = 0
+	available.Available = !l.Available
+	availableText := "🟢 Solo disponibles"
+	if l.Available {
+		availableText = "📋 Todos"
+	}
+	rows = append(rows, selector.Row(
+		selector.Data(sortText, "list", byName.Data()),
+		selector.Data(availableText, "list", available.Data()),
+	))
+
+	selector.Inline(rows...)
+	return strings.Join(lines, "\n"), selector
+}
+
+// listGames loads the games of the list from its source
+func (h *Handler) listGames(ctx context.Context, member Member, l GameList) ([]Game, error) {
+	if l.Source == ListSourceJuegatron {
+		event, err := h.juegatronEvent(ctx, member.State.JuegatronEventID())
+		if err != nil {
+			return nil, err
+		}
+		games, err := event.Audit.State(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if l.Arg != "" {
+			return Games(games).FindSimilar(l.Arg), nil
+		}
+		return games, nil
+	}
+
+	gameList, err := h.GameDB.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	games := []Game{}
+	switch l.Source {
+	case ListSourceLocation:
+		for _, g := range gameList {
+			if g.IsInLocation(Location(l.Arg)) {
+				games = append(games, g)
+			}
+		}
+	case ListSourceQuery:
+		games = parseGameQuery(l.Arg, h.locations(ctx)).Filter(gameList)
+	case ListSourceCategory:
+		for _, g := range gameList {
+			if g.HasCategory(l.Arg) {
+				games = append(games, g)
+			}
+		}
+	default:
+		games = Games(gameList).FindSimilar(l.Arg)
+	}
+	return games, nil
+}
+
+// sendGameList sends the first page of the list. If the list can't be paginated, every game is sent as text
+func (h *Handler) sendGameList(send func(what interface{}, opts ...interface{}) error, l GameList, games []Game) error {
+	if !l.CanPaginate() {
+		for _, block := range SendList(games) {
+			if err := send(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	text, markup := l.Render(games)
+	return send(text, markup)
+}
+
+func (h *Handler) OnListPage(c tele.Context) error {
+	return h.IsAuthorized(h.onListPage)(c)
+}
+
+// onListPage edits the list with the page, sort or filter selected
+func (h *Handler) onListPage(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	defer c.Respond()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "ListPage"), c.Sender()).WithField("List", c.Data())
+
+	l, err := ParseGameList(c.Data())
+	if err != nil {
+		log.WithError(err).Error("Failed to parse list")
+		return c.Send("Wops! Algo ha ido mal, vuelve a buscar los juegos")
+	}
+	games, err := h.listGames(ctx, member, l)
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	text, markup := l.Render(games)
+	return c.Edit(text, markup)
+}
+
+func (h *Handler) OnListOpen(c tele.Context) error {
+	return h.IsAuthorized(h.onListOpen)(c)
+}
+
+// onListOpen sends the card of the game pressed in a list
+func (h *Handler) onListOpen(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	defer c.Respond()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "ListOpen"), c.Sender()).WithField("Data", c.Data())
+
+	source, id, _ := strings.Cut(c.Data(), "|")
+	juegatron := ListSource(source) == ListSourceJuegatron
+
+	var games []Game
+	var err error
+	if juegatron {
+		games, err = h.listGames(ctx, member, GameList{Source: ListSourceJuegatron})
+	} else {
+		games, err = h.GameDB.List(ctx)
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+
+	matches := []Game{}
+	for _, g := range games {
+		if g.ID == id {
+			matches = append(matches, g)
+		}
+	}
+	if len(matches) == 0 {
+		return c.Send(fmt.Sprintf("No he encontrado ningún juego con el ID %s, tal vez se ha modificado el excel", id))
+	}
+	for _, g := range matches {
+		if juegatron {
+			err = c.Send(g.JuegatronCard(), g.JuegatronButtons(member.State.JuegatronEventID(), h.PayloadSecret))
+		} else {
+			err = c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
+		}
+		if err != nil {
+			log.Error(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package acnil_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/acnil/acnil-bot/pkg/acnil/matchers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	tele "gopkg.in/telebot.v3"
+)
+
+var _ = Describe("Game list", func() {
+	var games []acnil.Game
+
+	BeforeEach(func() {
+		games = []acnil.Game{}
+		for i := 25; i > 0; i-- {
+			g := acnil.Game{ID: fmt.Sprint(i), Name: fmt.Sprintf("Game %02d", i), Location: "Gamonal"}
+			if i%2 == 0 {
+				g.Holder = "Pepe"
+			}
+			games = append(games, g)
+		}
+	})
+
+	gameButtons := func(markup *tele.ReplyMarkup) []string {
+		out := []string{}
+		for _, b := range ToOneDimension(markup.InlineKeyboard) {
+			if b.Unique == "list-open" {
+				out = append(out, b.Data)
+			}
+		}
+		return out
+	}
+
+	It("Must encode the state in the callback data", func() {
+		l := acnil.GameList{Source: acnil.ListSourceSearch, Arg: "catan | duel", Page: 3, ByName: true, Available: true}
+		Expect(l.Data()).To(Equal("s|3|nd|catan | duel"))
+		parsed, err := acnil.ParseGameList(l.Data())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(Equal(l))
+
+		parsed, err = acnil.ParseGameList("l|0|-|Gamonal")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(Equal(acnil.GameList{Source: acnil.ListSourceLocation, Arg: "Gamonal"}))
+
+		_, err = acnil.ParseGameList("l|x|-|Gamonal")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Must fit in the callback data", func() {
+		l := acnil.GameList{Source: acnil.ListSourceQuery, Arg: strings.Repeat("a", 40), Page: 99, ByName: true, Available: true}
+		Expect(l.CanPaginate()).To(BeTrue())
+		_, markup := l.Render(games)
+		for _, b := range ToOneDimension(markup.InlineKeyboard) {
+			Expect(len("\f" + b.Unique + "|" + b.Data)).To(BeNumerically("<=", 64))
+		}
+
+		l.Arg = strings.Repeat("a", 41)
+		Expect(l.CanPaginate()).To(BeFalse())
+	})
+
+	It("Must show one page with buttons to open the games", func() {
+		text, markup := acnil.GameList{Source: acnil.ListSourceLocation, Arg: "Gamonal"}.Render(games)
+		Expect(text).To(HavePrefix("📍 Juegos en Gamonal (25)\nPágina 1 de 3"))
+		Expect(gameButtons(markup)).To(Equal([]string{"l|25", "l|24", "l|23", "l|22", "l|21", "l|20", "l|19", "l|18", "l|17", "l|16"}))
+
+		buttons := ToOneDimension(markup.InlineKeyboard)
+		Expect(buttons).To(ContainElement(And(WithButtonText("▶️"), WithButtonData("l|1|-|Gamonal"))))
+		Expect(buttons).ToNot(ContainElement(WithButtonText("◀️")))
+	})
+
+	It("Must move to the last page if the list is shorter now", func() {
+		text, markup := acnil.GameList{Source: acnil.ListSourceLocation, Arg: "Gamonal", Page: 7}.Render(games)
+		Expect(text).To(ContainSubstring("Página 3 de 3"))
+		Expect(gameButtons(markup)).To(Equal([]string{"l|5", "l|4", "l|3", "l|2", "l|1"}))
+
+		buttons := ToOneDimension(markup.InlineKeyboard)
+		Expect(buttons).To(ContainElement(And(WithButtonText("◀️"), WithButtonData("l|1|-|Gamonal"))))
+		Expect(buttons).ToNot(ContainElement(WithButtonText("▶️")))
+	})
+
+	It("Must sort and filter the games", func() {
+		l := acnil.GameList{Source: acnil.ListSourceLocation, Arg: "Gamonal", Page: 1, ByName: true, Available: true}
+		text, markup := l.Render(games)
+		Expect(text).To(HavePrefix("📍 Juegos en Gamonal (13)\nPágina 2 de 2"))
+		Expect(gameButtons(markup)).To(Equal([]string{"l|21", "l|23", "l|25"}))
+
+		buttons := ToOneDimension(markup.InlineKeyboard)
+		Expect(buttons).To(ContainElement(And(WithButtonText("↩️ Orden original"), WithButtonData("l|0|d|Gamonal"))))
+		Expect(buttons).To(ContainElement(And(WithButtonText("📋 Todos"), WithButtonData("l|0|n|Gamonal"))))
+	})
+
+	It("Must say when nothing is left after the filter", func() {
+		text, markup := acnil.GameList{Source: acnil.ListSourceSearch, Arg: "x", Available: true}.Render(games[1:2])
+		Expect(text).To(ContainSubstring("No hay ningún juego disponible"))
+		Expect(gameButtons(markup)).To(BeEmpty())
+	})
+})
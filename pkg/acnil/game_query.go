@@ -0,0 +1,551 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v3"
+)
+
+// QueryField is an attribute of the game that can be used in a search query
+type QueryField string
+
+const (
+	QueryFieldPlayers  QueryField = "jugadores"
+	QueryFieldTime     QueryField = "tiempo"
+	QueryFieldWeight   QueryField = "peso"
+	QueryFieldAge      QueryField = "edad"
+	QueryFieldLanguage QueryField = "idioma"
+	QueryFieldCategory QueryField = "categoria"
+	QueryFieldMechanic QueryField = "mecanica"
+	QueryFieldDesigner QueryField = "diseñador"
+	QueryFieldFamily   QueryField = "familia"
+)
+
+// IsText returns true if the field is compared with the names given by BGG instead of a number
+func (f QueryField) IsText() bool {
+	switch f {
+	case QueryFieldCategory, QueryFieldMechanic, QueryFieldDesigner, QueryFieldFamily:
+		return true
+	}
+	return false
+}
+
+// queryFieldAliases are other ways of writing the fields
+var queryFieldAliases = map[string]QueryField{
+	"jugadores":  QueryFieldPlayers,
+	"j":          QueryFieldPlayers,
+	"tiempo":     QueryFieldTime,
+	"duracion":   QueryFieldTime,
+	"t":          QueryFieldTime,
+	"peso":       QueryFieldWeight,
+	"dificultad": QueryFieldWeight,
+	"p":          QueryFieldWeight,
+	"edad":       QueryFieldAge,
+	"e":          QueryFieldAge,
+	"idioma":     QueryFieldLanguage,
+	"texto":      QueryFieldLanguage,
+	"i":          QueryFieldLanguage,
+	"categoria":  QueryFieldCategory,
+	"cat":        QueryFieldCategory,
+	"mecanica":   QueryFieldMechanic,
+	"mec":        QueryFieldMechanic,
+	"disenador":  QueryFieldDesigner,
+	"autor":      QueryFieldDesigner,
+	"familia":    QueryFieldFamily,
+}
+
+// textAliases translate common words into the names used by BGG, keys and values are written like textKey
+var textAliases = map[string]string{
+	"deckbuilding":             "deckbagandpoolbuilding",
+	"cooperativo":              "cooperativegame",
+	"cooperativos":             "cooperativegame",
+	"colocaciondetrabajadores": "workerplacement",
+	"dados":                    "dicerolling",
+	"faroleo":                  "bluffing",
+	"losetas":                  "tileplacement",
+	"cartas":                   "cardgame",
+	"fiesta":                   "partygame",
+	"roles":                    "hiddenroles",
+	"rolesocultos":             "hiddenroles",
+}
+
+// languageLevels are the words accepted by the idioma filter, they match the BGG poll levels
+var languageLevels = map[string]float64{
+	"no":      1,
+	"ninguno": 1,
+	"poco":    2,
+	"algo":    2,
+	"medio":   3,
+	"mucho":   4,
+}
+
+// queryAvailable is the flag that only keeps the games that can be taken now
+const queryAvailable = "disponible"
+
+var queryCondition = regexp.MustCompile(`^(\pL+):(<=|>=|<|>|=)?(\d+(?:[.,]\d+)?|[\pL\pN_\-'.]+)$`)
+
+// GameCondition compares an attribute of the game with a value
+type GameCondition struct {
+	Field QueryField
+	// Op is one of <, <=, >, >= or empty. Empty means a value that suits the game, see Matches
+	Op    string
+	Value float64
+	// Text is the value of the text fields, "_" is written instead of spaces
+	Text string
+}
+
+// textKey keeps only the letters and numbers of the normalized text, so "Deck, Bag, and Pool Building" is "deckbagandpoolbuilding"
+func textKey(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return r
+		}
+		return -1
+	}, Norm(text))
+}
+
+// matchesText returns true if any of the names contains the text
+func matchesText(names []string, text string) bool {
+	key := textKey(text)
+	if key == "" {
+		return false
+	}
+	alias := textAliases[key]
+	for _, name := range names {
+		name := textKey(name)
+		if strings.Contains(name, key) || (alias != "" && strings.Contains(name, alias)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the game meets the condition. Games without the attribute never match.
+// Text fields match if any of the names of the game contains the text, ignoring accents, case and punctuation.
+// Without operator, players must be in the range of the game, age is the minimum age of the player,
+// language is the maximum dependence accepted and any other field must be equal
+func (cond GameCondition) Matches(g Game) bool {
+	var value float64
+	switch cond.Field {
+	case QueryFieldCategory:
+		return matchesText(g.Categories, cond.Text)
+	case QueryFieldMechanic:
+		return matchesText(g.Mechanics, cond.Text)
+	case QueryFieldDesigner:
+		return matchesText(g.Designers, cond.Text)
+	case QueryFieldFamily:
+		return matchesText(g.Families, cond.Text)
+	case QueryFieldPlayers:
+		if g.MinPlayers == 0 && g.MaxPlayers == 0 {
+			return false
+		}
+		switch cond.Op {
+		case "":
+			return float64(g.MinPlayers) <= cond.Value && cond.Value <= float64(g.MaxPlayers)
+		case "<", "<=":
+			value = float64(g.MinPlayers)
+		default:
+			value = float64(g.MaxPlayers)
+		}
+	case QueryFieldTime:
+		value = g.Playingtime
+	case QueryFieldWeight:
+		value = g.AvgWeight
+	case QueryFieldAge:
+		value = float64(g.Age)
+		if cond.Op == "" {
+			return value > 0 && value <= cond.Value
+		}
+	case QueryFieldLanguage:
+		value = float64(g.LanguageLevel())
+		if cond.Op == "" {
+			return value > 0 && value <= cond.Value
+		}
+	}
+	if value == 0 {
+		return false
+	}
+	switch cond.Op {
+	case "<":
+		return value < cond.Value
+	case "<=":
+		return value <= cond.Value
+	case ">":
+		return value > cond.Value
+	case ">=":
+		return value >= cond.Value
+	default:
+		return value == cond.Value
+	}
+}
+
+func (cond GameCondition) String() string {
+	if cond.Field.IsText() {
+		return fmt.Sprintf("%s:%s", cond.Field, strings.ReplaceAll(cond.Text, " ", "_"))
+	}
+	return fmt.Sprintf("%s:%s%s", cond.Field, cond.Op, strconv.FormatFloat(cond.Value, 'f', -1, 64))
+}
+
+// GameQuery filters the games by name, location, availability and BGG attributes
+type GameQuery struct {
+	Name       string
+	Location   Location
+	Available  bool
+	Conditions []GameCondition
+}
+
+// ParseGameQuery reads a query like "jugadores:5 tiempo:<60 peso:<2.5 disponible gamonal" or "mecanica:deckbuilding diseñador:feld".
+// Words that are not filters are searched in the name of the game.
+// It returns false if the text has no filters, so it must be handled as a regular search.
+// A location alone is not a filter either, it may be part of the name of a game
+func ParseGameQuery(text string, locations Locations) (GameQuery, bool) {
+	query := parseGameQuery(text, locations)
+	if !query.HasFilters() {
+		return GameQuery{}, false
+	}
+	return query, true
+}
+
+// parseGameQuery reads the query even if it has no filters, the first location found is used as location filter.
+// Location names may have several words, the longest name is used
+func parseGameQuery(text string, locations Locations) GameQuery {
+	query := GameQuery{}
+	name := []string{}
+	for _, word := range strings.Fields(text) {
+		normalized := Norm(word)
+		if normalized == queryAvailable {
+			query.Available = true
+			continue
+		}
+		if cond, ok := parseGameCondition(normalized); ok {
+			query.Set(cond)
+			continue
+		}
+		name = append(name, word)
+	}
+
+	rest := []string{}
+	for i := 0; i < len(name); i++ {
+		if query.Location == "" {
+			if l, end, ok := findLocationAt(name, i, locations); ok {
+				query.Location = l.Name
+				i = end - 1
+				continue
+			}
+		}
+		rest = append(rest, name[i])
+	}
+	query.Name = strings.Join(rest, " ")
+	return query
+}
+
+// findLocationAt returns the location whose name is the longest run of words starting at start, so names with several words are found.
+// end is the index after the last word of the name
+func findLocationAt(words []string, start int, locations Locations) (LocationInfo, int, bool) {
+	for end := len(words); end > start; end-- {
+		if l, ok := locations.Find(strings.Join(words[start:end], " ")); ok {
+			return l, end, true
+		}
+	}
+	return LocationInfo{}, start, false
+}
+
+func parseGameCondition(word string) (GameCondition, bool) {
+	if !queryCondition.MatchString(word) {
+		return GameCondition{}, false
+	}
+	fragments := queryCondition.FindStringSubmatch(word)
+	field, ok := queryFieldAliases[fragments[1]]
+	if !ok {
+		return GameCondition{}, false
+	}
+	cond := GameCondition{Field: field, Op: fragments[2]}
+	if cond.Op == "=" {
+		cond.Op = ""
+	}
+	if field.IsText() {
+		if cond.Op != "" {
+			return GameCondition{}, false
+		}
+		cond.Text = strings.ReplaceAll(fragments[3], "_", " ")
+		return cond, true
+	}
+	if level, ok := languageLevels[fragments[3]]; ok && field == QueryFieldLanguage {
+		cond.Value = level
+		return cond, true
+	}
+	value, err := strconv.ParseFloat(strings.Replace(fragments[3], ",", ".", 1), 64)
+	if err != nil {
+		return GameCondition{}, false
+	}
+	cond.Value = value
+	return cond, true
+}
+
+// IsEmpty returns true if the query doesn't filter anything
+func (q GameQuery) IsEmpty() bool {
+	return !q.HasFilters() && q.Location == "" && strings.TrimSpace(q.Name) == ""
+}
+
+// HasFilters returns true if the query does more than a name search
+func (q GameQuery) HasFilters() bool {
+	return q.Available || len(q.Conditions) > 0
+}
+
+// Set adds the condition, replacing any other condition on the same field
+func (q *GameQuery) Set(cond GameCondition) {
+	q.Remove(cond.Field)
+	q.Conditions = append(q.Conditions, cond)
+}
+
+// Remove deletes the conditions on the field
+func (q *GameQuery) Remove(field QueryField) {
+	conditions := []GameCondition{}
+	for _, c := range q.Conditions {
+		if c.Field != field {
+			conditions = append(conditions, c)
+		}
+	}
+	q.Conditions = conditions
+}
+
+// Toggle applies a filter written in the query language, or removes it if it was already set
+func (q *GameQuery) Toggle(filter string, locations Locations) {
+	normalized := Norm(strings.TrimSpace(filter))
+	if normalized == queryAvailable {
+		q.Available = !q.Available
+		return
+	}
+	if cond, ok := parseGameCondition(normalized); ok {
+		for _, c := range q.Conditions {
+			if c == cond {
+				q.Remove(cond.Field)
+				return
+			}
+		}
+		q.Set(cond)
+		return
+	}
+	if l, ok := locations.Find(filter); ok {
+		if q.Location == l.Name {
+			q.Location = ""
+			return
+		}
+		q.Location = l.Name
+	}
+}
+
+// Filter returns the games that match the query. If there is a name, games are ranked like in Find
+func (q GameQuery) Filter(games Games) []Game {
+	if strings.TrimSpace(q.Name) != "" {
+		games = games.Find(q.Name)
+	}
+	result := []Game{}
+	for _, g := range games {
+		if q.Matches(g) {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// Matches checks every filter of the query except the name
+func (q GameQuery) Matches(g Game) bool {
+	if q.Available && (!g.IsAvailable() || g.IsInTransit() || g.IsReserved()) {
+		return false
+	}
+	if q.Location != "" && !g.IsInLocation(q.Location) {
+		return false
+	}
+	for _, cond := range q.Conditions {
+		if !cond.Matches(g) {
+			return false
+		}
+	}
+	return true
+}
+
+// String writes the query back in the query language
+func (q GameQuery) String() string {
+	words := []string{}
+	for _, cond := range q.Conditions {
+		words = append(words, cond.String())
+	}
+	if q.Available {
+		words = append(words, queryAvailable)
+	}
+	if q.Location != "" {
+		words = append(words, string(q.Location))
+	}
+	if q.Name != "" {
+		words = append(words, q.Name)
+	}
+	return strings.Join(words, " ")
+}
+
+// LanguageLevel converts the BGG language dependence poll into a level from 1, no text, to 5, unplayable in another language.
+// It returns 0 if it is unknown
+func (g Game) LanguageLevel() int {
+	levels := []string{
+		"no necessary",
+		"some necessary",
+		"moderate",
+		"extensive",
+		"unplayable",
+	}
+	dependence := strings.ToLower(g.LanguageDependence)
+	for i, prefix := range levels {
+		if strings.HasPrefix(dependence, prefix) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// filterLinePrefix is the line of the filter builder message that holds the query
+const filterLinePrefix = "Filtros: "
+
+// filterMessage is the message of the filter builder, the query is read back from it when a button is pressed
+func filterMessage(q GameQuery) string {
+	filters := q.String()
+	if filters == "" {
+		filters = "ninguno"
+	}
+	return fmt.Sprintf("🔎 Búsqueda por características\n%s%s\n\nElige los filtros y pulsa Buscar", filterLinePrefix, filters)
+}
+
+// parseFilterMessage returns the query written by filterMessage
+func parseFilterMessage(text string, locations Locations) GameQuery {
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, filterLinePrefix) {
+			continue
+		}
+		filters := strings.TrimPrefix(line, filterLinePrefix)
+		if filters == "ninguno" {
+			return GameQuery{}
+		}
+		return parseGameQuery(filters, locations)
+	}
+	return GameQuery{}
+}
+
+// filterButton is an option of the filter builder, Filter is written in the query language
+type filterButton struct {
+	Text   string
+	Filter string
+}
+
+var filterButtonRows = [][]filterButton{
+	{{"👥 2", "jugadores:2"}, {"👥 3", "jugadores:3"}, {"👥 4", "jugadores:4"}, {"👥 5", "jugadores:5"}, {"👥 6", "jugadores:6"}},
+	{{"⏱ <30m", "tiempo:<30"}, {"⏱ <60m", "tiempo:<60"}, {"⏱ <90m", "tiempo:<90"}, {"⏱ >90m", "tiempo:>90"}},
+	{{"Ligero", "peso:<2"}, {"Medio", "peso:<3"}, {"Duro", "peso:>=3"}},
+	{{"Edad 6", "edad:6"}, {"Edad 8", "edad:8"}, {"Edad 10", "edad:10"}, {"Edad 12", "edad:12"}},
+	{{"Sin texto", "idioma:no"}, {"Poco texto", "idioma:poco"}},
+	{{"Solo disponibles", queryAvailable}},
+}
+
+// IsSet returns true if the filter written in the query language is part of the query
+func (q GameQuery) IsSet(filter string) bool {
+	if filter == queryAvailable {
+		return q.Available
+	}
+	if cond, ok := parseGameCondition(filter); ok {
+		for _, c := range q.Conditions {
+			if c == cond {
+				return true
+			}
+		}
+		return false
+	}
+	return q.Location != "" && strings.EqualFold(string(q.Location), filter)
+}
+
+// filterButtons builds the keyboard of the filter builder, the selected filters are checked
+func filterButtons(q GameQuery, locations Locations) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	button := func(b filterButton) tele.Btn {
+		text := b.Text
+		if q.IsSet(b.Filter) {
+			text = "✅ " + text
+		}
+		return selector.Data(text, "filter", b.Filter)
+	}
+
+	rows := []tele.Row{}
+	for _, buttons := range filterButtonRows {
+		row := tele.Row{}
+		for _, b := range buttons {
+			row = append(row, button(b))
+		}
+		rows = append(rows, row)
+	}
+	row := tele.Row{}
+	for _, l := range locations {
+		row = append(row, button(filterButton{Text: "📍 " + string(l.Name), Filter: string(l.Name)}))
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, selector.Row(selector.Data("🔎 Buscar", "filter-search")))
+
+	selector.Inline(rows...)
+	return selector
+}
+
+func (h *Handler) OnFilters(c tele.Context) error {
+	return h.IsAuthorized(h.onFilters)(c)
+}
+
+// onFilters sends the filter builder, for people who won't write the query language
+func (h *Handler) onFilters(c tele.Context, member Member) error {
+	return c.Send(filterMessage(GameQuery{}), filterButtons(GameQuery{}, h.locations(context.Background())))
+}
+
+func (h *Handler) OnFilterToggle(c tele.Context) error {
+	return h.IsAuthorized(h.onFilterToggle)(c)
+}
+
+func (h *Handler) onFilterToggle(c tele.Context, member Member) error {
+	defer c.Respond()
+
+	locations := h.locations(context.Background())
+	q := parseFilterMessage(c.Message().Text, locations)
+	q.Toggle(c.Data(), locations)
+	return c.Edit(filterMessage(q), filterButtons(q, locations))
+}
+
+func (h *Handler) OnFilterSearch(c tele.Context) error {
+	return h.IsAuthorized(h.onFilterSearch)(c)
+}
+
+func (h *Handler) onFilterSearch(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	defer c.Respond()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "FilterSearch"), c.Sender())
+
+	q := parseFilterMessage(c.Message().Text, h.locations(ctx))
+	if q.IsEmpty() {
+		return c.Send("Elige al menos un filtro")
+	}
+	log = log.WithField("Query", q.String())
+
+	gameList, err := h.GameDB.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+	list := q.Filter(gameList)
+	if len(list) == 0 {
+		return c.Send(fmt.Sprintf("No hay ningún juego que cumpla %s", q), h.mainMenu(c, member))
+	}
+	return h.sendSearchResults(c, log, member, GameList{Source: ListSourceQuery, Arg: q.String()}, list)
+}
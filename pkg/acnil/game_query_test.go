@@ -0,0 +1,163 @@
+package acnil_test
+
+import (
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Game query", func() {
+	var games acnil.Games
+
+	BeforeEach(func() {
+		games = acnil.Games{
+			{ID: "1", Name: "Catan", Location: "Centro", MinPlayers: 3, MaxPlayers: 4, Playingtime: 90, AvgWeight: 2.3, Age: 10, LanguageDependence: "Some necessary text - easily memorized or small crib sheet"},
+			{ID: "2", Name: "Virus", Location: "Gamonal", MinPlayers: 2, MaxPlayers: 6, Playingtime: 20, AvgWeight: 1.2, Age: 8, LanguageDependence: "No necessary in-game text"},
+			{ID: "3", Name: "Dixit", Location: "Gamonal", Holder: "Pepe", MinPlayers: 3, MaxPlayers: 6, Playingtime: 30, AvgWeight: 1.2, Age: 8, LanguageDependence: "No necessary in-game text"},
+			{ID: "4", Name: "Sin datos", Location: "Gamonal"},
+		}
+	})
+
+	ids := func(list []acnil.Game) []string {
+		out := []string{}
+		for _, g := range list {
+			out = append(out, g.ID)
+		}
+		return out
+	}
+
+	It("Must parse every kind of filter", func() {
+		q, ok := acnil.ParseGameQuery("jugadores:5 tiempo:<60 peso:<2,5 edad:8 idioma:no disponible gamonal", acnil.DefaultLocations)
+		Expect(ok).To(BeTrue())
+		Expect(q.Available).To(BeTrue())
+		Expect(q.Location).To(Equal(acnil.LocationGamonal))
+		Expect(q.Name).To(BeEmpty())
+		Expect(q.Conditions).To(ConsistOf(
+			acnil.GameCondition{Field: acnil.QueryFieldPlayers, Value: 5},
+			acnil.GameCondition{Field: acnil.QueryFieldTime, Op: "<", Value: 60},
+			acnil.GameCondition{Field: acnil.QueryFieldWeight, Op: "<", Value: 2.5},
+			acnil.GameCondition{Field: acnil.QueryFieldAge, Value: 8},
+			acnil.GameCondition{Field: acnil.QueryFieldLanguage, Value: 1},
+		))
+		Expect(ids(q.Filter(games))).To(Equal([]string{"2"}))
+	})
+
+	It("Must not handle a text without filters as a query", func() {
+		_, ok := acnil.ParseGameQuery("catan", acnil.DefaultLocations)
+		Expect(ok).To(BeFalse())
+		_, ok = acnil.ParseGameQuery("gamonal", acnil.DefaultLocations)
+		Expect(ok).To(BeFalse())
+		_, ok = acnil.ParseGameQuery("7 Wonders: Duel", acnil.DefaultLocations)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Must combine filters with the name", func() {
+		q, ok := acnil.ParseGameQuery("j:3 dixit", acnil.DefaultLocations)
+		Expect(ok).To(BeTrue())
+		Expect(q.Name).To(Equal("dixit"))
+		Expect(ids(q.Filter(games))).To(Equal([]string{"3"}))
+	})
+
+	It("Must compare the player range", func() {
+		q, _ := acnil.ParseGameQuery("jugadores:>4", acnil.DefaultLocations)
+		Expect(ids(q.Filter(games))).To(Equal([]string{"2", "3"}))
+		q, _ = acnil.ParseGameQuery("jugadores:<3", acnil.DefaultLocations)
+		Expect(ids(q.Filter(games))).To(Equal([]string{"2"}))
+	})
+
+	It("Must skip games without BGG data", func() {
+		q, _ := acnil.ParseGameQuery("tiempo:<=90", acnil.DefaultLocations)
+		Expect(ids(q.Filter(games))).To(Equal([]string{"1", "2", "3"}))
+	})
+
+	It("Must write the query back", func() {
+		q, _ := acnil.ParseGameQuery("j:5 t:<60 disponible gamonal", acnil.DefaultLocations)
+		Expect(q.String()).To(Equal("jugadores:5 tiempo:<60 disponible Gamonal"))
+	})
+
+	It("Must read locations with several words", func() {
+		locations := acnil.Locations{{Name: "Sala"}, {Name: "Sala Norte"}}
+		q, ok := acnil.ParseGameQuery("disponible sala norte dixit", locations)
+		Expect(ok).To(BeTrue())
+		Expect(q.Location).To(Equal(acnil.Location("Sala Norte")))
+		Expect(q.Name).To(Equal("dixit"))
+
+		again, _ := acnil.ParseGameQuery(q.String(), locations)
+		Expect(again).To(Equal(q))
+
+		q, _ = acnil.ParseGameQuery("disponible sala dixit", locations)
+		Expect(q.Location).To(Equal(acnil.Location("Sala")))
+		Expect(q.Name).To(Equal("dixit"))
+	})
+
+	It("Must toggle filters", func() {
+		q := acnil.GameQuery{}
+		q.Toggle("jugadores:4", acnil.DefaultLocations)
+		q.Toggle("jugadores:5", acnil.DefaultLocations)
+		q.Toggle("disponible", acnil.DefaultLocations)
+		q.Toggle("Centro", acnil.DefaultLocations)
+		Expect(q.String()).To(Equal("jugadores:5 disponible Centro"))
+		Expect(q.IsSet("jugadores:5")).To(BeTrue())
+
+		q.Toggle("jugadores:5", acnil.DefaultLocations)
+		q.Toggle("disponible", acnil.DefaultLocations)
+		q.Toggle("Centro", acnil.DefaultLocations)
+		Expect(q.IsEmpty()).To(BeTrue())
+	})
+
+	Describe("Text filters", func() {
+		var bggGames acnil.Games
+
+		BeforeEach(func() {
+			bggGames = acnil.Games{
+				{ID: "5", Name: "Dominion", Categories: []string{"Card Game", "Medieval"}, Mechanics: []string{"Deck, Bag, and Pool Building", "Hand Management"}, Designers: []string{"Donald X. Vaccarino"}},
+				{ID: "6", Name: "Castillos de Borgoña", Categories: []string{"Dice", "Medieval", "Territory Building"}, Mechanics: []string{"Dice Rolling", "Tile Placement"}, Designers: []string{"Stefan Feld"}},
+				{ID: "7", Name: "Pandemic", Categories: []string{"Medical"}, Mechanics: []string{"Cooperative Game", "Hand Management"}, Designers: []string{"Matt Leacock"}},
+			}
+		})
+
+		It("Must parse the text filters", func() {
+			q, ok := acnil.ParseGameQuery("mecanica:deckbuilding diseñador:Feld categoría:card_game", acnil.DefaultLocations)
+			Expect(ok).To(BeTrue())
+			Expect(q.Conditions).To(ConsistOf(
+				acnil.GameCondition{Field: acnil.QueryFieldMechanic, Text: "deckbuilding"},
+				acnil.GameCondition{Field: acnil.QueryFieldDesigner, Text: "feld"},
+				acnil.GameCondition{Field: acnil.QueryFieldCategory, Text: "card game"},
+			))
+			Expect(q.String()).To(Equal("mecanica:deckbuilding diseñador:feld categoria:card_game"))
+		})
+
+		It("Must not accept operators on text filters", func() {
+			_, ok := acnil.ParseGameQuery("mecanica:>dados", acnil.DefaultLocations)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Must match part of the names ignoring case and punctuation", func() {
+			q, _ := acnil.ParseGameQuery("diseñador:feld", acnil.DefaultLocations)
+			Expect(ids(q.Filter(bggGames))).To(Equal([]string{"6"}))
+			q, _ = acnil.ParseGameQuery("mecanica:hand_management", acnil.DefaultLocations)
+			Expect(ids(q.Filter(bggGames))).To(Equal([]string{"5", "7"}))
+			q, _ = acnil.ParseGameQuery("autor:donald_x.", acnil.DefaultLocations)
+			Expect(ids(q.Filter(bggGames))).To(Equal([]string{"5"}))
+		})
+
+		It("Must translate common words into BGG names", func() {
+			q, _ := acnil.ParseGameQuery("mecanica:deckbuilding", acnil.DefaultLocations)
+			Expect(ids(q.Filter(bggGames))).To(Equal([]string{"5"}))
+			q, _ = acnil.ParseGameQuery("mec:cooperativo", acnil.DefaultLocations)
+			Expect(ids(q.Filter(bggGames))).To(Equal([]string{"7"}))
+			q, _ = acnil.ParseGameQuery("mecanica:dados categoria:medieval", acnil.DefaultLocations)
+			Expect(ids(q.Filter(bggGames))).To(Equal([]string{"6"}))
+		})
+
+		It("Must skip games without BGG data", func() {
+			q, _ := acnil.ParseGameQuery("categoria:medieval", acnil.DefaultLocations)
+			Expect(ids(q.Filter(games))).To(BeEmpty())
+		})
+	})
+
+	It("Must read the language dependence from BGG", func() {
+		Expect(games[0].LanguageLevel()).To(Equal(2))
+		Expect(games[3].LanguageLevel()).To(Equal(0))
+	})
+})
@@ -44,19 +44,19 @@ var _ = Describe("A game card", func() {
 			})
 
 			It("Must NOT contain return button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Devolver")))
 			})
 			It("Must contain take button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).To(ContainElement(WithButtonText("Tomar Prestado")))
 			})
 			It("Must not have a button to increase the time by a few days", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Dar mas tiempo")))
 			})
 			It("Must contain > button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).To(ContainElement(WithButtonText(">")))
 			})
 			Describe("Ïf return date is set but holder is not", func() {
@@ -64,47 +64,58 @@ var _ = Describe("A game card", func() {
 					game.ReturnDate = time.Now().Add(-24 * 30 * time.Hour)
 				})
 				It("Must not have a button to increase the time by a few days", func() {
-					buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+					buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 					Expect(buttons).ToNot(ContainElement(WithButtonText("Dar mas tiempo")))
 				})
 				It("Must append data to all buttons", func() {
-					buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+					buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 					for _, button := range buttons {
 						Expect(button).To(WithButtonData(button.Data))
 					}
 				})
 			})
 			Describe("in the 2nd button page", func() {
-				Describe("When it is in Gamonal", func() {
+				It("Must contain Mover de ubicación button", func() {
+					buttons := ToOneDimension(game.ButtonsForPage(member, 2, "").InlineKeyboard)
+					Expect(buttons).To(ContainElement(WithButtonText("Mover de ubicación")))
+				})
+				Describe("When the locations are listed", func() {
+					var (
+						locations acnil.Locations
+					)
 					BeforeEach(func() {
+						locations = acnil.Locations{
+							{Name: acnil.LocationGamonal},
+							{Name: acnil.LocationCentro},
+							{Name: "Almacén"},
+						}
 						game.Location = string(acnil.LocationGamonal)
 					})
-					It("Must contain Mover al Centro button", func() {
-						buttons := ToOneDimension(game.ButtonsForPage(member, 2).InlineKeyboard)
+					It("Must contain a button for every other location", func() {
+						buttons := ToOneDimension(game.LocationButtons(locations, "").InlineKeyboard)
 						var button telebot.InlineButton
-						Expect(buttons).To(ContainElement(WithButtonText("Mover al Centro"), &button))
+						Expect(buttons).To(ContainElement(WithButtonText("Mover a Centro"), &button))
+						Expect(button.Data).To(Equal(acnil.NewGamePayload(game).Encode("", "", "") + "|" + locations[1].Key()))
+						l, ok := locations.FindKey(locations[1].Key())
+						Expect(ok).To(BeTrue())
+						Expect(l.Name).To(Equal(acnil.LocationCentro))
+						Expect(buttons).To(ContainElement(WithButtonText("Mover a Almacén")))
 					})
-				})
-				Describe("When it is in Centro", func() {
-					BeforeEach(func() {
-						game.Location = string(acnil.LocationCentro)
-					})
-					It("Must contain Mover a Gamonal button", func() {
-						buttons := ToOneDimension(game.ButtonsForPage(member, 2).InlineKeyboard)
-						var button telebot.InlineButton
-						Expect(buttons).To(ContainElement(WithButtonText("Mover a Gamonal"), &button))
+					It("Must NOT contain a button for the current location", func() {
+						buttons := ToOneDimension(game.LocationButtons(locations, "").InlineKeyboard)
+						Expect(buttons).ToNot(ContainElement(WithButtonText("Mover a Gamonal")))
 					})
 				})
 				It("Must contain Actualizar comentario button", func() {
-					buttons := ToOneDimension(game.ButtonsForPage(member, 2).InlineKeyboard)
+					buttons := ToOneDimension(game.ButtonsForPage(member, 2, "").InlineKeyboard)
 					Expect(buttons).To(ContainElement(WithButtonText("Actualizar comentario")))
 				})
 				It("Must contain < button", func() {
-					buttons := ToOneDimension(game.ButtonsForPage(member, 2).InlineKeyboard)
+					buttons := ToOneDimension(game.ButtonsForPage(member, 2, "").InlineKeyboard)
 					Expect(buttons).To(ContainElement(WithButtonText("<")))
 				})
 				It("Must append data to all buttons", func() {
-					buttons := ToOneDimension(game.ButtonsForPage(member, 2).InlineKeyboard)
+					buttons := ToOneDimension(game.ButtonsForPage(member, 2, "").InlineKeyboard)
 					for _, button := range buttons {
 						Expect(button).To(WithButtonData(button.Data))
 					}
@@ -126,11 +137,11 @@ var _ = Describe("A game card", func() {
 				Expect(card).To(ContainSubstring(member.Nickname))
 			})
 			It("Must contain return button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).To(ContainElement(WithButtonText("Devolver")))
 			})
 			It("Must not contain take button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Tomar Prestado")))
 			})
 
@@ -144,7 +155,7 @@ var _ = Describe("A game card", func() {
 						Expect(card).To(ContainSubstring("⚠️"))
 					})
 					It("should have a button to increase the time by a few days", func() {
-						buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+						buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 						Expect(buttons).To(ContainElement(WithButtonText("Dar mas tiempo")))
 					})
 				})
@@ -164,16 +175,16 @@ var _ = Describe("A game card", func() {
 				Expect(card).To(ContainSubstring(game.Holder))
 			})
 			It("Must contain return button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).To(ContainElement(WithButtonText("Devolver")))
 			})
 			It("Must NOT contain take button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Tomar Prestado")))
 			})
 
 			It("should NOT have a button to increase the time by a few days", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Dar mas tiempo")))
 			})
 
@@ -187,7 +198,7 @@ var _ = Describe("A game card", func() {
 						Expect(card).To(ContainSubstring("⚠️"))
 					})
 					It("should NOT have a button to increase the time by a few days", func() {
-						buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+						buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 						Expect(buttons).ToNot(ContainElement(WithButtonText("Dar mas tiempo")))
 					})
 
@@ -195,12 +206,45 @@ var _ = Describe("A game card", func() {
 			})
 		})
 
+		Describe("in transit to another location", func() {
+			BeforeEach(func() {
+				game.Holder = ""
+				game.Location = string(acnil.LocationGamonal)
+				game.TransferTo = string(acnil.LocationCentro)
+				game.TransferDate = time.Now().Add(-10 * 24 * time.Hour)
+			})
+			AfterEach(func() {
+				game.CancelTransfer()
+			})
+			It("Must show the destination on the card", func() {
+				Expect(game.Card()).To(ContainSubstring("En tránsito → Centro"))
+			})
+			It("Must NOT contain take button", func() {
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
+				Expect(buttons).ToNot(ContainElement(WithButtonText("Tomar Prestado")))
+			})
+			It("Must contain a button to confirm the arrival", func() {
+				buttons := ToOneDimension(game.ButtonsForPage(member, 2, "").InlineKeyboard)
+				Expect(buttons).To(ContainElement(WithButtonText("Confirmar llegada a Centro")))
+				Expect(buttons).ToNot(ContainElement(WithButtonText("Mover de ubicación")))
+			})
+			It("Must be flagged as stale after the given days", func() {
+				Expect(game.IsTransferStale(7)).To(BeTrue())
+				Expect(game.IsTransferStale(15)).To(BeFalse())
+			})
+			It("Must be moved to the destination when the transfer completes", func() {
+				game.CompleteTransfer()
+				Expect(game.Location).To(Equal(string(acnil.LocationCentro)))
+				Expect(game.IsInTransit()).To(BeFalse())
+			})
+		})
+
 		Describe("with BGG Data", func() {
 			BeforeEach(func() {
 				game.BGG = "123"
 			})
 			It("Must contain more info button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).To(ContainElement(WithButtonText("Mas información")))
 			})
 		})
@@ -209,7 +253,7 @@ var _ = Describe("A game card", func() {
 				game.BGG = ""
 			})
 			It("Must Not contain more info button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Mas información")))
 			})
 		})
@@ -237,7 +281,7 @@ var _ = Describe("A game card", func() {
 					game.ReturnDate = time.Now().Add(-30 * 24 * time.Hour)
 				})
 				It("must not have a button to increase the time by a few days", func() {
-					buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+					buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 					Expect(buttons).ToNot(ContainElement(WithButtonText("Dar mas tiempo")))
 				})
 			})
@@ -258,16 +302,16 @@ var _ = Describe("A game card", func() {
 				Expect(card).To(ContainSubstring(game.Holder))
 			})
 			It("Must contain return button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).To(ContainElement(WithButtonText("Devolver")))
 			})
 			It("Must NOT contain take button", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Tomar Prestado")))
 			})
 
 			It("should NOT have a button to increase the time by a few days", func() {
-				buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+				buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 				Expect(buttons).ToNot(ContainElement(WithButtonText("Dar mas tiempo")))
 			})
 
@@ -281,7 +325,7 @@ var _ = Describe("A game card", func() {
 						Expect(card).To(ContainSubstring("⚠️"))
 					})
 					It("should have a button to increase the time by a few days", func() {
-						buttons := ToOneDimension(game.Buttons(member).InlineKeyboard)
+						buttons := ToOneDimension(game.Buttons(member, "").InlineKeyboard)
 						Expect(buttons).To(ContainElement(WithButtonText("Dar mas tiempo")))
 					})
 
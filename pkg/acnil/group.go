@@ -0,0 +1,205 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v3"
+)
+
+// GroupNotifier posts announcements to the club group chat.
+// The group is opt-in, nothing is sent if ChatID is not set
+type GroupNotifier struct {
+	ChatID int64
+	Bot    Sender
+}
+
+func (n *GroupNotifier) Enabled() bool {
+	return n != nil && n.ChatID != 0
+}
+
+func (n *GroupNotifier) send(msg string) error {
+	if !n.Enabled() || msg == "" {
+		return nil
+	}
+	_, err := n.Bot.Send(tele.ChatID(n.ChatID), msg)
+	return err
+}
+
+// Announcements returns the messages for the group given the snapshot before the audit entries were applied.
+// New games are announced, as well as games that have been returned and are available again
+func Announcements(before Snapshot, entries []AuditEntry) []string {
+	messages := []string{}
+	for _, entry := range entries {
+		g := entry.Game()
+		switch entry.Type {
+		case AuditEntryTypeNew:
+			messages = append(messages, fmt.Sprintf("🆕 Nuevo juego en la ludoteca!\n%s\n📍 %s", g.Line(), g.Location))
+		case AuditEntryTypeUpdate:
+			previous := before.Find(*g)
+			if previous != nil && !previous.IsAvailable() && g.IsAvailable() {
+				messages = append(messages, fmt.Sprintf("🔙 Vuelve a estar disponible\n%s\n📍 %s", g.Line(), g.Location))
+			}
+		}
+	}
+	return messages
+}
+
+// AnnounceChanges sends the announcements for the audit entries to the group
+func (n *GroupNotifier) AnnounceChanges(before Snapshot, entries []AuditEntry) error {
+	if !n.Enabled() {
+		return nil
+	}
+	for _, msg := range Announcements(before, entries) {
+		if err := n.send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OverdueSummary lists the games that should have been returned already, empty if there are none.
+// The holders are named after the member linked to the loan, so renames and stale names in the sheet don't matter
+func OverdueSummary(games []Game, resolver HolderResolver) string {
+	overdue := []Game{}
+	for _, g := range games {
+		if !g.IsAvailable() && g.IsLeaseExpired() {
+			overdue = append(overdue, g)
+		}
+	}
+	if len(overdue) == 0 {
+		return ""
+	}
+	sort.Slice(overdue, func(i, j int) bool { return overdue[i].LeaseDays() > overdue[j].LeaseDays() })
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "⏰ Hay %d juegos pendientes de devolver:\n", len(overdue))
+	for _, g := range overdue {
+		if m, ok := resolver.Resolve(g); ok {
+			g.Holder = m.Nickname
+		}
+		fmt.Fprintf(b, "%s, %d días\n", g.Line(), g.LeaseDays())
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// SendOverdueSummary posts the list of overdue games to the group
+func (n *GroupNotifier) SendOverdueSummary(ctx context.Context, gameDB ROGameDatabase, membersDB MembersDatabase) error {
+	games, err := gameDB.List(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to list game database, %w", err)
+	}
+	members, err := membersDB.List(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to list members database, %w", err)
+	}
+	return n.send(OverdueSummary(games, NewHolderResolver(members)))
+}
+
+// RunWeeklySummary sends the overdue summary every week at the given day and hour
+func (n *GroupNotifier) RunWeeklySummary(ctx context.Context, gameDB ROGameDatabase, membersDB MembersDatabase, weekday time.Weekday, hour int) {
+	if !n.Enabled() {
+		return
+	}
+	log := logrus.WithField(ilog.FieldHandler, "Weekly Summary")
+
+	ticker := time.NewTicker(time.Hour)
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				if now.Weekday() != weekday || now.Hour() != hour {
+					continue
+				}
+				log.Info("Sending overdue summary")
+				if err := n.SendOverdueSummary(ctx, gameDB, membersDB); err != nil {
+					log.WithError(err).Error("Failed to send overdue summary")
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// groupCommand matches the commands that are answered in the group chat
+var groupCommand = regexp.MustCompile(`^/disponible(@\w+)?(\s|$)`)
+
+// maxGroupResults avoids flooding the group with long lists
+const maxGroupResults = 5
+
+// ChatMiddleware only allows private chats, except for the read-only commands sent from the configured group
+func (h *Handler) ChatMiddleware(next tele.HandlerFunc) tele.HandlerFunc {
+	private := OnlyPrivateChatMiddleware(next)
+	return func(ctx tele.Context) error {
+		if h.isGroupCommand(ctx) {
+			return next(ctx)
+		}
+		return private(ctx)
+	}
+}
+
+func (h *Handler) isGroupCommand(c tele.Context) bool {
+	if h.GroupChatID == 0 || c.Chat() == nil || c.Chat().ID != h.GroupChatID || c.Message() == nil {
+		return false
+	}
+	return groupCommand.MatchString(c.Message().Text)
+}
+
+// OnAvailable answers /disponible <name> with short read only cards.
+// Loan changing buttons are only available in the private chat
+func (h *Handler) OnAvailable(c tele.Context) error {
+	if c.Chat() != nil && c.Chat().Type == tele.ChatPrivate {
+		return h.IsAuthorized(func(c tele.Context, _ Member) error { return h.onAvailable(c) })(c)
+	}
+	return h.onAvailable(c)
+}
+
+func (h *Handler) onAvailable(c tele.Context) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Available"), c.Sender())
+
+	text := strings.TrimSpace(c.Message().Payload)
+	if text == "" {
+		return c.Reply("Dime qué juego buscas, por ejemplo /disponible Catan")
+	}
+	log = log.WithField(ilog.FieldText, text)
+
+	gameList, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Reply("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+
+	found := Games(gameList).FindSimilar(text)
+	if len(found) == 0 && mayBeAnID.MatchString(text) {
+		if g, _ := Games(gameList).Get(mayBeAnID.FindStringSubmatch(text)[1], ""); g != nil {
+			found = append(found, *g)
+		}
+	}
+	if len(found) == 0 {
+		return c.Reply(fmt.Sprintf("No he encontrado ningún juego con el nombre %s", text))
+	}
+
+	cards := []string{}
+	for i, g := range found {
+		if i == maxGroupResults {
+			more := fmt.Sprintf("Y %d más...", len(found)-maxGroupResults)
+			if h.BotName != "" {
+				more += fmt.Sprintf(" búscalos en privado con @%s", h.BotName)
+			}
+			cards = append(cards, more)
+			break
+		}
+		cards = append(cards, g.InlineCard())
+	}
+
+	log.WithField("Results", len(found)).Info("Answering availability")
+	return c.Reply(strings.Join(cards, "\n\n"))
+}
@@ -0,0 +1,65 @@
+package acnil_test
+
+import (
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Group announcements", func() {
+	var before acnil.Snapshot
+
+	BeforeEach(func() {
+		before = acnil.Snapshot{
+			{ID: "1", Name: "Held", Holder: "Someone", Location: string(acnil.LocationCentro)},
+			{ID: "2", Name: "Available", Location: string(acnil.LocationGamonal)},
+		}
+	})
+
+	It("Must announce new games", func() {
+		messages := acnil.Announcements(before, []acnil.AuditEntry{
+			acnil.NewAuditEntry(acnil.Game{ID: "3", Name: "Brand new", Location: string(acnil.LocationGamonal)}, acnil.AuditEntryTypeNew),
+		})
+		Expect(messages).To(HaveLen(1))
+		Expect(messages[0]).To(ContainSubstring("Nuevo juego"))
+		Expect(messages[0]).To(ContainSubstring("Brand new"))
+	})
+
+	It("Must announce games that are back in stock", func() {
+		messages := acnil.Announcements(before, []acnil.AuditEntry{
+			acnil.NewAuditEntry(acnil.Game{ID: "1", Name: "Held", Location: string(acnil.LocationCentro)}, acnil.AuditEntryTypeUpdate),
+		})
+		Expect(messages).To(HaveLen(1))
+		Expect(messages[0]).To(ContainSubstring("Vuelve a estar disponible"))
+	})
+
+	It("Must not announce games that are taken", func() {
+		messages := acnil.Announcements(before, []acnil.AuditEntry{
+			acnil.NewAuditEntry(acnil.Game{ID: "2", Name: "Available", Holder: "Someone"}, acnil.AuditEntryTypeUpdate),
+		})
+		Expect(messages).To(BeEmpty())
+	})
+
+	Describe("The overdue summary", func() {
+		It("Must list games that should have been returned", func() {
+			summary := acnil.OverdueSummary([]acnil.Game{
+				{ID: "1", Name: "Late", Holder: "Someone", TakeDate: time.Now().Add(-60 * 24 * time.Hour), ReturnDate: time.Now().Add(-30 * 24 * time.Hour)},
+				{ID: "2", Name: "On time", Holder: "Someone", TakeDate: time.Now(), ReturnDate: time.Now().Add(7 * 24 * time.Hour)},
+			}, acnil.HolderResolver{})
+			Expect(summary).To(ContainSubstring("Late (Someone)"))
+			Expect(summary).ToNot(ContainSubstring("On time"))
+		})
+		It("Must name the holder after the linked member", func() {
+			pepe := acnil.Member{Nickname: "Pepito", TelegramID: "1", PreviousNicknames: []string{"Pepe"}}
+			summary := acnil.OverdueSummary([]acnil.Game{
+				{ID: "1", Name: "Late", Holder: "Pepe", HolderID: "1", TakeDate: time.Now().Add(-60 * 24 * time.Hour), ReturnDate: time.Now().Add(-30 * 24 * time.Hour)},
+			}, acnil.NewHolderResolver([]acnil.Member{pepe}))
+			Expect(summary).To(ContainSubstring("Late (Pepito)"))
+		})
+		It("Must be empty if everything is on time", func() {
+			Expect(acnil.OverdueSummary([]acnil.Game{{ID: "1", Name: "Available"}}, acnil.HolderResolver{})).To(BeEmpty())
+		})
+	})
+})
@@ -1,9 +1,11 @@
 package acnil
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
@@ -12,8 +14,11 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/acnil/acnil-bot/pkg/events"
 	httplambda "github.com/acnil/acnil-bot/pkg/httpLambda"
 	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/acnil/acnil-bot/pkg/labels"
+	"github.com/acnil/acnil-bot/pkg/sheetsparser"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/exp/slices"
 	tele "gopkg.in/telebot.v3"
@@ -23,39 +28,63 @@ var (
 	mainMenu = &tele.ReplyMarkup{ResizeKeyboard: true}
 	// Reply buttons.
 	btnMyGames          = mainMenu.Text("🎲 Mis Juegos")
-	btnEnGamonal        = mainMenu.Text("Lista de Gamonal")
-	btnEnCentro         = mainMenu.Text("Lista del Centro")
 	btnRename           = mainMenu.Text("🧍 Cambiar Nombre")
 	btnJuegatron        = mainMenu.Text("Juegatron!")
 	btnExitJuegatron    = mainMenu.Text("Salir de Juegatron")
 	btnListJuegatron    = mainMenu.Text("Lista de Juegatron")
+	btnJuegatronReport  = mainMenu.Text("Cierre de Juegatron")
 	cancelJuegatronMenu = &tele.ReplyMarkup{ResizeKeyboard: true}
 	btnCancelJuegatron  = cancelJuegatronMenu.Text("Cancelar préstamo")
 
 	btnAdmin = mainMenu.Text("👮 Administrador")
 
+	btnRecommend  = mainMenu.Text("🎲 ¿A qué jugamos?")
+	btnCategories = mainMenu.Text("📚 Categorías")
+
 	adminMenu           = &tele.ReplyMarkup{ResizeKeyboard: true}
 	btnForgotten        = adminMenu.Text("Juegos olvidados?")
 	btnNotInAnyPlace    = adminMenu.Text("Juegos en ningún sitio")
 	btnGamesTakenByUser = adminMenu.Text("Juegos cogidos por usuario")
+	btnPendingTransfers = adminMenu.Text("Traslados pendientes")
+	btnStocktake        = adminMenu.Text("Hacer inventario")
+	btnLabels           = adminMenu.Text("Imprimir etiquetas")
+	btnJuegatronStats   = adminMenu.Text("Estadísticas de Juegatron")
+	btnEventPublish     = adminMenu.Text("Publicar juegos en evento")
+	btnLinkHolders      = adminMenu.Text("Vincular préstamos a socios")
 	btnCancelAdminMenu  = adminMenu.Text("Atrás")
 
 	cancelMenu = &tele.ReplyMarkup{ResizeKeyboard: true}
 	btnCancel  = cancelMenu.Text("Cancel")
 
+	stocktakeMenu        = &tele.ReplyMarkup{ResizeKeyboard: true}
+	btnFinishStocktake   = stocktakeMenu.Text("Terminar inventario")
+	btnCancelStocktake   = stocktakeMenu.Text("Cancelar inventario")
+	btnStocktakeLocation = stocktakeMenu.Text("Cambiar ubicación")
+
 	startMenu = &tele.ReplyMarkup{ResizeKeyboard: true}
 	btnStart  = startMenu.Text("Empezar!")
 )
 
 // mainMenuReplyMarkup Given a member, builds the main menu keyboard with appropriate buttons.
-func mainMenuReplyMarkup(member Member) *tele.ReplyMarkup {
+// There is a button to list the games of each location in the registry,
+// and the event mode is only offered while the member can lend games in an event.
+func mainMenuReplyMarkup(member Member, locations Locations, events Events) *tele.ReplyMarkup {
 	markup := &tele.ReplyMarkup{ResizeKeyboard: true}
 
 	std := []tele.Row{
 		markup.Row(btnMyGames),
-		markup.Row(btnEnGamonal, btnEnCentro),
-		markup.Row(btnRename),
-		// markup.Row(btnJuegatron),
+	}
+	for i := 0; i < len(locations); i += 2 {
+		row := markup.Row(markup.Text(locationListButtonText(locations[i].Name)))
+		if i+1 < len(locations) {
+			row = append(row, markup.Text(locationListButtonText(locations[i+1].Name)))
+		}
+		std = append(std, row)
+	}
+	std = append(std, markup.Row(btnRecommend, btnCategories))
+	std = append(std, markup.Row(btnRename))
+	if len(events) > 0 {
+		std = append(std, markup.Row(btnJuegatron))
 	}
 	if member.Permissions == PermissionAdmin {
 		std = append(std, markup.Row(btnAdmin))
@@ -65,12 +94,24 @@ func mainMenuReplyMarkup(member Member) *tele.ReplyMarkup {
 	return markup
 }
 
+var locationListButton = regexp.MustCompile(`^Lista del? (.+)$`)
+
+func locationListButtonText(location Location) string {
+	return "Lista de " + string(location)
+}
+
 func adminMenuReplyMarkup(member Member) *tele.ReplyMarkup {
 	markup := &tele.ReplyMarkup{ResizeKeyboard: true}
 	markup.Reply(
 		markup.Row(btnForgotten),
 		markup.Row(btnNotInAnyPlace),
 		markup.Row(btnGamesTakenByUser),
+		markup.Row(btnPendingTransfers),
+		markup.Row(btnStocktake),
+		markup.Row(btnLabels),
+		markup.Row(btnJuegatronStats),
+		markup.Row(btnEventPublish),
+		markup.Row(btnLinkHolders),
 		markup.Row(btnCancelAdminMenu),
 	)
 	markup.ResizeKeyboard = true
@@ -83,6 +124,7 @@ func juegatronReplyMarkup() *tele.ReplyMarkup {
 	markup.Reply(
 		markup.Row(btnExitJuegatron),
 		markup.Row(btnListJuegatron),
+		markup.Row(btnJuegatronReport),
 	)
 	markup.ResizeKeyboard = true
 	return markup
@@ -104,6 +146,12 @@ func init() {
 		cancelJuegatronMenu.Row(btnCancelJuegatron),
 	)
 	cancelJuegatronMenu.RemoveKeyboard = true
+
+	stocktakeMenu.Reply(
+		stocktakeMenu.Row(btnStocktakeLocation),
+		stocktakeMenu.Row(btnFinishStocktake),
+		stocktakeMenu.Row(btnCancelStocktake),
+	)
 }
 
 // MembersDatabase gives access the the current member using the application
@@ -127,22 +175,64 @@ type Sender interface {
 	Send(to tele.Recipient, what interface{}, opts ...interface{}) (*tele.Message, error)
 }
 
+// LocationDatabase gives access to the registry of places where games are stored
+type LocationDatabase interface {
+	List(ctx context.Context) ([]LocationInfo, error)
+}
+
+// AttendeeDatabase gives access to the people registered in the Juegatron
+type AttendeeDatabase interface {
+	List(ctx context.Context) ([]Attendee, error)
+	Append(ctx context.Context, attendee Attendee) error
+}
+
+// EventDatabase gives access to the registry of events
+type EventDatabase interface {
+	List(ctx context.Context) ([]EventInfo, error)
+}
+
+// CatalogueDatabase gives access to the games lent in an event
+type CatalogueDatabase interface {
+	List(ctx context.Context) ([]Game, error)
+	Append(ctx context.Context, games ...Game) error
+}
+
 // ROAudit gives read only access to the audit database
 type ROAudit interface {
 	Find(ctx context.Context, query Query) ([]AuditEntry, error)
 }
 
 type Handler struct {
-	MembersDB MembersDatabase
-	GameDB    GameDatabase
-	Audit     ROAudit
+	MembersDB  MembersDatabase
+	GameDB     GameDatabase
+	Audit      ROAudit
+	LocationDB LocationDatabase
+
+	// Events is the registry of the events where games are lent, OpenEvent gives access to the databases of each one
+	Events    EventDatabase
+	OpenEvent EventOpener
+	// JuegatronMaxGames is the number of games an attendee can have at the same time, 0 means no limit
+	JuegatronMaxGames int
 
-	JuegatronGameDB ROGameDatabase
-	JuegatronAudit  *JuegatronAudit
+	// PayloadSecret signs the data of the buttons so it can't be forged, it is not signed if it is empty.
+	// Buttons sent with a different secret are rejected
+	PayloadSecret string
+
+	// BotName is the telegram username of the bot, used to build deep links
+	BotName string
+
+	// GroupChatID is the club group where read only commands are answered, 0 disables group support
+	GroupChatID int64
+
+	// StaleTransferDays is the number of days a game can be in transit before
+	// it is flagged in the pending transfers list. Defaults to DefaultStaleTransferDays
+	StaleTransferDays int
 
 	Bot Sender
 }
 
+const DefaultStaleTransferDays = 7
+
 func AttatchLambdaContext(next tele.HandlerFunc) tele.HandlerFunc {
 	return func(ctx tele.Context) error {
 		update := ctx.Update()
@@ -153,34 +243,61 @@ func AttatchLambdaContext(next tele.HandlerFunc) tele.HandlerFunc {
 
 func (h *Handler) Register(handlerGroup *tele.Group) {
 	handlerGroup.Use(AttatchLambdaContext)
-	handlerGroup.Use(OnlyPrivateChatMiddleware)
+	handlerGroup.Use(h.ChatMiddleware)
 
 	handlerGroup.Handle("/start", h.Start)
 	handlerGroup.Handle(&btnStart, h.Start)
 
 	handlerGroup.Handle(tele.OnText, h.OnText)
+	handlerGroup.Handle(tele.OnQuery, h.OnQuery)
+	handlerGroup.Handle("/disponible", h.OnAvailable)
+	handlerGroup.Handle("/filtros", h.OnFilters)
+	handlerGroup.Handle("/jugamos", h.OnRecommend)
+	handlerGroup.Handle(&btnRecommend, h.OnRecommend)
+	handlerGroup.Handle("\frecommend", h.OnRecommendAnswer)
+	handlerGroup.Handle("/categorias", h.OnCategories)
+	handlerGroup.Handle(&btnCategories, h.OnCategories)
+	handlerGroup.Handle("\fcategory", h.OnCategory)
+	handlerGroup.Handle("\flist", h.OnListPage)
+	handlerGroup.Handle("\flist-open", h.OnListOpen)
+	handlerGroup.Handle("\ffilter", h.OnFilterToggle)
+	handlerGroup.Handle("\ffilter-search", h.OnFilterSearch)
 	handlerGroup.Handle("\ftake", h.OnTake)
 	handlerGroup.Handle("\ftake-all", h.OnTakeAll)
 	handlerGroup.Handle("\freturn", h.OnReturn)
 	handlerGroup.Handle("\freturn-all", h.OnReturnAll)
+	// Buttons sent before the location was part of the data of return-all
+	handlerGroup.Handle("\freturn-all-location", h.OnReturnAll)
+	handlerGroup.Handle("\freturn-location", h.OnReturnLocation)
 	handlerGroup.Handle("\fmore", h.OnMore)
 	handlerGroup.Handle("\fauthorise", h.OnAuthorise)
 	handlerGroup.Handle("\fhistory", h.OnHistory)
 	handlerGroup.Handle("\fextendLease", h.OnExtendLease)
 	handlerGroup.Handle("\fgame-page-1", h.OnGamePage(1))
 	handlerGroup.Handle("\fgame-page-2", h.OnGamePage(2))
+	handlerGroup.Handle("\fselect-location", h.OnSelectLocation)
 	handlerGroup.Handle("\fswitch-location", h.OnSwitchLocation)
+	handlerGroup.Handle("\ftransfer-confirm", h.OnConfirmTransfer)
+	handlerGroup.Handle("\ftransfer-cancel", h.OnCancelTransfer)
 	handlerGroup.Handle("\fupdate-comment", h.OnUpdateCommentButton)
+	handlerGroup.Handle("\fadd-alias", h.OnAddAliasButton)
+	handlerGroup.Handle("\fcancel-reservation", h.OnCancelReservation)
 	handlerGroup.Handle(&btnMyGames, h.MyGames)
-	handlerGroup.Handle(&btnEnGamonal, h.IsAuthorized(h.InGamonal))
-	handlerGroup.Handle(&btnEnCentro, h.IsAuthorized(h.InCentro))
 	handlerGroup.Handle(&btnRename, h.Rename)
 	handlerGroup.Handle(&btnJuegatron, h.OnJuegatron)
+	handlerGroup.Handle("\fjuegatron-event", h.OnJuegatronEvent)
 	handlerGroup.Handle(&btnExitJuegatron, h.OnExitJuegatron)
 	handlerGroup.Handle(&btnListJuegatron, h.OnListJuegatron)
 	handlerGroup.Handle("\fjuegatron-return", h.OnJuegatronReturn)
-	handlerGroup.Handle("\fundo-juegatron-return", h.OnUndoJuegatronReturn)
+	handlerGroup.Handle("\fundo-juegatron", h.OnUndoJuegatron)
+	// Buttons sent before takes could be undone
+	handlerGroup.Handle("\fundo-juegatron-return", h.OnUndoJuegatron)
 	handlerGroup.Handle("\fjuegatron-take", h.OnJuegatronTake)
+	handlerGroup.Handle("\fjuegatron-attendee", h.OnJuegatronPickAttendee)
+	handlerGroup.Handle("\fjuegatron-correct", h.OnJuegatronCorrect)
+	handlerGroup.Handle(&btnJuegatronReport, h.OnJuegatronReport)
+	handlerGroup.Handle("\fjuegatron-close", h.OnJuegatronClose)
+	handlerGroup.Handle("\fjuegatron-close-confirm", h.OnJuegatronCloseConfirm)
 	handlerGroup.Handle(&btnCancelJuegatron, h.OnCancelJuegatron)
 
 	handlerGroup.Handle(&btnCancel, h.Cancel)
@@ -190,10 +307,34 @@ func (h *Handler) Register(handlerGroup *tele.Group) {
 	handlerGroup.Handle(&btnForgotten, h.OnForgotten)
 	handlerGroup.Handle(&btnNotInAnyPlace, h.OnNotInAnyPlace)
 	handlerGroup.Handle(&btnGamesTakenByUser, h.OnGamesTakenByUser)
+	handlerGroup.Handle(&btnPendingTransfers, h.OnPendingTransfers)
+	handlerGroup.Handle(&btnStocktake, h.OnStocktake)
+	handlerGroup.Handle(&btnLabels, h.OnLabels)
+	handlerGroup.Handle(&btnJuegatronStats, h.OnJuegatronStats)
+	handlerGroup.Handle("\fjuegatron-stats", h.OnJuegatronStatsEvent)
+	handlerGroup.Handle(&btnEventPublish, h.OnEventPublish)
+	handlerGroup.Handle("\fevent-publish", h.OnEventPublishEvent)
+	handlerGroup.Handle("\fevent-publish-confirm", h.OnEventPublishConfirm)
+	handlerGroup.Handle("\flabels", h.OnLabelsLocation)
+	handlerGroup.Handle(&btnLinkHolders, h.OnLinkHolders)
+	handlerGroup.Handle("\flink-holder", h.OnLinkHolderButton)
+	handlerGroup.Handle("\fremind-overdue", h.OnRemindOverdue)
+	handlerGroup.Handle(&btnStocktakeLocation, h.OnStocktake)
+	handlerGroup.Handle(&btnFinishStocktake, h.OnFinishStocktake)
+	handlerGroup.Handle(&btnCancelStocktake, h.OnCancelStocktake)
+	handlerGroup.Handle("\fstocktake-location", h.OnStocktakeLocation)
+	handlerGroup.Handle("\fstocktake-apply", h.OnApplyStocktake)
 }
 
 func OnlyPrivateChatMiddleware(next tele.HandlerFunc) tele.HandlerFunc {
 	return func(ctx tele.Context) error {
+		// Inline queries don't belong to a chat, they can be used from anywhere
+		if ctx.Chat() == nil {
+			if ctx.Query() != nil {
+				next(ctx)
+			}
+			return nil
+		}
 		if ctx.Chat().Type == tele.ChatPrivate {
 			next(ctx)
 		}
@@ -217,6 +358,9 @@ func (h *Handler) IsAuthorized(next func(tele.Context, Member) error) func(tele.
 		}
 		if m == nil {
 			newMember := NewMemberFromTelegram(c.Sender())
+			if payload, ok := ParseStartPayload(startPayload(c)); ok {
+				newMember.State.SetPendingStart(payload)
+			}
 			sender, _ := json.Marshal(c.Sender())
 			log.WithField(ilog.FieldName, newMember.Nickname).WithField("sender", string(sender)).Info("Registering new user")
 			m = &newMember
@@ -250,6 +394,13 @@ func (h *Handler) IsAuthorized(next func(tele.Context, Member) error) func(tele.
 			log.
 				WithField(ilog.FieldName, m.Nickname).
 				Info("Permission denied")
+			// Remember where the member wanted to go, new members already have it set
+			if payload, ok := ParseStartPayload(startPayload(c)); ok && m.Row != "" && m.State.Data != payload.String() {
+				m.State.SetPendingStart(payload)
+				if err := h.MembersDB.Update(context.Background(), *m); err != nil {
+					log.WithError(err).Error("Failed to save pending start")
+				}
+			}
 			return c.Send(fmt.Sprintf(`Hola,
 He notificado a un administrador de que necesitas acceso. Te avisaré cuando lo tengas.
 
@@ -318,6 +469,12 @@ func (h *Handler) onAuthorise(c tele.Context, _ Member) error {
 		return nil
 	}
 
+	pending, hasPending := StartPayload{}, false
+	if newMember.State.Is(StateActionPendingStart) {
+		pending, hasPending = ParseStartPayload(newMember.State.Data)
+		newMember.State.Clear()
+	}
+
 	newMember.Permissions = PermissionYes
 	err = h.MembersDB.Update(context.Background(), *newMember)
 	if err != nil {
@@ -328,6 +485,18 @@ func (h *Handler) onAuthorise(c tele.Context, _ Member) error {
 	if err != nil {
 		log.Errorf("Error sending message to new member, %s", err)
 	}
+	if hasPending {
+		log.WithField("Payload", pending.String()).Info("Opening pending start")
+		send := func(what interface{}, opts ...interface{}) error {
+			_, err := h.Bot.Send(newMember, what, opts...)
+			return err
+		}
+		ctx, cancel := GetContext(c)
+		defer cancel()
+		if err := h.openStartPayload(ctx, send, *newMember, pending); err != nil {
+			log.Errorf("Error sending pending start to new member, %s", err)
+		}
+	}
 
 	return c.Edit(fmt.Sprintf("Se ha dado acceso al usuario %s de forma correcta", newMember.Nickname))
 }
@@ -336,10 +505,29 @@ func (h *Handler) Start(c tele.Context) error {
 	return h.IsAuthorized(h.start)(c)
 }
 
+// startPayload returns the parameter of the /start command, empty for any other message
+func startPayload(c tele.Context) string {
+	if c.Message() == nil || !strings.HasPrefix(c.Message().Text, "/start") {
+		return ""
+	}
+	return c.Message().Payload
+}
+
 func (h *Handler) start(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Start"), c.Sender())
 	log.Info(c.Text())
 
+	if payload, ok := ParseStartPayload(startPayload(c)); ok {
+		log.WithField("Payload", payload.String()).Info("Deep link")
+		if member.State.Is(StateActionStocktake) && payload.Prefix == StartPayloadGamePrefix {
+			// Labels scanned during a stocktake mark the game as seen
+			return h.stocktakeSeen(c, member, []string{payload.Value})
+		}
+		ctx, cancel := GetContext(c)
+		defer cancel()
+		return h.openStartPayload(ctx, c.Send, member, payload)
+	}
+
 	return c.Send(fmt.Sprintf(`Bienvenido al bot de Acnil,
 Tu nombre es %s y se ha generado en base a tu nombre de Telegram. Es el nombre que aparecerá en el excel cuando reserves un juego. Si quieres cambiarlo, utiliza el teclado a continuación.
 
@@ -349,7 +537,42 @@ También puedo buscar por parte del nombre. por ejemplo, Intenta decir "Explodin
 
 Por último, si me mandas el ID de un juego, también puedo encontrarlo.
 
-Si algo va mal, habla con @MetalBlueberry`, member.Nickname), mainMenuReplyMarkup(member))
+Si no sabes a qué jugar, busca por características, por ejemplo "jugadores:5 tiempo:<60 peso:<2.5 disponible", o usa /filtros. También puedes buscar por mecánica o diseñador, como "mecanica:deckbuilding" o "diseñador:feld", o mirar las /categorias. Si estáis en la ludoteca y no sabéis qué sacar, pregúntame /jugamos
+
+Si algo va mal, habla con @MetalBlueberry`, member.Nickname), h.mainMenu(c, member))
+}
+
+// openStartPayload sends the target of a deep link
+func (h *Handler) openStartPayload(ctx context.Context, send func(what interface{}, opts ...interface{}) error, member Member, payload StartPayload) error {
+	switch payload.Prefix {
+	case StartPayloadGamePrefix:
+		gameList, err := h.GameDB.List(ctx)
+		if err != nil {
+			return send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n"+err.Error(), h.mainMenuWithContext(ctx, member))
+		}
+		id := payload.Value
+		if mayBeAnID.MatchString(id) {
+			id = mayBeAnID.FindStringSubmatch(id)[1]
+		}
+		g, err := Games(gameList).Get(id, "")
+		if mmErr, ok := err.(MultipleMatchesError); ok {
+			for _, block := range SendList(mmErr.Matches) {
+				send(block, h.mainMenuWithContext(ctx, member))
+			}
+			return nil
+		}
+		if g == nil {
+			return send(fmt.Sprintf("No he encontrado ningún juego con el ID %s", id), h.mainMenuWithContext(ctx, member))
+		}
+		return send(g.Card(), g.Buttons(member, h.PayloadSecret))
+	case StartPayloadLocationPrefix:
+		location, ok := h.locations(ctx).FindBySlug(payload.Value)
+		if !ok {
+			return send("No conozco esa ubicación", h.mainMenuWithContext(ctx, member))
+		}
+		return h.inLocation(send, member, location.Name)
+	}
+	return nil
 }
 
 func (h *Handler) skipGroup(next func(c tele.Context) error) func(c tele.Context) error {
@@ -370,18 +593,35 @@ func (h *Handler) OnText(c tele.Context) error {
 func (h *Handler) onText(c tele.Context, member Member) error {
 	switch {
 	case member.State.Is(StateActionJuegatron):
-		return h.onJuegatronText(c, member)
-	case member.State.Is(StateActionJuegatronWaitingForName):
-		return h.onJuegatronTakeWaitForName(c, member)
+		return h.InJuegatronEvent(h.onJuegatronText)(c, member)
+	case member.State.Is(StateActionJuegatronWaitingForName), member.State.Is(StateActionJuegatronCorrecting):
+		return h.InJuegatronEvent(h.onJuegatronTakeWaitForName)(c, member)
+	case member.State.Is(StateActionJuegatronNewAttendee):
+		return h.InJuegatronEvent(h.onJuegatronNewAttendee)(c, member)
 	case isACommandForSure.Match([]byte(c.Text())):
 		return h.onSearchByText(c, member)
 	case member.State.Is(StateActionRename):
 		return h.onRename(c, member)
 	case member.State.Is(StateActionUpdateComment):
 		return h.onUpdateComment(c, member)
+	case member.State.Is(StateActionAddAlias) && member.Permissions == PermissionAdmin:
+		return h.onAddAlias(c, member)
+	case member.State.Is(StateActionLinkHolder) && member.Permissions == PermissionAdmin:
+		return h.onLinkHolder(c, member)
 	case member.State.Is(StateGetGamesTakenByUser):
 		return h.onGetGamesTakenByUser(c, member)
+	case member.State.Is(StateActionStocktake):
+		return h.onStocktakeText(c, member)
+	case member.State.Is(StateActionEventPublish):
+		return h.IsAdmin(h.onEventPublishSelection)(c, member)
 	default:
+		// The location list buttons are only handled without state, in a state the text belongs to the state handler
+		if locationListButton.MatchString(c.Text()) {
+			name := locationListButton.FindStringSubmatch(c.Text())[1]
+			if location, ok := h.locations(context.Background()).Find(name); ok {
+				return h.inLocation(c.Send, member, location.Name)
+			}
+		}
 		return h.onSearchByText(c, member)
 	}
 }
@@ -397,6 +637,47 @@ func GetContext(c tele.Context) (_ context.Context, cancelFunc func()) {
 	return context.WithDeadline(ctx, time.Now().Add(5*time.Second))
 }
 
+// locations returns the location registry, falling back to the default locations if it cannot be loaded
+func (h Handler) locations(ctx context.Context) Locations {
+	if h.LocationDB == nil {
+		return DefaultLocations
+	}
+	locations, err := h.LocationDB.List(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load locations, using defaults")
+		return DefaultLocations
+	}
+	if len(locations) == 0 {
+		return DefaultLocations
+	}
+	return locations
+}
+
+// mainMenu builds the main menu keyboard for the member, the registries are read with the context of the request
+func (h Handler) mainMenu(c tele.Context, member Member) *tele.ReplyMarkup {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	return h.mainMenuWithContext(ctx, member)
+}
+
+// mainMenuWithContext builds the main menu keyboard for the member
+func (h Handler) mainMenuWithContext(ctx context.Context, member Member) *tele.ReplyMarkup {
+	return mainMenuReplyMarkup(member, h.locations(ctx), h.availableEvents(ctx, member))
+}
+
+// availableEvents returns the events where the member can lend games now, none if the registry cannot be loaded
+func (h Handler) availableEvents(ctx context.Context, member Member) Events {
+	if h.Events == nil {
+		return nil
+	}
+	events, err := h.Events.List(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load events")
+		return nil
+	}
+	return Events(events).Available(member, time.Now())
+}
+
 func (h *Handler) onSearchByText(c tele.Context, member Member) error {
 	ctx, cancel := GetContext(c)
 	defer cancel()
@@ -411,11 +692,20 @@ func (h *Handler) onSearchByText(c tele.Context, member Member) error {
 		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
 	}
 
+	if query, ok := ParseGameQuery(c.Text(), h.locations(ctx)); ok {
+		log.WithField("Query", query.String()).Info("Searching with filters")
+		list := query.Filter(gameList)
+		if len(list) == 0 {
+			return c.Send(fmt.Sprintf("No hay ningún juego que cumpla %s", query), h.mainMenu(c, member))
+		}
+		return h.sendSearchResults(c, log, member, GameList{Source: ListSourceQuery, Arg: c.Text()}, list)
+	}
+
 	lines := strings.Split(c.Text(), "\n")
 
 	list := Games{}
 	for _, line := range lines {
-		search, err := h.textSearchGame(log, c, gameList, line, mainMenuReplyMarkup(member))
+		search, err := h.textSearchGame(log, c, gameList, line, h.mainMenu(c, member))
 		if err != nil {
 			log.WithError(err).Error("Failed to search games")
 			return c.Send("No he podido buscar el juego, inténtalo otra vez")
@@ -427,34 +717,7 @@ func (h *Handler) onSearchByText(c tele.Context, member Member) error {
 	}
 
 	if len(lines) == 1 {
-		switch {
-		case len(list) <= 3:
-			for _, g := range list {
-				log.
-					WithField("Game", g.Name).
-					Info("Found Game")
-				err := c.Send(g.Card(), g.Buttons(member))
-				if err != nil {
-					log.Error(err)
-				}
-			}
-		default:
-			log.WithField("count", len(list)).Info("Found multiple games")
-			c.Send(`He encontrado varios juegos, 
-intenta darme el nombre concreto del juego que buscas. 
-También puedes seleccionar un juego por su ID, solo dime el número de la lista.
-
-Esto es todo lo que he encontrado`, mainMenu)
-			for _, block := range SendList(list) {
-				err := c.Send(block, mainMenuReplyMarkup(member))
-				if err != nil {
-					log.Error(err)
-				}
-				if ctx.Err() != nil {
-					return c.Send(fmt.Sprintf("Wops! Parece que no me ha dado tiempo... Envíame algo mas simple.\n%s", ctx.Err()))
-				}
-			}
-		}
+		return h.sendSearchResults(c, log, member, GameList{Source: ListSourceSearch, Arg: c.Text()}, list)
 	} else {
 		duplicate, list := list.FindDuplicates()
 		if len(duplicate) > 0 {
@@ -470,19 +733,9 @@ Esto es todo lo que he encontrado`, mainMenu)
 			}
 		}
 
-		selector := &tele.ReplyMarkup{}
-		rows := []tele.Row{}
-		rows = append(rows, selector.Row(
-			selector.Data("Devolver todos", "return-all"),
-		))
-		rows = append(rows, selector.Row(
-			selector.Data("Tomar prestados todos", "take-all"),
-		))
-
-		selector.Inline(rows...)
-
-		for _, block := range SendList(list) {
-			err := c.Send(block, selector)
+		locations := h.locations(ctx)
+		for _, chunk := range h.bulkChunks(list, locations) {
+			err := c.Send(JoinList(chunk), h.bulkButtons(chunk, locations, true, true))
 			if err != nil {
 				log.Error(err)
 			}
@@ -495,6 +748,30 @@ Esto es todo lo que he encontrado`, mainMenu)
 	return nil
 }
 
+// sendSearchResults sends the cards of the games if there are a few, or the paginated list otherwise.
+// source describes how to find the games again when the list changes page
+func (h *Handler) sendSearchResults(c tele.Context, log *logrus.Entry, member Member, source GameList, list []Game) error {
+	switch {
+	case len(list) <= 3:
+		for _, g := range list {
+			log.
+				WithField("Game", g.Name).
+				Info("Found Game")
+			err := c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
+			if err != nil {
+				log.Error(err)
+			}
+		}
+	default:
+		log.WithField("count", len(list)).Info("Found multiple games")
+		err := h.sendGameList(c.Send, source, list)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+	return nil
+}
+
 var mayBeAnID = regexp.MustCompile(`^[/]?0*(\d+\w*)$`)
 var isAnIDForSure = regexp.MustCompile(`^[/]?(\d+)$`)
 var isACommandForSure = regexp.MustCompile(`^/.*$`)
@@ -524,7 +801,7 @@ func (h *Handler) textSearchGame(log *logrus.Entry, c tele.Context, gameList Gam
 	}
 
 	if !isAnIDForSure.MatchString(text) {
-		list := gameList.Find(text)
+		list := gameList.FindSimilar(text)
 		if len(list) > 1 {
 			c.Send(fmt.Sprintf("He encontrado %d juegos con el nombre \"%s\"", len(list), text), markup)
 			return list, nil
@@ -551,6 +828,10 @@ func (h *Handler) textSearchGame(log *logrus.Entry, c tele.Context, gameList Gam
 		return []Game{*getResult}, nil
 	}
 
+	if g, ok := gameList.Suggest(text); ok {
+		c.Send(fmt.Sprintf("No he podido encontrar ningún juego con el nombre %s\n¿Quisiste decir %s? /%04s", text, g.Name, g.ID), markup)
+		return []Game{}, nil
+	}
 	c.Send(fmt.Sprintf("No he podido encontrar ningún juego con el nombre %s", text), markup)
 	return []Game{}, nil
 
@@ -564,50 +845,22 @@ func (h *Handler) onTakeAll(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Take All"), c.Sender())
 	defer c.Respond()
 
-	allGames, err := h.GameDB.List(context.Background())
+	payload, _, err := h.bulkPayloadFromCallback(c)
 	if err != nil {
-		log.WithError(err).Error("Failed to get game from DB")
-		c.Send("No he podido buscar el juego en la base de datos, inténtalo otra vez")
-		return nil
+		log.WithError(err).Error("Invalid payload")
+		return c.Edit("Datos inválidos, vuelve a realizar la búsqueda")
 	}
 
-	games := Games{}
-	hasBeenModified := false
-
-	lines := strings.Split(c.Message().Text, "\n")
-	for _, line := range lines {
-		lineGame, err := NewGameFromLine(line)
-		if err != nil {
-			log.WithError(err).Errorf("Invalid line")
-			return c.Edit("Datos inválidos, vuelve a realizar la búsqueda")
-		}
-
-		log.
-			WithField("Game", lineGame.Name).
-			WithField("ID", lineGame.ID)
-
-		g, err := Games(allGames).Get(lineGame.ID, lineGame.Name)
-		if err != nil {
-			log.Info("Multiple matches for the game")
-			return c.Send(fmt.Sprintf("Hay multiples coincidencias para el juego %s, %s.\n%s\nNo puedo realizar la operación", lineGame.ID, lineGame.Name, err.(MultipleMatchesError).Matches))
-		}
-		if g == nil {
-			log.Info("Game not found")
-			return c.Send(fmt.Sprintf("No he encontrado el juego %s: \"%s\", ¿Se ha modificado el excel? vuelve a darme la lista", lineGame.ID, lineGame.Name))
-		}
-
-		if g.Holder != lineGame.Holder {
-			log.Info("It has been modified!")
-			hasBeenModified = true
-		}
-
-		games = append(games, *g)
+	games, ok := h.gamesFromBulkPayload(c, log, payload)
+	if !ok {
+		return nil
 	}
-
-	if hasBeenModified {
-		log.Info("Detected conflict on TakeAll")
-		c.Send("Parece que los datos han cambiado, revisa la información y vuelve a intentarlo")
-		return h.bulk(c.Edit, games)
+	for _, g := range games {
+		if g.IsInTransit() || g.IsReserved() {
+			log.WithField("Game", g.Name).WithField("ID", g.ID).Info("Detected conflict on TakeAll")
+			c.Send("Parece que los datos han cambiado, revisa la información y vuelve a intentarlo")
+			return h.bulk(c.Edit, games)
+		}
 	}
 
 	log.Info("Taking all games")
@@ -616,7 +869,7 @@ func (h *Handler) onTakeAll(c tele.Context, member Member) error {
 			WithField("Game", games[i].Name).
 			WithField("ID", games[i].ID).
 			Info("Taking game")
-		games[i].Take(member.Nickname)
+		games[i].TakeBy(member)
 	}
 
 	if err := h.GameDB.Update(context.Background(), games...); err != nil {
@@ -635,17 +888,15 @@ func (h *Handler) OnTake(c tele.Context) error {
 func (h *Handler) onTake(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Take"), c.Sender())
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log = log.WithField("ID", payload.ID)
 
-	getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
 	if err != nil {
 		log.WithError(err).Error("Unable to get game from DB")
 		c.Edit(err.Error())
@@ -657,14 +908,15 @@ func (h *Handler) onTake(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g = *getResult
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	if !g.IsAvailable() {
+	if payload.HolderChanged(g) || !g.IsAvailable() {
 		err := c.Edit("Parece que alguien ha modificado los datos, te envío los últimos actualizados")
 		if err != nil {
 			log.Error(err)
 		}
-		err = c.Send(g.Card(), g.Buttons(member))
+		err = c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
 		if err != nil {
 			log.Error(err)
 		}
@@ -672,7 +924,25 @@ func (h *Handler) onTake(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g.Take(member.Nickname)
+	if g.IsInTransit() {
+		err := c.Edit(g.Card(), g.Buttons(member, h.PayloadSecret))
+		if err != nil {
+			log.Error(err)
+		}
+		log.Info("Game is in transit")
+		return c.Respond(&tele.CallbackResponse{Text: "El juego está en tránsito, no se puede coger hasta que llegue a " + g.TransferTo})
+	}
+
+	if g.IsReserved() {
+		err := c.Edit(g.Card(), g.Buttons(member, h.PayloadSecret))
+		if err != nil {
+			log.Error(err)
+		}
+		log.Info("Game is reserved")
+		return c.Respond(&tele.CallbackResponse{Text: "El juego está reservado para " + g.ReservedFor})
+	}
+
+	g.TakeBy(member)
 
 	err = h.GameDB.Update(context.TODO(), g)
 	if err != nil {
@@ -681,7 +951,7 @@ func (h *Handler) onTake(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	c.Edit(g.Card(), g.Buttons(member))
+	c.Edit(g.Card(), g.Buttons(member, h.PayloadSecret))
 	log.Info("Game taken")
 	return c.Respond()
 }
@@ -690,65 +960,44 @@ func (h *Handler) OnReturnAll(c tele.Context) error {
 	return h.IsAuthorized(h.onReturnAll)(c)
 }
 
+// onReturnAll asks where the games of the message have been dropped off, and returns them once the value of the button has the location
 func (h *Handler) onReturnAll(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Return All"), c.Sender())
 	defer c.Respond()
 
-	allGames, err := h.GameDB.List(context.Background())
+	payload, value, err := h.bulkPayloadFromCallback(c)
 	if err != nil {
-		log.WithError(err).Error("Failed to get game from DB")
-		c.Send("No he podido buscar el juego en la base de datos, inténtalo otra vez")
-		return nil
+		log.WithError(err).Error("Invalid payload")
+		return c.Edit("Datos inválidos, vuelve a realizar la búsqueda")
 	}
-	games := Games{}
-	hasBeenModified := false
-
-	lines := strings.Split(c.Message().Text, "\n")
-	for _, line := range lines {
-		lineGame, err := NewGameFromLine(line)
-		if err != nil {
-			log.WithError(err).Errorf("Invalid line")
-			return c.Edit("Datos inválidos, vuelve a realizar la búsqueda")
-		}
-
-		log.
-			WithField("Game", lineGame.Name).
-			WithField("ID", lineGame.ID)
 
-		g, err := Games(allGames).Get(lineGame.ID, lineGame.Name)
-		if err != nil {
-			log.Info("Multiple matches for the game")
-			return c.Send(fmt.Sprintf("Hay multiples coincidencias para el juego %s, %s.\n%s\nNo puedo realizar la operación", lineGame.ID, lineGame.Name, err.(MultipleMatchesError).Matches))
-		}
-		if g == nil {
-			log.Info("Game not found")
-			return c.Send(fmt.Sprintf("No he encontrado el juego %s: \"%s\", ¿Se ha modificado el excel? vuelve a darme la lista", lineGame.ID, lineGame.Name))
-		}
-
-		if g.Holder != lineGame.Holder {
-			log.
-				WithField("CurrentHolder", g.Holder).
-				WithField("ExpectedHolder", lineGame.Holder).
-				Info("It has been modified!")
-			hasBeenModified = true
-		}
+	games, ok := h.gamesFromBulkPayload(c, log, payload)
+	if !ok {
+		return nil
+	}
 
-		games = append(games, *g)
+	if value == "" {
+		log.Info("Asking for return location")
+		return c.Edit(c.Message().Text, h.returnAllLocationButtons(h.locations(context.Background()), payload))
 	}
 
-	if hasBeenModified {
-		log.Info("Detected conflict on ReturnAll")
-		c.Send("Parece que los datos han cambiado, revisa la información y vuelve a intentarlo")
-		return h.bulk(c.Edit, games)
+	location, ok := h.locations(context.Background()).FindKey(value)
+	if !ok {
+		log.WithField(ilog.FieldLocation, value).Warn("Unknown location")
+		return c.Edit("No conozco esa ubicación, vuelve a realizar la búsqueda")
 	}
+	log = log.WithField(ilog.FieldLocation, location.Name)
 
 	log.Info("Returning all games")
 	for i := range games {
+		if games[i].IsAvailable() {
+			continue
+		}
 		log.
 			WithField("Game", games[i].Name).
 			WithField("ID", games[i].ID).
 			Info("Return game")
-		games[i].Return()
+		games[i].ReturnTo(location.Name)
 	}
 
 	if err := h.GameDB.Update(context.Background(), games...); err != nil {
@@ -759,6 +1008,58 @@ func (h *Handler) onReturnAll(c tele.Context, member Member) error {
 	return h.bulk(c.Edit, games)
 }
 
+// gamesFromBulkPayload loads from the database the games of the payload of a bulk message.
+// If the data has changed since the message was sent, it refreshes the message and returns ok = false
+func (h *Handler) gamesFromBulkPayload(c tele.Context, log *logrus.Entry, payload GamesPayload) (_ Games, ok bool) {
+	allGames, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to get game from DB")
+		c.Send("No he podido buscar el juego en la base de datos, inténtalo otra vez")
+		return nil, false
+	}
+
+	games, err := payload.Find(allGames)
+	var mmErr MultipleMatchesError
+	var nfErr GameNotFoundError
+	switch {
+	case errors.As(err, &mmErr):
+		log.Info("Multiple matches for the game")
+		c.Send(fmt.Sprintf("Hay multiples coincidencias para el juego %s.\n%s\nNo puedo realizar la operación", mmErr.Matches[0].ID, JoinList(mmErr.Matches)))
+		return nil, false
+	case errors.As(err, &nfErr):
+		log.WithField("ID", nfErr.ID).Info("Game not found")
+		c.Send(nfErr.Error())
+		return nil, false
+	case err != nil:
+		log.WithError(err).Error("Failed to find the games of the payload")
+		c.Send("Wops! Algo ha ido mal, vuelve a realizar la búsqueda")
+		return nil, false
+	}
+
+	if payload.Changed(games) {
+		log.Info("Detected conflict on bulk operation")
+		c.Send("Parece que los datos han cambiado, revisa la información y vuelve a intentarlo")
+		h.bulk(c.Edit, games)
+		return nil, false
+	}
+	return games, true
+}
+
+// returnAllLocationButtons asks where the games of the payload have been dropped off
+func (h *Handler) returnAllLocationButtons(locations Locations, payload GamesPayload) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{}
+
+	for _, location := range locations {
+		rows = append(rows, selector.Row(
+			selector.Data("Devueltos en "+string(location.Name), "return-all", payload.Encode(h.PayloadSecret, "return-all", location.Key()), location.Key()),
+		))
+	}
+
+	selector.Inline(rows...)
+	return selector
+}
+
 func (h *Handler) OnReturn(c tele.Context) error {
 	return h.IsAuthorized(h.onReturn)(c)
 }
@@ -766,16 +1067,14 @@ func (h *Handler) OnReturn(c tele.Context) error {
 func (h *Handler) onReturn(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Return"), c.Sender())
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log = log.WithField("ID", payload.ID)
 
-	getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
 	if err != nil {
 		log.WithError(err).Error("Unable to get from GameDB")
 		c.Edit(err.Error())
@@ -787,14 +1086,15 @@ func (h *Handler) onReturn(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g = *getResult
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	if g.IsAvailable() {
+	if payload.HolderChanged(g) || g.IsAvailable() {
 		err := c.Edit("Parece que alguien ha modificado los datos. te envío los últimos actualizados")
 		if err != nil {
 			log.Print(err)
 		}
-		err = c.Send(g.Card(), g.Buttons(member))
+		err = c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
 		if err != nil {
 			log.Print(err)
 		}
@@ -802,7 +1102,65 @@ func (h *Handler) onReturn(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g.Return()
+	err = c.Edit(g.Card(), g.ReturnButtons(h.locations(context.Background()), h.PayloadSecret))
+	if err != nil {
+		log.WithError(err).Error("Failed to edit card")
+	}
+	log.Info("Asking for return location")
+	return c.Respond()
+}
+
+func (h *Handler) OnReturnLocation(c tele.Context) error {
+	return h.IsAuthorized(h.onReturnLocation)(c)
+}
+
+func (h *Handler) onReturnLocation(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "ReturnLocation"), c.Sender())
+
+	payload, value, err := h.gamePayloadFromCallback(c)
+	if err != nil {
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
+	}
+
+	location, ok := h.locations(context.Background()).FindKey(value)
+	if !ok {
+		log.WithField(ilog.FieldLocation, value).Warn("Unknown location")
+		c.Edit("No conozco esa ubicación, vuelve a buscar el juego")
+		return c.Respond()
+	}
+	log = log.WithField("ID", payload.ID).
+		WithField(ilog.FieldLocation, location.Name)
+
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		c.Edit(err.Error())
+		return c.Respond()
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+		return c.Respond()
+	}
+
+	g := *getResult
+	log = log.WithField("Game", g.Name)
+
+	if payload.HolderChanged(g) || g.IsAvailable() {
+		err := c.Edit("Parece que alguien ha modificado los datos. te envío los últimos actualizados")
+		if err != nil {
+			log.Print(err)
+		}
+		err = c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
+		if err != nil {
+			log.Print(err)
+		}
+		log.Info("Conflict on Return")
+		return c.Respond()
+	}
+
+	g.ReturnTo(location.Name)
 
 	err = h.GameDB.Update(context.TODO(), g)
 	if err != nil {
@@ -811,7 +1169,7 @@ func (h *Handler) onReturn(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	c.Edit(g.Card(), g.Buttons(member))
+	c.Edit(g.Card(), g.Buttons(member, h.PayloadSecret))
 	log.Info("Game returned")
 	return c.Respond()
 }
@@ -823,31 +1181,29 @@ func (h *Handler) OnMore(c tele.Context) error {
 func (h *Handler) onMore(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "More"), c.Sender())
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log = log.WithField("ID", payload.ID)
 
-	getResult, err := h.GameDB.Get(context.Background(), g.ID, g.Name)
+	getResult, err := payload.Find(h.gameGetter(context.Background()))
 	if err != nil {
 		c.Edit(err.Error())
 		log.WithError(err).Error("Unable to get from GameDB")
 		return c.Respond()
 	}
-	g = *getResult
-
 	if getResult == nil {
 		c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
 		log.Warn("Unable to find game")
 		return c.Respond()
 	}
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	err = c.Edit(g.MoreCard(), g.Buttons(member))
+	err = c.Edit(g.MoreCard(), g.Buttons(member, h.PayloadSecret))
 	if err != nil {
 		log.WithError(err).Error("Failed to send message")
 	}
@@ -898,15 +1254,25 @@ func (h *Handler) onHistory(c tele.Context, member Member) error {
 
 	defer c.Respond()
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log = log.WithField("ID", payload.ID)
+
+	getResult, err := payload.Find(h.gameGetter(ctx))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		return c.Send(err.Error())
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+	}
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
 	entries, err := h.Audit.Find(ctx, Query{
 		Game:  &g,
@@ -972,15 +1338,15 @@ func (h *Handler) OnExtendLease(c tele.Context) error {
 func (h *Handler) onExtendLease(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "OnExtendLease"), c.Sender())
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	log = log.WithField("Game", g.Name)
+	log = log.WithField("ID", payload.ID)
 
-	getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
 	if err != nil {
 		log.WithError(err).Error("Unable to get from GameDB")
 		c.Edit(err.Error())
@@ -992,14 +1358,15 @@ func (h *Handler) onExtendLease(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g = *getResult
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	if member.Permissions != PermissionAdmin && !g.IsHeldBy(member) {
+	if payload.HolderChanged(g) || (member.Permissions != PermissionAdmin && !g.IsHeldBy(member)) {
 		err := c.Edit("Parece que alguien ha modificado los datos. te envío los últimos actualizados")
 		if err != nil {
 			log.Print(err)
 		}
-		err = c.Send(g.Card(), g.Buttons(member))
+		err = c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
 		if err != nil {
 			log.Print(err)
 		}
@@ -1024,7 +1391,7 @@ func (h *Handler) onExtendLease(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	err = c.Edit(g.Card(), g.Buttons(member))
+	err = c.Edit(g.Card(), g.Buttons(member, h.PayloadSecret))
 	if err != nil {
 		log.Errorf("Failed to edit card, %s", err)
 	}
@@ -1065,23 +1432,15 @@ func (h *Handler) myGames(c tele.Context, member Member) error {
 	return h.bulk(c.Send, myGames)
 }
 
-func (h *Handler) InCentro(c tele.Context, member Member) error {
-	return h.inLocation(c, member, LocationCentro)
-}
-
-func (h *Handler) InGamonal(c tele.Context, member Member) error {
-	return h.inLocation(c, member, LocationGamonal)
-}
-
-func (h *Handler) inLocation(c tele.Context, member Member, location Location) error {
-	log := ilog.WithTelegramUser(logrus.
+func (h *Handler) inLocation(send func(what interface{}, opts ...interface{}) error, member Member, location Location) error {
+	log := logrus.
 		WithField(ilog.FieldHandler, "inLocation").
-		WithField(ilog.FieldLocation, location),
-		c.Sender())
+		WithField(ilog.FieldLocation, location).
+		WithField(ilog.FieldName, member.Nickname)
 
 	gameList, err := h.GameDB.List(context.TODO())
 	if err != nil {
-		return c.Send(err.Error())
+		return send(err.Error())
 	}
 
 	inLocation := []Game{}
@@ -1092,50 +1451,99 @@ func (h *Handler) inLocation(c tele.Context, member Member, location Location) e
 	}
 
 	if len(inLocation) == 0 {
-		return c.Send("No se han encontrado juegos")
+		return send("No se han encontrado juegos")
 	}
 
-	for _, block := range SendList(inLocation) {
-		err := c.Send(block, mainMenuReplyMarkup(member))
-		if err != nil {
-			log.Error(err)
-		}
+	err = h.sendGameList(send, GameList{Source: ListSourceLocation, Arg: string(location)}, inLocation)
+	if err != nil {
+		log.Error(err)
 	}
-
 	return nil
 }
 
 // Bulk sends a message with bulk operations for the list of games
 func (h *Handler) bulk(action func(what interface{}, opts ...interface{}) error, games []Game) error {
+	canReturn := Games(games).CanReturn()
+	canTake := Games(games).CanTake()
+	locations := h.locations(context.Background())
+
+	for _, chunk := range h.bulkChunks(games, locations) {
+		err := action(JoinList(chunk), h.bulkButtons(chunk, locations, canReturn, canTake))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxCallbackData is the size limit of the data of a button, including the unique
+const maxCallbackData = 64
+
+// bulkPayloadFits returns true if the payload fits in the data of the buttons that return the games to a location, the longest of a bulk message
+func (h *Handler) bulkPayloadFits(payload GamesPayload, locations Locations) bool {
+	for _, l := range locations {
+		data := payload.Encode(h.PayloadSecret, "return-all", string(l.Name))
+		if len("\freturn-all|"+data+"|"+string(l.Name)) > maxCallbackData {
+			return false
+		}
+	}
+	return true
+}
+
+// bulkChunks splits the games in messages that fit in telegram, and whose IDs fit in the data of the buttons of the message
+func (h *Handler) bulkChunks(games []Game, locations Locations) [][]Game {
+	chunks := [][]Game{}
+	for _, chunk := range ChunkList(games) {
+		start := 0
+		for i := range chunk {
+			if i > start && !h.bulkPayloadFits(NewGamesPayload(chunk[start:i+1]), locations) {
+				chunks = append(chunks, chunk[start:i])
+				start = i
+			}
+		}
+		chunks = append(chunks, chunk[start:])
+	}
+	return chunks
+}
+
+// bulkButtons builds the buttons of a message with several games. The payload has the IDs of the games and detects changes in them.
+// There are no buttons if the games can't be found by ID
+func (h *Handler) bulkButtons(games []Game, locations Locations, canReturn bool, canTake bool) *tele.ReplyMarkup {
 	selector := &tele.ReplyMarkup{}
 	rows := []tele.Row{}
+	payload := NewGamesPayload(games)
+	if !payload.CanEncode() || !h.bulkPayloadFits(payload, locations) {
+		return selector
+	}
 
-	if Games(games).CanReturn() {
+	if canReturn {
 		rows = append(rows, selector.Row(
-			selector.Data("Devolver todos", "return-all"),
+			selector.Data("Devolver todos", "return-all", payload.Encode(h.PayloadSecret, "return-all", "")),
 		))
 	}
-	if Games(games).CanTake() {
+	if canTake {
 		rows = append(rows, selector.Row(
-			selector.Data("Tomar prestados todos", "take-all"),
+			selector.Data("Tomar prestados todos", "take-all", payload.Encode(h.PayloadSecret, "take-all", "")),
 		))
 	}
 
 	selector.Inline(rows...)
+	return selector
+}
 
-	for _, block := range SendList(games) {
-		err := action(block, selector)
-		if err != nil {
-			return err
-		}
+func SendList[T fmt.Stringer](items []T) []string {
+	msgs := make([]string, 0)
+	for _, chunk := range ChunkList(items) {
+		msgs = append(msgs, JoinList(chunk))
 	}
-	return nil
+	return msgs
 }
 
-func SendList[T fmt.Stringer](items []T) []string {
+// ChunkList splits the items in groups that fit in a telegram message, one item per line
+func ChunkList[T fmt.Stringer](items []T) [][]T {
 	msgCharacters := 0
-	msgFragments := make([]string, 0)
-	msgs := make([]string, 0)
+	chunk := make([]T, 0)
+	chunks := make([][]T, 0)
 
 	for _, item := range items {
 		line := item.String()
@@ -1144,15 +1552,24 @@ func SendList[T fmt.Stringer](items []T) []string {
 
 		if msgCharacters >= 3900 { // Max Telegram Message Length
 			msgCharacters = len(line)
-			msgs = append(msgs, strings.Join(msgFragments, "\n"))
-			msgFragments = msgFragments[0:0]
+			chunks = append(chunks, chunk)
+			chunk = make([]T, 0)
 		}
-		msgFragments = append(msgFragments, line)
+		chunk = append(chunk, item)
 	}
-	if len(msgFragments) > 0 {
-		msgs = append(msgs, strings.Join(msgFragments, "\n"))
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
 	}
-	return msgs
+	return chunks
+}
+
+// JoinList writes the items one per line
+func JoinList[T fmt.Stringer](items []T) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, item.String())
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (h *Handler) Rename(c tele.Context) error {
@@ -1184,16 +1601,14 @@ func (h *Handler) cancelRename(c tele.Context, member Member) error {
 		return err
 	}
 
-	return c.Send("Okey, Cancelado.", mainMenuReplyMarkup(member))
+	return c.Send("Okey, Cancelado.", h.mainMenu(c, member))
 }
 
 func (h *Handler) onRename(c tele.Context, member Member) error {
-	defer func() {
-		err := h.MembersDB.Update(context.Background(), member)
-		if err != nil {
-			c.Send(err.Error())
-		}
-	}()
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Rename"), c.Sender())
 
 	newName := strings.TrimSpace(c.Text())
 	if len(newName) > 25 {
@@ -1202,13 +1617,76 @@ func (h *Handler) onRename(c tele.Context, member Member) error {
 
 	if newName == member.Nickname {
 		member.State.Clear()
-		return c.Send("Okey, te dejo el mismo nombre", mainMenuReplyMarkup(member))
+		if err := h.MembersDB.Update(ctx, member); err != nil {
+			return c.Send(err.Error())
+		}
+		return c.Send("Okey, te dejo el mismo nombre", h.mainMenu(c, member))
+	}
+	log = log.WithField(ilog.FieldName, member.Nickname).WithField("NewName", newName)
+
+	members, err := h.MembersDB.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to list members")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos", cancelMenu)
+	}
+	for _, m := range members {
+		if m.TelegramID != member.TelegramID && m.HasName(newName) {
+			log.Info("Name already in use by other member")
+			return c.Send("Ese nombre ya lo usa otro socio, dime otro", cancelMenu)
+		}
+	}
+
+	games, err := h.GameDB.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos", cancelMenu)
+	}
+	held := []Game{}
+	for _, g := range games {
+		switch {
+		case g.IsHeldBy(member):
+			held = append(held, g)
+		case g.Holder != "" && Norm(strings.TrimSpace(g.Holder)) == Norm(newName):
+			log.WithField("Game", g.Name).Info("Name already in use as holder")
+			return c.Send(fmt.Sprintf("Ya hay juegos prestados a %s, dime otro nombre", strings.TrimSpace(g.Holder)), cancelMenu)
+		}
+	}
+
+	// The games are moved first, if the member can't be updated they are moved back
+	moved := make([]Game, 0, len(held))
+	for _, g := range held {
+		g.Holder = newName
+		g.HolderID = member.TelegramID
+		moved = append(moved, g)
+	}
+	if err := h.updateGames(ctx, moved); err != nil {
+		log.WithError(err).Error("Failed to move the games to the new name")
+		return c.Send("No he podido cambiarte el nombre, vuelve a intentarlo", cancelMenu)
 	}
 
 	member.State.Clear()
-	member.Nickname = newName
-	c.Send("Listo! ahora te llamas "+member.Nickname, mainMenuReplyMarkup(member))
-	return nil
+	member.Rename(newName)
+	if err := h.MembersDB.Update(ctx, member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+		if err := h.updateGames(ctx, held); err != nil {
+			log.WithError(err).Error("Failed to move the games back to the previous name")
+		}
+		return c.Send("No he podido cambiarte el nombre, vuelve a intentarlo", cancelMenu)
+	}
+
+	log.WithField("Games", len(held)).Info("Member renamed")
+	if len(held) > 0 {
+		return c.Send(fmt.Sprintf("Listo! ahora te llamas %s\nHe pasado a tu nuevo nombre los %d juegos que tienes prestados", member.Nickname, len(held)), h.mainMenu(c, member))
+	}
+	return c.Send("Listo! ahora te llamas "+member.Nickname, h.mainMenu(c, member))
+}
+
+// updateGames updates the games, if there is any
+func (h *Handler) updateGames(ctx context.Context, games []Game) error {
+	if len(games) == 0 {
+		return nil
+	}
+	return h.GameDB.Update(ctx, games...)
 }
 
 func (h *Handler) OnAdmin(c tele.Context) error {
@@ -1228,7 +1706,7 @@ func (h *Handler) onCancelAdminMenu(c tele.Context, member Member) error {
 	member.State.Clear()
 	h.MembersDB.Update(context.Background(), member)
 	log.Info("Going back")
-	return c.Send("Volviendo al menu principal", mainMenuReplyMarkup(member))
+	return c.Send("Volviendo al menu principal", h.mainMenu(c, member))
 }
 
 func (h *Handler) OnForgotten(c tele.Context) error {
@@ -1253,9 +1731,12 @@ func (h *Handler) onForgotten(c tele.Context, member Member) error {
 	sort.Slice(forgottenGames, func(i, j int) bool { return forgottenGames[i].LeaseDays() < forgottenGames[j].LeaseDays() })
 
 	for _, g := range forgottenGames {
-		c.Send(g.Card(), g.Buttons(member))
+		c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
 	}
 
+	if len(forgottenGames) > 0 {
+		return c.Send(fmt.Sprintf("Hay %d juegos pendientes de devolver", len(forgottenGames)), remindOverdueButtons())
+	}
 	return nil
 }
 
@@ -1270,9 +1751,11 @@ func (h *Handler) onNotInAnyPlace(c tele.Context, member Member) error {
 		return c.Send(err.Error())
 	}
 
+	locations := h.locations(context.Background())
+
 	notInAnyPlace := []Game{}
 	for _, g := range games {
-		if !g.IsInLocation(LocationGamonal) && !g.IsInLocation(LocationCentro) {
+		if !locations.Contains(g.Location) {
 			notInAnyPlace = append(notInAnyPlace, g)
 		}
 	}
@@ -1280,7 +1763,7 @@ func (h *Handler) onNotInAnyPlace(c tele.Context, member Member) error {
 	sort.Slice(notInAnyPlace, func(i, j int) bool { return notInAnyPlace[i].LeaseDays() < notInAnyPlace[j].LeaseDays() })
 
 	for _, g := range notInAnyPlace {
-		c.Send(g.Card(), g.Buttons(member))
+		c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
 	}
 
 	if len(notInAnyPlace) == 0 {
@@ -1290,6 +1773,46 @@ func (h *Handler) onNotInAnyPlace(c tele.Context, member Member) error {
 	return nil
 }
 
+func (h *Handler) OnPendingTransfers(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onPendingTransfers))(c)
+}
+
+func (h *Handler) onPendingTransfers(c tele.Context, member Member) error {
+	games, err := h.GameDB.List(context.Background())
+	if err != nil {
+		c.Send("Wops! Algo ha ido mal!")
+		return c.Send(err.Error())
+	}
+
+	staleDays := h.StaleTransferDays
+	if staleDays <= 0 {
+		staleDays = DefaultStaleTransferDays
+	}
+
+	inTransit := []Game{}
+	for _, g := range games {
+		if g.IsInTransit() {
+			inTransit = append(inTransit, g)
+		}
+	}
+
+	if len(inTransit) == 0 {
+		return c.Send("No hay traslados pendientes")
+	}
+
+	sort.Slice(inTransit, func(i, j int) bool { return inTransit[i].TransferDate.Before(inTransit[j].TransferDate) })
+
+	for _, g := range inTransit {
+		card := g.Card()
+		if g.IsTransferStale(staleDays) {
+			card += fmt.Sprintf("\n⚠️ Lleva más de %d días en tránsito", staleDays)
+		}
+		c.Send(card, g.ButtonsForPage(member, 2, h.PayloadSecret))
+	}
+
+	return nil
+}
+
 func (h *Handler) OnGamePage(page int) func(c tele.Context) error {
 	return func(c tele.Context) error {
 		return h.IsAuthorized(h.onGamePage(page))(c)
@@ -1300,14 +1823,14 @@ func (h *Handler) onGamePage(page int) func(c tele.Context, member Member) error
 	return func(c tele.Context, member Member) error {
 		log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "GamePage"), c.Sender()).WithField(ilog.FieldPage, page)
 
-		g, err := NewGameFromCard(c.Message().Text)
+		payload, _, err := h.gamePayloadFromCallback(c)
 		if err != nil {
 			c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-			return fmt.Errorf("failed to load data form card, %w", err)
+			return fmt.Errorf("failed to read the button payload, %w", err)
 		}
-		log = log.WithField("Game", g.Name)
+		log = log.WithField("ID", payload.ID)
 
-		getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+		getResult, err := payload.Find(h.gameGetter(context.TODO()))
 		if err != nil {
 			log.WithError(err).Error("Unable to get from GameDB")
 			c.Edit(err.Error())
@@ -1319,9 +1842,10 @@ func (h *Handler) onGamePage(page int) func(c tele.Context, member Member) error
 			return c.Respond()
 		}
 
-		g = *getResult
+		g := *getResult
+		log = log.WithField("Game", g.Name)
 
-		err = c.Edit(g.Card(), g.ButtonsForPage(member, page))
+		err = c.Edit(g.Card(), g.ButtonsForPage(member, page, h.PayloadSecret))
 		if err != nil {
 			log.Errorf("Failed to edit card, %s", err)
 		}
@@ -1337,17 +1861,15 @@ func (h *Handler) OnSwitchLocation(c tele.Context) error {
 func (h *Handler) onSwitchLocation(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "SwitchLocation"), c.Sender())
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, value, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log = log.WithField("ID", payload.ID)
 
-	getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
 	if err != nil {
 		log.WithError(err).Error("Unable to get from GameDB")
 		c.Edit(err.Error())
@@ -1359,18 +1881,34 @@ func (h *Handler) onSwitchLocation(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g = *getResult
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	switch {
-	case g.IsInLocation(LocationCentro):
-		g.Location = string(LocationGamonal)
-	case g.IsInLocation(LocationGamonal):
-		g.Location = string(LocationCentro)
-	default:
-		log.Warn("Failed to determine current location, moving to Gamonal")
-		g.Location = string(LocationGamonal)
+	locations := h.locations(context.Background())
+
+	location, ok := locations.FindKey(value)
+	if !ok {
+		// Buttons sent before the location registry existed don't carry the destination
+		log.WithField(ilog.FieldLocation, value).Warn("Unknown location, asking again")
+		err = c.Edit(g.Card(), g.LocationButtons(locations, h.PayloadSecret))
+		if err != nil {
+			log.WithError(err).Error("Failed to update card")
+		}
+		return c.Respond()
+	}
+
+	log = log.WithField(ilog.FieldLocation, location.Name)
+
+	if g.IsInTransit() {
+		log.Info("Game is already in transit")
+		err = c.Edit(g.Card(), g.ButtonsForPage(member, 2, h.PayloadSecret))
+		if err != nil {
+			log.WithError(err).Error("Failed to update card")
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "El juego ya está en tránsito a " + g.TransferTo})
 	}
-	log = log.WithField(ilog.FieldLocation, g.Location)
+
+	g.RequestTransfer(location.Name, member)
 
 	err = h.GameDB.Update(context.TODO(), g)
 	if err != nil {
@@ -1379,70 +1917,147 @@ func (h *Handler) onSwitchLocation(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	err = c.Edit(g.Card(), g.ButtonsForPage(member, 2))
+	err = c.Edit(g.Card(), g.ButtonsForPage(member, 2, h.PayloadSecret))
 	if err != nil {
 		log.WithError(err).Error("Failed to update card")
 	}
-	log.Info("Switched location")
+	log.Info("Transfer requested")
+
+	if err := h.notifyCustodians(context.Background(), location, fmt.Sprintf("%s ha enviado un juego a %s, confirma cuando llegue", member.Nickname, location.Name), g); err != nil {
+		log.WithError(err).Error("Failed to notify custodians")
+	}
 
 	return c.Respond()
 }
 
-func (h *Handler) OnUpdateCommentButton(c tele.Context) error {
-	return h.IsAuthorized(h.onUpdateCommentButton)(c)
+// notifyCustodians sends the game card to the custodians of the location, or to the admins if the location has no custodians
+func (h *Handler) notifyCustodians(ctx context.Context, location LocationInfo, msg string, g Game) error {
+	members, err := h.MembersDB.List(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get list of members, %w", err)
+	}
+
+	recipients := []Member{}
+	for _, m := range members {
+		if location.IsCustodian(m) {
+			recipients = append(recipients, m)
+		}
+	}
+	if len(recipients) == 0 {
+		for _, m := range members {
+			if m.Permissions == PermissionAdmin {
+				recipients = append(recipients, m)
+			}
+		}
+	}
+
+	for _, m := range recipients {
+		if _, err := h.Bot.Send(&m, msg); err != nil {
+			return err
+		}
+		if _, err := h.Bot.Send(&m, g.Card(), g.ButtonsForPage(m, 2, h.PayloadSecret)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (h *Handler) onUpdateCommentButton(c tele.Context, member Member) error {
-	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "UpdateCommentButton"), c.Sender())
+// canConfirmTransfer returns true if the member is allowed to confirm the arrival of the game
+func (h *Handler) canConfirmTransfer(member Member, g Game) bool {
+	if member.Permissions == PermissionAdmin {
+		return true
+	}
+	location, ok := h.locations(context.Background()).Find(g.TransferTo)
+	return ok && location.IsCustodian(member)
+}
+
+// canCancelTransfer returns true if the member requested the transfer or can confirm it
+func (h *Handler) canCancelTransfer(member Member, g Game) bool {
+	if g.TransferBy != "" && g.TransferBy == member.TelegramID {
+		return true
+	}
+	return h.canConfirmTransfer(member, g)
+}
 
-	g, err := NewGameFromCard(c.Message().Text)
+func (h *Handler) OnConfirmTransfer(c tele.Context) error {
+	return h.IsAuthorized(h.onConfirmTransfer)(c)
+}
+
+func (h *Handler) onConfirmTransfer(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "ConfirmTransfer"), c.Sender())
+
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	log = log.WithField("Game", g.Name)
+	log = log.WithField("ID", payload.ID)
 
-	c.Send(fmt.Sprintf("Dime que comentario quieres dejar para el juego %s: %s", g.ID, g.Name), cancelMenu)
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		c.Edit(err.Error())
+		return c.Respond()
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+		return c.Respond()
+	}
 
-	member.State.SetUpdateComment(g)
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	err = h.MembersDB.Update(context.Background(), member)
-	if err != nil {
-		log.Error("Failed to updated memberDB")
-		return err
+	if !g.IsInTransit() {
+		log.Info("Conflict on ConfirmTransfer")
+		err = c.Edit(g.Card(), g.Buttons(member, h.PayloadSecret))
+		if err != nil {
+			log.WithError(err).Error("Failed to update card")
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "El juego ya no está en tránsito"})
 	}
 
-	return c.Respond()
-}
+	log = log.WithField(ilog.FieldLocation, g.TransferTo)
 
-func (h *Handler) OnCancelCommentButton(c tele.Context) error {
-	return h.IsAuthorized(h.onCancelCommentButton)(c)
-}
+	if !h.canConfirmTransfer(member, g) {
+		log.Info("Member is not a custodian of the destination")
+		return c.Respond(&tele.CallbackResponse{Text: "Solo los responsables de " + g.TransferTo + " pueden confirmar la llegada"})
+	}
 
-func (h *Handler) onCancelCommentButton(c tele.Context, member Member) error {
-	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "CancelUpdateCommentButton"), c.Sender())
-	defer c.Respond()
+	g.CompleteTransfer()
 
-	member.State.Clear()
+	err = h.GameDB.Update(context.TODO(), g)
+	if err != nil {
+		c.Edit(err.Error())
+		log.Error("Failed to update game database")
+		return c.Respond()
+	}
 
-	err := h.MembersDB.Update(context.Background(), member)
+	err = c.Edit(g.Card(), g.Buttons(member, h.PayloadSecret))
 	if err != nil {
-		log.Error("Failed to updated memberDB")
-		return err
+		log.WithError(err).Error("Failed to update card")
 	}
-	return c.Send("Ok, no dejo ningún comentario", mainMenuReplyMarkup(member))
+	log.Info("Transfer confirmed")
+	return c.Respond()
+}
 
+func (h *Handler) OnCancelTransfer(c tele.Context) error {
+	return h.IsAuthorized(h.onCancelTransfer)(c)
 }
 
-func (h *Handler) onUpdateComment(c tele.Context, member Member) error {
-	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "UpdateComment"), c.Sender())
+func (h *Handler) onCancelTransfer(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "CancelTransfer"), c.Sender())
 
-	g := NewGameFromLineData(member.State.Data)
+	payload, _, err := h.gamePayloadFromCallback(c)
+	if err != nil {
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
+	}
 
-	log = log.WithField("Game", g.Name)
+	log = log.WithField("ID", payload.ID)
 
-	getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
 	if err != nil {
 		log.WithError(err).Error("Unable to get from GameDB")
 		c.Edit(err.Error())
@@ -1454,43 +2069,318 @@ func (h *Handler) onUpdateComment(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g = *getResult
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	member.State.Clear()
+	if g.IsInTransit() {
+		if !h.canCancelTransfer(member, g) {
+			log.WithField(ilog.FieldLocation, g.TransferTo).Info("Member can't cancel the transfer")
+			return c.Respond(&tele.CallbackResponse{Text: "Solo quien ha enviado el juego o los responsables de " + g.TransferTo + " pueden cancelar el traslado"})
+		}
+		g.CancelTransfer()
 
-	err = h.MembersDB.Update(context.Background(), member)
-	if err != nil {
-		log.Error("Failed to updated memberDB")
-		return c.Respond()
+		err = h.GameDB.Update(context.TODO(), g)
+		if err != nil {
+			c.Edit(err.Error())
+			log.Error("Failed to update game database")
+			return c.Respond()
+		}
+		log.Info("Transfer cancelled")
 	}
 
-	g.Comments = c.Text()
-
-	err = h.GameDB.Update(context.Background(), g)
+	err = c.Edit(g.Card(), g.ButtonsForPage(member, 2, h.PayloadSecret))
 	if err != nil {
-		log.Error("Failed to update game DB")
+		log.WithError(err).Error("Failed to update card")
 	}
-
-	c.Send(g.Card(), g.Buttons(member))
-
 	return c.Respond()
 }
 
-func (h *Handler) OnGamesTakenByUser(c tele.Context) error {
-	return h.IsAuthorized(h.IsAdmin(h.onGamesTakenByUser))(c)
+func (h *Handler) OnCancelReservation(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onCancelReservation))(c)
 }
 
-func (h *Handler) onGamesTakenByUser(c tele.Context, member Member) error {
-	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "GamesTakenByUser"), c.Sender())
-	games, err := h.GameDB.List(context.Background())
+// onCancelReservation releases a game published to an event, so it can be taken again
+func (h *Handler) onCancelReservation(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "CancelReservation"), c.Sender())
+
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
-		c.Send("Wops! algo ha ido mal. Vuelve a intentarlo mas tarde")
-		return fmt.Errorf("Failed to load game list, %w", err)
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	names := []string{}
-	for _, g := range games {
-		if !g.IsAvailable() {
+	log = log.WithField("ID", payload.ID)
+
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		c.Edit(err.Error())
+		return c.Respond()
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+		return c.Respond()
+	}
+
+	g := *getResult
+	log = log.WithField("Game", g.Name)
+
+	if strings.TrimSpace(g.ReservedFor) != "" {
+		log = log.WithField("Event", g.ReservedFor)
+		g.CancelReservation()
+
+		err = h.GameDB.Update(context.TODO(), g)
+		if err != nil {
+			c.Edit(err.Error())
+			log.Error("Failed to update game database")
+			return c.Respond()
+		}
+		log.Info("Reservation cancelled")
+	}
+
+	err = c.Edit(g.Card(), g.ButtonsForPage(member, 2, h.PayloadSecret))
+	if err != nil {
+		log.WithError(err).Error("Failed to update card")
+	}
+	return c.Respond()
+}
+
+func (h *Handler) OnSelectLocation(c tele.Context) error {
+	return h.IsAuthorized(h.onSelectLocation)(c)
+}
+
+func (h *Handler) onSelectLocation(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "SelectLocation"), c.Sender())
+
+	payload, _, err := h.gamePayloadFromCallback(c)
+	if err != nil {
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
+	}
+
+	log = log.WithField("ID", payload.ID)
+
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		c.Edit(err.Error())
+		return c.Respond()
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+		return c.Respond()
+	}
+
+	g := *getResult
+	log = log.WithField("Game", g.Name)
+
+	err = c.Edit(g.Card(), g.LocationButtons(h.locations(context.Background()), h.PayloadSecret))
+	if err != nil {
+		log.WithError(err).Error("Failed to update card")
+	}
+	log.Info("Display locations")
+	return c.Respond()
+}
+
+func (h *Handler) OnUpdateCommentButton(c tele.Context) error {
+	return h.IsAuthorized(h.onUpdateCommentButton)(c)
+}
+
+func (h *Handler) onUpdateCommentButton(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "UpdateCommentButton"), c.Sender())
+
+	payload, _, err := h.gamePayloadFromCallback(c)
+	if err != nil {
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
+	}
+
+	log = log.WithField("ID", payload.ID)
+
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		return c.Send(err.Error())
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+	}
+	g := *getResult
+	log = log.WithField("Game", g.Name)
+
+	c.Send(fmt.Sprintf("Dime que comentario quieres dejar para el juego %s: %s", g.ID, g.Name), cancelMenu)
+
+	member.State.SetUpdateComment(g)
+
+	err = h.MembersDB.Update(context.Background(), member)
+	if err != nil {
+		log.Error("Failed to updated memberDB")
+		return err
+	}
+
+	return c.Respond()
+}
+
+func (h *Handler) OnAddAliasButton(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onAddAliasButton))(c)
+}
+
+func (h *Handler) onAddAliasButton(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "AddAliasButton"), c.Sender())
+	defer c.Respond()
+
+	payload, _, err := h.gamePayloadFromCallback(c)
+	if err != nil {
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
+	}
+
+	log = log.WithField("ID", payload.ID)
+
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		return c.Send(err.Error())
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+	}
+	g := *getResult
+	log = log.WithField("Game", g.Name)
+
+	member.State.SetAddAlias(g)
+
+	err = h.MembersDB.Update(context.Background(), member)
+	if err != nil {
+		log.Error("Failed to updated memberDB")
+		return err
+	}
+
+	return c.Send(fmt.Sprintf("Dime otro nombre para el juego %s: %s, se usará al buscar. Puedes poner varios separados por ;", g.ID, g.Name), cancelMenu)
+}
+
+// onAddAlias adds the names written by the admin to the aliases of the game
+func (h *Handler) onAddAlias(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "AddAlias"), c.Sender())
+
+	g := NewGameFromLineData(member.State.Data)
+
+	log = log.WithField("Game", g.Name)
+
+	getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		return c.Send(err.Error())
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+	}
+	g = *getResult
+
+	member.State.Clear()
+
+	err = h.MembersDB.Update(context.Background(), member)
+	if err != nil {
+		log.Error("Failed to updated memberDB")
+		return err
+	}
+
+	added := g.AddAliases(strings.Split(c.Text(), sheetsparser.ListSeparator)...)
+	if len(added) == 0 {
+		return c.Send(fmt.Sprintf("%s ya se puede encontrar con esos nombres", g.Name), h.mainMenu(c, member))
+	}
+
+	err = h.GameDB.Update(context.Background(), g)
+	if err != nil {
+		log.WithError(err).Error("Failed to update game DB")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos", h.mainMenu(c, member))
+	}
+	log.WithField("Aliases", added).Info("Aliases added")
+
+	c.Send(fmt.Sprintf("Hecho, ahora también se puede buscar como %s", strings.Join(added, ", ")), h.mainMenu(c, member))
+	return c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
+}
+
+func (h *Handler) OnCancelCommentButton(c tele.Context) error {
+	return h.IsAuthorized(h.onCancelCommentButton)(c)
+}
+
+func (h *Handler) onCancelCommentButton(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "CancelUpdateCommentButton"), c.Sender())
+	defer c.Respond()
+
+	member.State.Clear()
+
+	err := h.MembersDB.Update(context.Background(), member)
+	if err != nil {
+		log.Error("Failed to updated memberDB")
+		return err
+	}
+	return c.Send("Ok, no dejo ningún comentario", h.mainMenu(c, member))
+
+}
+
+func (h *Handler) onUpdateComment(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "UpdateComment"), c.Sender())
+
+	g := NewGameFromLineData(member.State.Data)
+
+	log = log.WithField("Game", g.Name)
+
+	getResult, err := h.GameDB.Get(context.TODO(), g.ID, g.Name)
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		c.Edit(err.Error())
+		return c.Respond()
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+		return c.Respond()
+	}
+
+	g = *getResult
+
+	member.State.Clear()
+
+	err = h.MembersDB.Update(context.Background(), member)
+	if err != nil {
+		log.Error("Failed to updated memberDB")
+		return c.Respond()
+	}
+
+	g.Comments = c.Text()
+
+	err = h.GameDB.Update(context.Background(), g)
+	if err != nil {
+		log.Error("Failed to update game DB")
+	}
+
+	c.Send(g.Card(), g.Buttons(member, h.PayloadSecret))
+
+	return c.Respond()
+}
+
+func (h *Handler) OnGamesTakenByUser(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onGamesTakenByUser))(c)
+}
+
+func (h *Handler) onGamesTakenByUser(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "GamesTakenByUser"), c.Sender())
+	games, err := h.GameDB.List(context.Background())
+	if err != nil {
+		c.Send("Wops! algo ha ido mal. Vuelve a intentarlo mas tarde")
+		return fmt.Errorf("Failed to load game list, %w", err)
+	}
+
+	names := []string{}
+	for _, g := range games {
+		if !g.IsAvailable() {
 			names = append(names, g.Holder)
 		}
 	}
@@ -1533,10 +2423,23 @@ type GetGamesTakenByUserTemplateData struct {
 func (h *Handler) onGetGamesTakenByUser(c tele.Context, member Member) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "GetGamesTakenByUser"), c.Sender())
 
+	// The member is looked up to find the games held with previous nicknames too
+	taker := &Member{
+		Nickname: c.Text(),
+	}
+	members, err := h.MembersDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Warn("Failed to list members, searching only by name")
+	}
+	for i := range members {
+		if members[i].HasName(c.Text()) {
+			taker = &members[i]
+			break
+		}
+	}
+
 	entries, err := h.Audit.Find(context.Background(), Query{
-		Member: &Member{
-			Nickname: c.Text(),
-		},
+		Member: taker,
 	})
 	if err != nil {
 		c.Send("Wops! Algo ha ido mal, vuelve a intentarlo mas tarde")
@@ -1603,23 +2506,6 @@ func (h *Handler) onGetGamesTakenByUser(c tele.Context, member Member) error {
 
 }
 
-func (h *Handler) OnJuegatron(c tele.Context) error {
-	return h.IsAuthorized(h.onJuegatron)(c)
-}
-
-func (h *Handler) onJuegatron(c tele.Context, member Member) error {
-
-	member.State.SetJuegatron()
-	err := h.MembersDB.Update(context.Background(), member)
-	if err != nil {
-		c.Send("Wops! Algo ha ido mal. Inténtalo de nuevo")
-		return fmt.Errorf("Failed to update DB, %w", err)
-	}
-
-	return c.Send("Listo! Ahora estas en modo juegatron. Puedes buscar juegos diciéndome parte del nombre.\nPor ejemplo, puedes buscar el \"Virus\" diciendo \"vir\".", juegatronReplyMarkup())
-
-}
-
 func (h *Handler) OnExitJuegatron(c tele.Context) error {
 	return h.IsAuthorized(h.onExitJuegatron)(c)
 }
@@ -1633,11 +2519,11 @@ func (h *Handler) onExitJuegatron(c tele.Context, member Member) error {
 		return fmt.Errorf("Failed to update DB, %w", err)
 	}
 
-	return c.Send("Vuelves a estar en modo normal", mainMenuReplyMarkup(member))
+	return c.Send("Vuelves a estar en modo normal", h.mainMenu(c, member))
 
 }
 
-func (h *Handler) onJuegatronText(c tele.Context, member Member) error {
+func (h *Handler) onJuegatronText(c tele.Context, member Member, event *JuegatronEvent) error {
 	ctx, cancel := GetContext(c)
 	defer cancel()
 
@@ -1645,7 +2531,11 @@ func (h *Handler) onJuegatronText(c tele.Context, member Member) error {
 
 	log = log.WithField(ilog.FieldText, c.Text())
 
-	gameList, err := h.JuegatronGameDB.List(ctx)
+	if dni, err := ValidateDNI(c.Text()); err == nil {
+		return h.juegatronAttendeeGames(ctx, c, event, dni)
+	}
+
+	gameList, err := event.Audit.State(ctx)
 	if err != nil {
 		log.WithError(err).Error("Failed to cache game data")
 		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos. Si el problema persiste, Avisa a @MetalBlueberry")
@@ -1661,54 +2551,42 @@ func (h *Handler) onJuegatronText(c tele.Context, member Member) error {
 	case len(list) <= 3:
 		for _, g := range list {
 			log.WithField("Game", g.Name).Info("Found Game")
-			err := c.Send(g.JuegatronCard(), g.JuegatronButtons())
+			err := c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
 			if err != nil {
 				log.Error(err)
 			}
 		}
 	default:
 		log.WithField("count", len(list)).Info("Found multiple games")
-		c.Send(`He encontrado varios juegos, 
-intenta darme el nombre concreto del juego que buscas. 
-También puedes seleccionar un juego por su ID, solo dime el número de la lista.
-
-Esto es todo lo que he encontrado`, mainMenu)
-		for _, block := range SendList(list) {
-			err := c.Send(block, juegatronReplyMarkup())
-			if err != nil {
-				log.Error(err)
-			}
-			if ctx.Err() != nil {
-				return c.Send(fmt.Sprintf("Wops! Parece que no me ha dado tiempo... Envíame algo mas simple.\n%s", ctx.Err()))
-			}
+		err := h.sendGameList(c.Send, GameList{Source: ListSourceJuegatron, Arg: c.Text()}, list)
+		if err != nil {
+			log.Error(err)
 		}
 	}
 	return nil
 }
 
 func (h *Handler) OnJuegatronReturn(c tele.Context) error {
-	return h.IsAuthorized(h.onJuegatronReturn)(c)
+	return h.IsAuthorized(h.InJuegatronEvent(h.onJuegatronReturn))(c)
 }
 
-func (h *Handler) onJuegatronReturn(c tele.Context, member Member) error {
+func (h *Handler) onJuegatronReturn(c tele.Context, member Member, event *JuegatronEvent) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronReturn"), c.Sender())
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log = log.WithField("ID", payload.ID)
 
-	games, err := h.JuegatronGameDB.List(context.Background())
+	games, err := event.Audit.State(context.Background())
 	if err != nil {
-		log.WithError(err).Error("Unable to list from Juegatron GameDB")
+		log.WithError(err).Error("Unable to compute Juegatron games")
 		c.Edit(err.Error())
 		return c.Respond()
 	}
-	getResult, err := Games(games).Get(g.ID, g.Name)
+	getResult, err := payload.Find(Games(games).Get)
 	if err != nil {
 		log.WithError(err).Error("Unable to get from Juegatron GameDB")
 		c.Edit(err.Error())
@@ -1720,14 +2598,15 @@ func (h *Handler) onJuegatronReturn(c tele.Context, member Member) error {
 		return c.Respond()
 	}
 
-	g = *getResult
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	if g.IsAvailable() {
+	if payload.HolderChanged(g) || g.IsAvailable() {
 		err := c.Edit("Parece que alguien ha modificado los datos. te envío los últimos actualizados")
 		if err != nil {
 			log.Print(err)
 		}
-		err = c.Send(g.JuegatronCard(), g.JuegatronButtons())
+		err = c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
 		if err != nil {
 			log.Print(err)
 		}
@@ -1736,103 +2615,119 @@ func (h *Handler) onJuegatronReturn(c tele.Context, member Member) error {
 	}
 
 	if g.IsAvailable() {
-		c.Edit(g.JuegatronCard(), g.JuegatronButtons())
+		c.Edit(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
 		return c.Send("Parece que alguien ha devuelvo ya este juego...")
 	}
 
 	previousHolder := g.Holder
 
+	err = event.Loans(member).ReturnGame(context.Background(), g.ID)
+	if errors.Is(err, events.ErrGameNotLoaned) {
+		log.Info("Conflict on Return")
+		g.Return()
+		c.Edit(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
+		c.Send("Parece que alguien ha devuelto ya este juego...")
+		return c.Respond()
+	}
+	if err != nil {
+		log.WithError(err).Error("Unable to register the return")
+		c.Send("Wops! No he podido guardar la devolución, vuelve a intentarlo")
+		return c.Respond()
+	}
 	g.Return()
 
-	h.JuegatronAudit.AuditDB.Append(context.Background(), []JuegatronAuditEntry{
-		NewJuegatronAuditEntry(g, member),
-	})
-
-	c.Edit(g.JuegatronCard(), g.JuegatronButtons())
+	c.Edit(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
 	log.Info("Game returned")
 
-	selector := &tele.ReplyMarkup{}
-	rows := []tele.Row{}
-
-	rows = append(rows, selector.Row(
-		selector.Data("Deshacer", "undo-juegatron-return", g.LineData()),
-	))
-
-	selector.Inline(rows...)
-	c.Send(fmt.Sprintf("Devuelto %s %s\nLo tenia....", g.ID, g.Name), selector)
+	err = c.Send(fmt.Sprintf("Devuelto %s %s\nLo tenia....", g.ID, g.Name), g.JuegatronUndoButtons(event.Info.ID, h.PayloadSecret))
+	if err != nil {
+		log.WithError(err).Error("Failed to send the undo button")
+	}
 	c.Send(previousHolder)
 	return c.Respond()
 }
 
-func (h *Handler) OnUndoJuegatronReturn(c tele.Context) error {
-	return h.IsAuthorized(h.onUndoJuegatronReturn)(c)
+func (h *Handler) OnUndoJuegatron(c tele.Context) error {
+	return h.IsAuthorized(h.InJuegatronEvent(h.onUndoJuegatron))(c)
 }
 
-func (h *Handler) onUndoJuegatronReturn(c tele.Context, member Member) error {
+// onUndoJuegatron writes an entry that reverts the last take, return or correction of the member
+func (h *Handler) onUndoJuegatron(c tele.Context, member Member, event *JuegatronEvent) error {
 	defer c.Respond()
 
-	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "UndoJuegatronReturn"), c.Sender())
-	g := NewGameFromLineData(c.Data())
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "UndoJuegatron"), c.Sender())
+	payload, err := h.undoJuegatronPayload(c)
+	if err != nil {
+		log.WithError(err).Error("Failed to read the button payload")
+		return c.Send("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+	}
+	log = log.WithField("ID", payload.ID)
 
-	entries, err := h.JuegatronAudit.AuditDB.List(context.Background())
+	entries, err := event.Audit.AuditDB.List(context.Background())
 	if err != nil {
 		log.WithError(err).Error("Failed to list entries")
 		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo")
 
 	}
-	var lastEntry *JuegatronAuditEntry
-	for i := range entries {
-		if entries[i].ID == g.ID && entries[i].Actor == member.Nickname {
-			lastEntry = &entries[i]
-		}
-	}
-	if lastEntry == nil {
-		return c.Send("Wops! No he podido encontrar tu ultima acción. Mejor revisa el excel a mano por si acaso")
+	undo, err := JuegatronUndo(entries, payload.ID, member)
+	if err != nil {
+		log.WithError(err).Info("Nothing to undo")
+		return c.Send("No puedo deshacerlo, alguien ha cambiado el juego después que tú o ya lo has deshecho. Revisa la ficha del juego")
 	}
-	err = h.JuegatronAudit.AuditDB.Delete(context.Background(), *lastEntry)
+	err = event.Audit.AuditDB.Append(context.Background(), []JuegatronAuditEntry{undo})
 	if err != nil {
-		log.WithError(err).Error("Failed to delete entries")
+		log.WithError(err).Error("Failed to append undo entry")
 		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo")
 	}
+	log.WithField("Holder", undo.Holder).Info("Undone")
 	c.Edit("Okey! Hemos vuelto atrás en el tiempo")
 
-	games, err := h.JuegatronGameDB.List(context.Background())
+	games, err := event.Audit.State(context.Background())
 	if err != nil {
 		log.WithError(err).Error("Failed to list games")
 		return nil
 	}
-	getGame, _ := Games(games).Get(g.ID, g.Name)
-	return c.Send(getGame.JuegatronCard(), getGame.JuegatronButtons())
+	getGame, _ := payload.Find(Games(games).Get)
+	if getGame == nil {
+		return nil
+	}
+	return c.Send(getGame.JuegatronCard(), getGame.JuegatronButtons(event.Info.ID, h.PayloadSecret))
+}
+
+// undoJuegatronPayload reads the game of the undo button.
+// Buttons sent before they carried the payload have the line data of the game instead
+func (h *Handler) undoJuegatronPayload(c tele.Context) (GamePayload, error) {
+	data, _, _ := juegatronButtonData(c)
+	if !strings.Contains(data, payloadSeparator) {
+		return NewGamePayload(NewGameFromLineData(data)), nil
+	}
+	payload, _, err := h.gamePayloadFromCallback(c)
+	return payload, err
 }
 
 func (h *Handler) OnJuegatronTake(c tele.Context) error {
-	return h.IsAuthorized(h.onJuegatronTake)(c)
+	return h.IsAuthorized(h.InJuegatronEvent(h.onJuegatronTake))(c)
 }
 
-func (h *Handler) onJuegatronTake(c tele.Context, member Member) error {
+func (h *Handler) onJuegatronTake(c tele.Context, member Member, event *JuegatronEvent) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronTake"), c.Sender())
 	defer c.Respond()
 
-	g, err := NewGameFromCard(c.Message().Text)
+	payload, _, err := h.gamePayloadFromCallback(c)
 	if err != nil {
 		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
-		return fmt.Errorf("failed to load data form card, %w", err)
+		return fmt.Errorf("failed to read the button payload, %w", err)
 	}
 
-	log = log.
-		WithField("Game", g.Name).
-		WithField("ID", g.ID)
+	log = log.WithField("ID", payload.ID)
 
-	games, err := h.JuegatronGameDB.List(context.Background())
+	games, err := event.Audit.State(context.Background())
 	if err != nil {
-		log.WithError(err).Error("Unable to list from Juegatron GameDB")
+		log.WithError(err).Error("Unable to compute Juegatron games")
 		c.Edit(err.Error())
 		return c.Respond()
 	}
-	getResult, err := Games(games).Get(g.ID, g.Name)
+	getResult, err := payload.Find(Games(games).Get)
 	if err != nil {
 		log.WithError(err).Error("Unable to get from Juegatron GameDB")
 		c.Edit(err.Error())
@@ -1847,14 +2742,15 @@ func (h *Handler) onJuegatronTake(c tele.Context, member Member) error {
 		return c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
 	}
 
-	g = *getResult
+	g := *getResult
+	log = log.WithField("Game", g.Name)
 
-	if !g.IsAvailable() {
+	if payload.HolderChanged(g) || !g.IsAvailable() {
 		err := c.Edit("Parece que alguien ha modificado los datos, te envío los últimos actualizados")
 		if err != nil {
 			log.Error(err)
 		}
-		err = c.Send(g.JuegatronCard(), g.JuegatronButtons())
+		err = c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
 		if err != nil {
 			log.Error(err)
 		}
@@ -1862,80 +2758,329 @@ func (h *Handler) onJuegatronTake(c tele.Context, member Member) error {
 		return err
 	}
 
-	member.State.SetJuegatronWaitingForName(g)
+	member.State.SetJuegatronWaitingForName(event.Info.ID, g)
 
 	err = h.MembersDB.Update(context.Background(), member)
 	if err != nil {
 		log.WithError(err).Error("failed to update memberDB")
 		return c.Send("Algo ha ido mal, vuelve a intentarlo")
 	}
-	return c.Send("Dime el nombre de la persona o el DNI.", cancelJuegatronMenu)
+	return c.Send("Dime el DNI/NIE de la persona. Si ya está registrada, también puedes buscarla por su nombre.", cancelJuegatronMenu)
 }
 
-func (h *Handler) onJuegatronTakeWaitForName(c tele.Context, member Member) error {
+func (h *Handler) onJuegatronTakeWaitForName(c tele.Context, member Member, event *JuegatronEvent) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronTakeWaitForName"), c.Sender())
 
-	g := NewGameFromLineData(member.State.Data)
+	g := member.State.JuegatronWaitingForName()
 	log = log.
 		WithField("Game", g.Name).
 		WithField("ID", g.ID)
 
-	member.State.SetJuegatron()
-	err := h.MembersDB.Update(context.Background(), member)
+	attendees, err := event.Attendees.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Unable to list attendees")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo", cancelJuegatronMenu)
+	}
+
+	dni, err := ValidateDNI(c.Text())
+	if err != nil {
+		found := Attendees(attendees).Find(c.Text())
+		if len(found) == 0 {
+			return c.Send("Eso no es un DNI/NIE válido y no hay nadie registrado con ese nombre. Revisa la letra del DNI o pulsa \"Cancelar préstamo\"", cancelJuegatronMenu)
+		}
+		return c.Send("¿Quién se lleva el juego?", attendeeButtons(event.Info.ID, found))
+	}
+
+	attendee, ok := Attendees(attendees).Get(dni)
+	if !ok {
+		member.State.SetJuegatronNewAttendee(event.Info.ID, g, dni, member.State.Is(StateActionJuegatronCorrecting))
+		if err := h.MembersDB.Update(ctx, member); err != nil {
+			log.WithError(err).Error("failed to update memberDB")
+			return c.Send("Algo ha ido mal, vuelve a intentarlo")
+		}
+		return c.Send(fmt.Sprintf("No hay nadie registrado con el DNI/NIE %s. Dime su nombre y lo registro", dni), cancelJuegatronMenu)
+	}
+
+	if member.State.Is(StateActionJuegatronCorrecting) {
+		return h.juegatronCorrectFor(ctx, c, member, event, g, attendee)
+	}
+	return h.juegatronTakeFor(ctx, c, member, event, g, attendee)
+}
+
+func (h *Handler) onJuegatronNewAttendee(c tele.Context, member Member, event *JuegatronEvent) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronNewAttendee"), c.Sender())
+
+	dni, g, correction := member.State.JuegatronNewAttendee()
+	name := strings.TrimSpace(c.Text())
+	if name == "" {
+		return c.Send("Dime el nombre de la persona", cancelJuegatronMenu)
+	}
+
+	attendee := Attendee{DNI: dni, Name: name}
+	if err := event.Attendees.Append(ctx, attendee); err != nil {
+		log.WithError(err).Error("Unable to register attendee")
+		return c.Send("Wops! No he podido registrar a la persona, vuelve a intentarlo", cancelJuegatronMenu)
+	}
+	log.WithField("Attendee", attendee.Holder()).Info("Attendee registered")
+
+	if correction {
+		return h.juegatronCorrectFor(ctx, c, member, event, g, attendee)
+	}
+	return h.juegatronTakeFor(ctx, c, member, event, g, attendee)
+}
+
+func attendeeButtons(eventID string, attendees Attendees) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{}
+	for _, a := range attendees {
+		rows = append(rows, selector.Row(
+			selector.Data(a.Holder(), "juegatron-attendee", a.DNI, eventID),
+		))
+	}
+	selector.Inline(rows...)
+	return selector
+}
+
+func (h *Handler) OnJuegatronPickAttendee(c tele.Context) error {
+	return h.IsAuthorized(h.InJuegatronEvent(h.onJuegatronPickAttendee))(c)
+}
+
+func (h *Handler) onJuegatronPickAttendee(c tele.Context, member Member, event *JuegatronEvent) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	defer c.Respond()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronPickAttendee"), c.Sender())
+
+	if !member.State.Is(StateActionJuegatronWaitingForName) && !member.State.Is(StateActionJuegatronCorrecting) {
+		return c.Edit("Este préstamo ya no está en curso")
+	}
+	g := member.State.JuegatronWaitingForName()
+
+	attendees, err := event.Attendees.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Unable to list attendees")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo", cancelJuegatronMenu)
+	}
+	dni, _, _ := juegatronButtonData(c)
+	attendee, ok := Attendees(attendees).Get(dni)
+	if !ok {
+		return c.Edit("No he encontrado a esa persona, dime su DNI/NIE")
+	}
+	if member.State.Is(StateActionJuegatronCorrecting) {
+		c.Edit(fmt.Sprintf("Lo tiene %s", attendee.Holder()))
+		return h.juegatronCorrectFor(ctx, c, member, event, g, attendee)
+	}
+	c.Edit(fmt.Sprintf("Se lo lleva %s", attendee.Holder()))
+	return h.juegatronTakeFor(ctx, c, member, event, g, attendee)
+}
+
+// juegatronTakeFor gives the game to the attendee, as long as it is still available and the attendee is below the limit
+func (h *Handler) juegatronTakeFor(ctx context.Context, c tele.Context, member Member, event *JuegatronEvent, g Game, attendee Attendee) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronTakeFor"), c.Sender()).
+		WithField("Game", g.Name).
+		WithField("ID", g.ID).
+		WithField("Attendee", attendee.Holder())
+
+	member.State.SetJuegatron(event.Info.ID)
+	err := h.MembersDB.Update(ctx, member)
 	if err != nil {
 		log.WithError(err).Warn("Failed to update member DB")
 	}
 
-	games, err := h.JuegatronGameDB.List(context.Background())
+	games, err := event.Audit.State(ctx)
 	if err != nil {
-		log.WithError(err).Error("Unable to list from Juegatron GameDB")
-		c.Edit(err.Error())
-		return c.Respond()
+		log.WithError(err).Error("Unable to compute Juegatron games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo", juegatronReplyMarkup())
 	}
 	getResult, err := Games(games).Get(g.ID, g.Name)
 	if err != nil {
 		log.WithError(err).Error("Unable to get from Juegatron GameDB")
-		c.Edit(err.Error())
-		return c.Respond()
-	}
-	if err != nil {
-		log.WithError(err).Error("Unable to get from GameDB")
-		c.Edit(err.Error())
-		return c.Respond()
+		return c.Send(err.Error(), juegatronReplyMarkup())
 	}
 	if getResult == nil {
 		log.Warn("Unable to find game")
-		c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
-		return c.Respond()
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel", juegatronReplyMarkup())
 	}
 	g = *getResult
 
 	if !g.IsAvailable() {
-		c.Edit(g.JuegatronCard(), g.JuegatronButtons())
 		log.Info("Conflict on take")
-		return c.Send("El juego no está disponible")
+		c.Send("El juego no está disponible", juegatronReplyMarkup())
+		return c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
+	}
+
+	held := attendee.Games(games)
+	if h.JuegatronMaxGames > 0 && len(held) >= h.JuegatronMaxGames {
+		log.WithField("Held", len(held)).Info("Attendee over the limit")
+		c.Send(fmt.Sprintf("%s ya tiene %d juegos y el máximo es %d. Tiene que devolver alguno antes de llevarse otro", attendee.Holder(), len(held), h.JuegatronMaxGames), juegatronReplyMarkup())
+		for _, block := range SendList(held) {
+			c.Send(block)
+		}
+		return nil
+	}
+
+	err = event.Loans(member).LoanGame(ctx, events.Loan{GameID: g.ID, FullName: attendee.Holder()})
+	if errors.Is(err, events.ErrGameLoaned) {
+		log.WithError(err).Info("Conflict on take")
+		return c.Send("El juego no está disponible, alguien lo acaba de prestar", juegatronReplyMarkup())
+	}
+	if err != nil {
+		log.WithError(err).Error("Unable to register the loan")
+		return c.Send("Wops! No he podido guardar el préstamo, vuelve a intentarlo", juegatronReplyMarkup())
+	}
+	g.Take(attendee.Holder())
+	log.Info("Game taken")
+
+	c.Send(fmt.Sprintf("Listo! has dado el juego a %s", attendee.Holder()), juegatronReplyMarkup())
+	c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
+	return c.Send("¿Te has equivocado?", g.JuegatronUndoButtons(event.Info.ID, h.PayloadSecret))
+}
+
+func (h *Handler) OnJuegatronCorrect(c tele.Context) error {
+	return h.IsAuthorized(h.InJuegatronEvent(h.onJuegatronCorrect))(c)
+}
+
+// onJuegatronCorrect asks for the attendee that really has a game lent to the wrong person
+func (h *Handler) onJuegatronCorrect(c tele.Context, member Member, event *JuegatronEvent) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronCorrect"), c.Sender())
+	defer c.Respond()
+
+	payload, _, err := h.gamePayloadFromCallback(c)
+	if err != nil {
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
+	}
+	log = log.WithField("ID", payload.ID)
+
+	games, err := event.Audit.State(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Unable to compute Juegatron games")
+		return c.Edit(err.Error())
+	}
+	getResult, err := payload.Find(Games(games).Get)
+	if err != nil || getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Edit("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+	}
+	g := *getResult
+	log = log.WithField("Game", g.Name)
+
+	if payload.HolderChanged(g) || g.IsAvailable() {
+		c.Edit("Parece que alguien ha modificado los datos, te envío los últimos actualizados")
+		log.Info("Conflict on correct")
+		return c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
+	}
+
+	member.State.SetJuegatronCorrecting(event.Info.ID, g)
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("failed to update memberDB")
+		return c.Send("Algo ha ido mal, vuelve a intentarlo")
+	}
+	return c.Send(fmt.Sprintf("Ahora lo tiene %s. Dime el DNI/NIE de quien lo tiene de verdad, o búscalo por su nombre.", g.Holder), cancelJuegatronMenu)
+}
+
+// juegatronCorrectFor changes the holder of an active loan to the attendee
+func (h *Handler) juegatronCorrectFor(ctx context.Context, c tele.Context, member Member, event *JuegatronEvent, g Game, attendee Attendee) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronCorrectFor"), c.Sender()).
+		WithField("Game", g.Name).
+		WithField("ID", g.ID).
+		WithField("Attendee", attendee.Holder())
+
+	member.State.SetJuegatron(event.Info.ID)
+	if err := h.MembersDB.Update(ctx, member); err != nil {
+		log.WithError(err).Warn("Failed to update member DB")
+	}
+
+	games, err := event.Audit.State(ctx)
+	if err != nil {
+		log.WithError(err).Error("Unable to compute Juegatron games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo", juegatronReplyMarkup())
+	}
+	getResult, err := Games(games).Get(g.ID, g.Name)
+	if err != nil || getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel", juegatronReplyMarkup())
+	}
+	g = *getResult
+
+	if g.IsAvailable() {
+		log.Info("Conflict on correct")
+		c.Send("El juego ya se ha devuelto, no hay nada que corregir", juegatronReplyMarkup())
+		return c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
+	}
+	if attendee.Holds(g) {
+		return c.Send(fmt.Sprintf("El juego ya lo tiene %s", attendee.Holder()), juegatronReplyMarkup())
+	}
+
+	held := attendee.Games(games)
+	if h.JuegatronMaxGames > 0 && len(held) >= h.JuegatronMaxGames {
+		log.WithField("Held", len(held)).Info("Attendee over the limit")
+		return c.Send(fmt.Sprintf("%s ya tiene %d juegos y el máximo es %d", attendee.Holder(), len(held), h.JuegatronMaxGames), juegatronReplyMarkup())
 	}
 
-	g.Take(c.Text())
+	previousHolder := g.Holder
+	g.Holder = attendee.Holder()
 
-	h.JuegatronAudit.AuditDB.Append(context.Background(), []JuegatronAuditEntry{
-		NewJuegatronAuditEntry(g, member),
+	err = event.Audit.AuditDB.Append(ctx, []JuegatronAuditEntry{
+		NewJuegatronCorrection(g, attendee.Holder(), member),
 	})
+	if err != nil {
+		log.WithError(err).Error("Unable to register the correction")
+		return c.Send("Wops! No he podido guardar la corrección, vuelve a intentarlo", juegatronReplyMarkup())
+	}
+	log.WithField("PreviousHolder", previousHolder).Info("Loan corrected")
 
-	c.Edit(g.JuegatronCard(), g.JuegatronButtons())
-	log.Info("Game taken")
+	c.Send(fmt.Sprintf("Corregido! Ya no lo tiene %s, lo tiene %s", previousHolder, attendee.Holder()), juegatronReplyMarkup())
+	c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret))
+	return c.Send("¿Te has equivocado?", g.JuegatronUndoButtons(event.Info.ID, h.PayloadSecret))
+}
 
-	c.Send(fmt.Sprintf("Listo! has dado el juego a %s", c.Text()), juegatronReplyMarkup())
-	c.Send(g.JuegatronCard(), g.JuegatronButtons())
-	return c.Respond()
+// juegatronAttendeeGames shows the games currently held by an attendee
+func (h *Handler) juegatronAttendeeGames(ctx context.Context, c tele.Context, event *JuegatronEvent, dni string) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronAttendeeGames"), c.Sender())
+
+	attendees, err := event.Attendees.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Unable to list attendees")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo", juegatronReplyMarkup())
+	}
+	attendee, ok := Attendees(attendees).Get(dni)
+	if !ok {
+		return c.Send(fmt.Sprintf("No hay nadie registrado con el DNI/NIE %s", dni), juegatronReplyMarkup())
+	}
+
+	games, err := event.Audit.State(ctx)
+	if err != nil {
+		log.WithError(err).Error("Unable to compute Juegatron games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo", juegatronReplyMarkup())
+	}
+
+	held := attendee.Games(games)
+	if len(held) == 0 {
+		return c.Send(fmt.Sprintf("%s no tiene ningún juego", attendee.Holder()), juegatronReplyMarkup())
+	}
+	c.Send(fmt.Sprintf("%s tiene %d juegos", attendee.Holder(), len(held)), juegatronReplyMarkup())
+	for _, g := range held {
+		if err := c.Send(g.JuegatronCard(), g.JuegatronButtons(event.Info.ID, h.PayloadSecret)); err != nil {
+			log.Error(err)
+		}
+	}
+	return nil
 }
 
 func (h *Handler) OnCancelJuegatron(c tele.Context) error {
-	return h.IsAuthorized(h.onCancelJuegatron)(c)
+	return h.IsAuthorized(h.InJuegatronEvent(h.onCancelJuegatron))(c)
 }
-func (h *Handler) onCancelJuegatron(c tele.Context, member Member) error {
+func (h *Handler) onCancelJuegatron(c tele.Context, member Member, event *JuegatronEvent) error {
 	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "CancelJuegatron"), c.Sender())
-	member.State.SetJuegatron()
+	member.State.SetJuegatron(event.Info.ID)
 
 	err := h.MembersDB.Update(context.Background(), member)
 	if err != nil {
@@ -1948,16 +3093,16 @@ func (h *Handler) onCancelJuegatron(c tele.Context, member Member) error {
 }
 
 func (h *Handler) OnListJuegatron(c tele.Context) error {
-	return h.IsAuthorized(h.onListJuegatron)(c)
+	return h.IsAuthorized(h.InJuegatronEvent(h.onListJuegatron))(c)
 }
 
-func (h *Handler) onListJuegatron(c tele.Context, member Member) error {
+func (h *Handler) onListJuegatron(c tele.Context, member Member, event *JuegatronEvent) error {
 
 	log := ilog.WithTelegramUser(logrus.
 		WithField(ilog.FieldHandler, "listJuegatron"),
 		c.Sender())
 
-	gameList, err := h.JuegatronGameDB.List(context.TODO())
+	gameList, err := event.Audit.State(context.TODO())
 	if err != nil {
 		return c.Send(err.Error())
 	}
@@ -1966,12 +3111,815 @@ func (h *Handler) onListJuegatron(c tele.Context, member Member) error {
 		return c.Send("No se han encontrado juegos")
 	}
 
-	for _, block := range SendList(gameList) {
-		err := c.Send(block, juegatronReplyMarkup())
+	err = h.sendGameList(c.Send, GameList{Source: ListSourceJuegatron}, gameList)
+	if err != nil {
+		log.Error(err)
+	}
+	return nil
+}
+
+const (
+	juegatronCloseReturn = "return"
+	juegatronCloseLost   = "lost"
+	juegatronCloseCancel = "cancel"
+)
+
+func (h *Handler) OnJuegatronReport(c tele.Context) error {
+	return h.IsAuthorized(h.InJuegatronEvent(h.onJuegatronReport))(c)
+}
+
+func (h *Handler) onJuegatronReport(c tele.Context, member Member, event *JuegatronEvent) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronReport"), c.Sender())
+
+	report, err := event.Audit.Report(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to build juegatron report")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+	log.WithField("Entries", len(report)).Info("Juegatron report")
+
+	text := report.String()
+	if len(text) > 4000 {
+		text = fmt.Sprintf("Juegos sin devolver: %d\nJuegos perdidos: %d\n\nTienes el detalle en el documento",
+			len(report.Held()),
+			len(report.Lost()),
+		)
+	}
+	c.Send(text, juegatronReplyMarkup())
+
+	data, err := report.CSV()
+	if err != nil {
+		log.WithError(err).Error("Failed to build csv")
+		return c.Send("No he podido generar el documento del cierre")
+	}
+	c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("juegatron-%s.csv", time.Now().Format("2006-01-02")),
+		MIME:     "text/csv",
+	})
+
+	// Only admins can close the loans of the event
+	held := report.Held()
+	if len(held) == 0 || member.Permissions != PermissionAdmin {
+		return nil
+	}
+
+	selector := &tele.ReplyMarkup{}
+	selector.Inline(
+		selector.Row(selector.Data("Marcar todo como devuelto", "juegatron-close", juegatronCloseReturn, event.Info.ID)),
+		selector.Row(selector.Data("Marcar todo como perdido", "juegatron-close", juegatronCloseLost, event.Info.ID)),
+	)
+	return c.Send(fmt.Sprintf("Quedan %d juegos sin devolver, ¿qué hago con ellos?", len(held)), selector)
+}
+
+func (h *Handler) OnJuegatronClose(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.InJuegatronEvent(h.onJuegatronClose)))(c)
+}
+
+// onJuegatronClose asks for confirmation before changing all the pending loans
+func (h *Handler) onJuegatronClose(c tele.Context, member Member, event *JuegatronEvent) error {
+	defer c.Respond()
+
+	action, _, _ := juegatronButtonData(c)
+	question := fmt.Sprintf("¿Seguro que quieres marcar todos los juegos pendientes de %s como devueltos?", event.Info.Name)
+	if action == juegatronCloseLost {
+		question = fmt.Sprintf("¿Seguro que quieres marcar todos los juegos pendientes de %s como perdidos?", event.Info.Name)
+	}
+
+	selector := &tele.ReplyMarkup{}
+	selector.Inline(
+		selector.Row(selector.Data("Sí, estoy seguro", "juegatron-close-confirm", action, event.Info.ID)),
+		selector.Row(selector.Data("No, déjalo como está", "juegatron-close-confirm", juegatronCloseCancel, event.Info.ID)),
+	)
+	return c.Edit(question, selector)
+}
+
+func (h *Handler) OnJuegatronCloseConfirm(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.InJuegatronEvent(h.onJuegatronCloseConfirm)))(c)
+}
+
+func (h *Handler) onJuegatronCloseConfirm(c tele.Context, member Member, event *JuegatronEvent) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	defer c.Respond()
+
+	action, _, _ := juegatronButtonData(c)
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronCloseConfirm"), c.Sender()).
+		WithField("Action", action).
+		WithField("Event", event.Info.ID)
+
+	if action != juegatronCloseReturn && action != juegatronCloseLost {
+		return c.Edit("Okey, no cambio nada")
+	}
+
+	report, err := event.Audit.Report(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to build juegatron report")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+
+	// The report is built again, so loans that changed since it was sent are not overwritten
+	held := report.Held()
+	if len(held) == 0 {
+		return c.Edit("No queda ningún juego pendiente")
+	}
+
+	if action == juegatronCloseLost {
+		entries := []JuegatronAuditEntry{}
+		for _, l := range held {
+			entries = append(entries, JuegatronAuditEntry{
+				ID:     l.Game.ID,
+				Holder: JuegatronLostHolder,
+				Actor:  member.Nickname,
+			})
+		}
+		if err := event.Audit.AuditDB.Append(ctx, entries); err != nil {
+			log.WithError(err).Error("Failed to close juegatron loans")
+			return c.Edit("Wops! No he podido guardar los cambios, vuelve a intentarlo")
+		}
+		log.WithField("Entries", len(entries)).Info("Juegatron closed")
+		return c.Edit(fmt.Sprintf("Listo! He marcado %d juegos como perdidos", len(entries)))
+	}
+
+	// Returns are registered like the ones made from the game card
+	loans := event.Loans(member)
+	returned := 0
+	for _, l := range held {
+		err := loans.ReturnGame(ctx, l.Game.ID)
+		if errors.Is(err, events.ErrGameNotLoaned) {
+			log.WithField("ID", l.Game.ID).Info("Game already returned")
+			continue
+		}
 		if err != nil {
-			log.Error(err)
+			log.WithError(err).WithField("ID", l.Game.ID).Error("Failed to return juegatron loan")
+			return c.Edit(fmt.Sprintf("Wops! Solo he podido marcar %d juegos como devueltos, vuelve a intentarlo", returned))
 		}
+		returned++
+	}
+	log.WithField("Returned", returned).Info("Juegatron closed")
+	return c.Edit(fmt.Sprintf("Listo! He marcado %d juegos como devueltos", returned))
+}
+
+func (h *Handler) OnJuegatronStats(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onJuegatronStats))(c)
+}
+
+// onJuegatronStats asks for the event if there is more than one, past events are included
+func (h *Handler) onJuegatronStats(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronStats"), c.Sender())
+
+	if h.Events == nil {
+		return c.Send("No hay ningún evento registrado")
+	}
+	events, err := h.Events.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list events")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	switch len(events) {
+	case 0:
+		return c.Send("No hay ningún evento registrado")
+	case 1:
+		return h.juegatronStats(c, member, h.OpenEvent(events[0]))
+	default:
+		return c.Send("¿De qué evento quieres ver las estadísticas?", eventButtons("juegatron-stats", events))
 	}
+}
 
-	return nil
+func (h *Handler) OnJuegatronStatsEvent(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onJuegatronStatsEvent))(c)
+}
+
+func (h *Handler) onJuegatronStatsEvent(c tele.Context, member Member) error {
+	defer c.Respond()
+
+	event, err := h.juegatronEvent(context.Background(), c.Data())
+	if err != nil {
+		return c.Edit("No he podido cargar el evento, vuelve a intentarlo")
+	}
+	c.Edit(fmt.Sprintf("Estadísticas de %s", event.Info.Name))
+	return h.juegatronStats(c, member, event)
+}
+
+func (h *Handler) juegatronStats(c tele.Context, member Member, event *JuegatronEvent) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "JuegatronStats"), c.Sender()).
+		WithField("Event", event.Info.ID)
+
+	stats, err := event.Audit.Stats(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to build juegatron stats")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+	log.WithField("Games", len(stats.Games)).Info("Juegatron stats")
+
+	text := stats.String()
+	if len(text) > 4000 {
+		cut := strings.LastIndex(text[:4000], "\n")
+		if cut < 0 {
+			cut = 4000
+		}
+		text = text[:cut] + "\n...\n\nTienes el detalle en el documento"
+	}
+	c.Send(text, adminMenuReplyMarkup(member))
+
+	data, err := stats.CSV()
+	if err != nil {
+		log.WithError(err).Error("Failed to build csv")
+		return c.Send("No he podido generar el documento de estadísticas")
+	}
+	c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("juegatron-estadisticas-%s.csv", time.Now().Format("2006-01-02")),
+		MIME:     "text/csv",
+	})
+
+	images, err := stats.Charts()
+	if err != nil {
+		log.WithError(err).Error("Failed to draw charts")
+		return c.Send("No he podido generar las gráficas")
+	}
+	for _, img := range images {
+		if err := c.Send(&tele.Photo{File: tele.FromReader(bytes.NewReader(img))}); err != nil {
+			log.WithError(err).Error("Failed to send chart")
+		}
+	}
+	return nil
+}
+
+func stocktakeLocationButtons(locations Locations) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{}
+	for _, l := range locations {
+		rows = append(rows, selector.Row(
+			selector.Data(string(l.Name), "stocktake-location", string(l.Name)),
+		))
+	}
+	selector.Inline(rows...)
+	return selector
+}
+
+func (h *Handler) OnStocktake(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onStocktake))(c)
+}
+
+func (h *Handler) onStocktake(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Stocktake"), c.Sender())
+
+	if !member.State.Is(StateActionStocktake) {
+		member.State.SetStocktake(Stocktake{})
+		if err := h.MembersDB.Update(context.Background(), member); err != nil {
+			log.WithError(err).Error("Failed to update memberDB")
+			return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo")
+		}
+		log.Info("Stocktake started")
+		c.Send("Empezamos el inventario. Elige una ubicación y envíame los IDs de los juegos que veas en ella", stocktakeMenu)
+	}
+
+	return c.Send("¿Qué ubicación vas a revisar?", stocktakeLocationButtons(h.locations(context.Background())))
+}
+
+func (h *Handler) OnStocktakeLocation(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onStocktakeLocation))(c)
+}
+
+func (h *Handler) onStocktakeLocation(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "StocktakeLocation"), c.Sender())
+	defer c.Respond()
+
+	if !member.State.Is(StateActionStocktake) {
+		return c.Edit("No hay ningún inventario en marcha")
+	}
+
+	location, ok := h.locations(context.Background()).Find(c.Data())
+	if !ok {
+		log.WithField(ilog.FieldLocation, c.Data()).Warn("Unknown location")
+		return c.Edit("No conozco esa ubicación, elige otra", stocktakeLocationButtons(h.locations(context.Background())))
+	}
+
+	stocktake, err := NewStocktakeFromData(member.State.Data)
+	if err != nil {
+		log.WithError(err).Error("Failed to load stocktake")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a empezar el inventario")
+	}
+	stocktake.Location = location.Name
+	member.State.SetStocktake(stocktake)
+
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a intentarlo")
+	}
+
+	log.WithField(ilog.FieldLocation, location.Name).Info("Checking location")
+	return c.Edit(fmt.Sprintf("Revisando %s. Envíame los IDs de los juegos que veas, puedes enviar varios en el mismo mensaje", location.Name))
+}
+
+func (h *Handler) onStocktakeText(c tele.Context, member Member) error {
+	return h.stocktakeSeen(c, member, strings.Fields(strings.ReplaceAll(c.Text(), ",", " ")))
+}
+
+// stocktakeSeen marks the given IDs as seen in the location being checked
+func (h *Handler) stocktakeSeen(c tele.Context, member Member, tokens []string) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "StocktakeSeen"), c.Sender())
+
+	stocktake, err := NewStocktakeFromData(member.State.Data)
+	if err != nil {
+		log.WithError(err).Error("Failed to load stocktake")
+		return c.Send("Wops! Algo ha ido mal, vuelve a empezar el inventario")
+	}
+	if stocktake.Location == "" {
+		return c.Send("Primero dime qué ubicación estás revisando", stocktakeLocationButtons(h.locations(context.Background())))
+	}
+
+	gameList, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+
+	lines := []string{}
+	for _, token := range tokens {
+		if !mayBeAnID.MatchString(token) {
+			lines = append(lines, fmt.Sprintf("❓ %s no es un ID", token))
+			continue
+		}
+		id := mayBeAnID.FindStringSubmatch(token)[1]
+		matches := Games{}
+		g, err := Games(gameList).Get(id, "")
+		if mmErr, ok := err.(MultipleMatchesError); ok {
+			matches = mmErr.Matches
+		} else if g != nil {
+			matches = append(matches, *g)
+		}
+		if len(matches) == 0 {
+			lines = append(lines, fmt.Sprintf("❓ No conozco el ID %s", id))
+			continue
+		}
+
+		stocktake.MarkSeen(id)
+		for _, g := range matches {
+			switch {
+			case !g.IsAvailable():
+				lines = append(lines, fmt.Sprintf("⚠️ %s, debería estar prestado", g.Line()))
+			case !g.IsInLocation(stocktake.Location):
+				lines = append(lines, fmt.Sprintf("⚠️ %s, debería estar en %s", g.Line(), g.Location))
+			default:
+				lines = append(lines, fmt.Sprintf("✅ %s", g.Line()))
+			}
+		}
+	}
+
+	member.State.SetStocktake(stocktake)
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+		return c.Send("No he podido guardar los juegos vistos, vuelve a enviarlos")
+	}
+
+	log.WithField(ilog.FieldLocation, stocktake.Location).Info("Games marked as seen")
+	lines = append(lines, fmt.Sprintf("\nLlevas %d juegos revisados", stocktake.Count()))
+	return c.Send(strings.Join(lines, "\n"))
+}
+
+func (h *Handler) OnFinishStocktake(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onFinishStocktake))(c)
+}
+
+func (h *Handler) onFinishStocktake(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "FinishStocktake"), c.Sender())
+
+	if !member.State.Is(StateActionStocktake) {
+		return c.Send("No hay ningún inventario en marcha", h.mainMenu(c, member))
+	}
+
+	stocktake, err := NewStocktakeFromData(member.State.Data)
+	if err != nil {
+		log.WithError(err).Error("Failed to load stocktake")
+		return c.Send("Wops! Algo ha ido mal, vuelve a empezar el inventario")
+	}
+
+	games, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+
+	member.State.SetStocktakeFinished()
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo")
+	}
+
+	report := stocktake.Report(games)
+	log.WithField("Entries", len(report)).Info("Stocktake finished")
+
+	text := report.String()
+	if len(text) > 4000 {
+		text = fmt.Sprintf("Juegos no encontrados: %d\nJuegos en otra ubicación: %d\nJuegos prestados: %d\n\nTienes el detalle en el documento",
+			len(report.Filter(StocktakeMissing)),
+			len(report.Filter(StocktakeWrongLocation)),
+			len(report.Filter(StocktakeHeld)),
+		)
+	}
+	c.Send(text, h.mainMenu(c, member))
+
+	data, err := report.CSV()
+	if err != nil {
+		log.WithError(err).Error("Failed to build csv")
+		return c.Send("No he podido generar el documento del inventario")
+	}
+	c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("inventario-%s.csv", time.Now().Format("2006-01-02")),
+		MIME:     "text/csv",
+	})
+
+	corrections := report.Corrections()
+	if len(corrections) == 0 {
+		return nil
+	}
+
+	selector := &tele.ReplyMarkup{}
+	selector.Inline(selector.Row(selector.Data("Corregir ubicaciones", "stocktake-apply")))
+	return c.Send(fmt.Sprintf("Hay %d juegos en otra ubicación, ¿quieres cambiar su ubicación en el excel a donde los has visto?", len(corrections)), selector)
+}
+
+func (h *Handler) OnApplyStocktake(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onApplyStocktake))(c)
+}
+
+func (h *Handler) onApplyStocktake(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "ApplyStocktake"), c.Sender())
+	defer c.Respond()
+
+	if !member.State.Is(StateActionStocktakeFinished) {
+		return c.Edit("Este inventario ya no está disponible")
+	}
+
+	stocktake, err := NewStocktakeFromData(member.State.Data)
+	if err != nil {
+		log.WithError(err).Error("Failed to load stocktake")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a empezar el inventario")
+	}
+
+	games, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+
+	corrections := stocktake.Report(games).Corrections()
+	if len(corrections) > 0 {
+		if err := h.GameDB.Update(context.Background(), corrections...); err != nil {
+			log.WithError(err).Error("Failed to update gameDB")
+			return c.Edit("No he podido actualizar la base de datos, vuelve a intentarlo")
+		}
+	}
+
+	member.State.Clear()
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+	}
+
+	log.WithField("Games", len(corrections)).Info("Stocktake corrections applied")
+	return c.Edit(fmt.Sprintf("Listo! He corregido la ubicación de %d juegos", len(corrections)))
+}
+
+func (h *Handler) OnCancelStocktake(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onCancelStocktake))(c)
+}
+
+func (h *Handler) onCancelStocktake(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "CancelStocktake"), c.Sender())
+
+	member.State.Clear()
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+	}
+	log.Info("Stocktake cancelled")
+	return c.Send("Inventario cancelado", h.mainMenu(c, member))
+}
+
+// labelsAllLocations is the callback data used to print the labels of every game
+const labelsAllLocations = "*"
+
+func labelsLocationButtons(locations Locations) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{
+		selector.Row(selector.Data("Todos los juegos", "labels", labelsAllLocations)),
+	}
+	for _, l := range locations {
+		rows = append(rows, selector.Row(
+			selector.Data(string(l.Name), "labels", string(l.Name)),
+		))
+	}
+	selector.Inline(rows...)
+	return selector
+}
+
+func (h *Handler) OnEventPublish(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onEventPublish))(c)
+}
+
+// onEventPublish asks for the event if there is more than one that hasn't finished yet
+func (h *Handler) onEventPublish(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "EventPublish"), c.Sender())
+
+	if h.Events == nil {
+		return c.Send("No hay ningún evento registrado")
+	}
+	events, err := h.Events.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list events")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	upcoming := Events(events).Upcoming(time.Now())
+	switch len(upcoming) {
+	case 0:
+		return c.Send("No hay ningún evento próximo")
+	case 1:
+		return h.eventPublish(c, member, upcoming[0])
+	default:
+		return c.Send("¿En qué evento quieres publicar juegos?", eventButtons("event-publish", upcoming))
+	}
+}
+
+func (h *Handler) OnEventPublishEvent(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onEventPublishEvent))(c)
+}
+
+func (h *Handler) onEventPublishEvent(c tele.Context, member Member) error {
+	defer c.Respond()
+
+	event, err := h.juegatronEvent(context.Background(), c.Data())
+	if err != nil {
+		return c.Edit("No he podido cargar el evento, vuelve a intentarlo")
+	}
+	c.Edit(fmt.Sprintf("Publicar juegos en %s", event.Info.Name))
+	return h.eventPublish(c, member, event.Info)
+}
+
+// eventPublish waits for the games to publish
+func (h *Handler) eventPublish(c tele.Context, member Member, event EventInfo) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "EventPublish"), c.Sender())
+
+	member.State.SetEventPublish(event.ID)
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo")
+	}
+	return c.Send(fmt.Sprintf("¿Qué juegos quieres publicar en %s? Envíame los IDs separados por espacios, el nombre de una ubicación o parte del nombre de los juegos", event.Name), adminMenuReplyMarkup(member))
+}
+
+// onEventPublishSelection shows the games that will be published and asks for confirmation
+func (h *Handler) onEventPublishSelection(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "EventPublishSelection"), c.Sender()).WithField(ilog.FieldText, c.Text())
+
+	eventID, _ := member.State.EventPublish()
+	event, err := h.juegatronEvent(context.Background(), eventID)
+	if err != nil {
+		log.WithError(err).Warn("Unable to load event")
+		member.State.Clear()
+		h.MembersDB.Update(context.Background(), member)
+		return c.Send("No he podido cargar el evento, vuelve a empezar", adminMenuReplyMarkup(member))
+	}
+
+	selection := ParseGameSelection(c.Text(), h.locations(context.Background()))
+	if selection.IsEmpty() {
+		return c.Send("Dime qué juegos quieres publicar")
+	}
+
+	games, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	selected := selection.Select(games)
+	if len(selected) == 0 {
+		return c.Send("No he encontrado ningún juego, prueba con otros IDs, una ubicación o un nombre")
+	}
+
+	member.State.SetEventPublishSelection(event.Info.ID, c.Text())
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo")
+	}
+
+	log.WithField("Results", len(selected)).Info("Asking to publish games")
+
+	c.Send(fmt.Sprintf("Voy a publicar %d juegos en %s y a reservarlos hasta que termine:", len(selected), event.Info.Name))
+	for _, block := range SendList(selected) {
+		c.Send(block)
+	}
+	selector := &tele.ReplyMarkup{}
+	selector.Inline(selector.Row(
+		selector.Data("Publicar", "event-publish-confirm", "publish"),
+		selector.Data("Cancelar", "event-publish-confirm", "cancel"),
+	))
+	return c.Send("¿Los publico?", selector)
+}
+
+func (h *Handler) OnEventPublishConfirm(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onEventPublishConfirm))(c)
+}
+
+func (h *Handler) onEventPublishConfirm(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "EventPublishConfirm"), c.Sender())
+	defer c.Respond()
+
+	eventID, text := member.State.EventPublish()
+	if !member.State.Is(StateActionEventPublish) || text == "" {
+		return c.Edit("Esta publicación ya no está en curso")
+	}
+
+	member.State.Clear()
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.WithError(err).Error("Failed to update memberDB")
+	}
+	if c.Data() != "publish" {
+		return c.Edit("Publicación cancelada")
+	}
+
+	event, err := h.juegatronEvent(context.Background(), eventID)
+	if err != nil {
+		log.WithError(err).Warn("Unable to load event")
+		return c.Edit("No he podido cargar el evento, vuelve a empezar")
+	}
+	log = log.WithField("Event", event.Info.ID)
+
+	games, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	selected := ParseGameSelection(text, h.locations(context.Background())).Select(games)
+
+	result, err := PublishToEvent(context.Background(), h.GameDB, event, selected)
+	if err != nil {
+		log.WithError(err).Error("Failed to publish games")
+		return c.Edit("Wops! No he podido publicar los juegos.\n" + err.Error())
+	}
+	log.
+		WithField("Published", len(result.Published)).
+		WithField("AlreadyListed", len(result.AlreadyListed)).
+		Info("Games published")
+	return c.Edit(fmt.Sprintf("%s en %s", result.String(), event.Info.Name))
+}
+
+func (h *Handler) OnLabels(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onLabels))(c)
+}
+
+func (h *Handler) onLabels(c tele.Context, member Member) error {
+	return c.Send("¿De qué ubicación quieres las etiquetas?", labelsLocationButtons(h.locations(context.Background())))
+}
+
+func (h *Handler) OnLabelsLocation(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onLabelsLocation))(c)
+}
+
+func (h *Handler) onLabelsLocation(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Labels"), c.Sender()).WithField(ilog.FieldLocation, c.Data())
+	defer c.Respond()
+
+	if h.BotName == "" {
+		log.Error("Bot name is not configured")
+		return c.Edit("No sé cómo me llamo, no puedo generar los enlaces de las etiquetas")
+	}
+
+	games, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos.\n" + err.Error())
+	}
+
+	gameLabels := []labels.Label{}
+	for _, g := range games {
+		if c.Data() != labelsAllLocations && !g.IsInLocation(Location(c.Data())) {
+			continue
+		}
+		gameLabels = append(gameLabels, g.Label(h.BotName))
+	}
+	if len(gameLabels) == 0 {
+		return c.Edit("No hay juegos en esta ubicación")
+	}
+
+	c.Edit(fmt.Sprintf("Generando %d etiquetas...", len(gameLabels)))
+
+	pdf := &bytes.Buffer{}
+	if err := labels.PDF(pdf, gameLabels); err != nil {
+		log.WithError(err).Error("Failed to generate pdf")
+		return c.Send("No he podido generar las etiquetas")
+	}
+	pages, err := labels.PNG(gameLabels)
+	if err != nil {
+		log.WithError(err).Error("Failed to generate png")
+		return c.Send("No he podido generar las etiquetas")
+	}
+	zipped := &bytes.Buffer{}
+	zw := zip.NewWriter(zipped)
+	for i, page := range pages {
+		f, err := zw.Create(fmt.Sprintf("etiquetas-%02d.png", i+1))
+		if err != nil {
+			log.WithError(err).Error("Failed to generate zip")
+			return c.Send("No he podido generar las etiquetas")
+		}
+		f.Write(page)
+	}
+	if err := zw.Close(); err != nil {
+		log.WithError(err).Error("Failed to generate zip")
+		return c.Send("No he podido generar las etiquetas")
+	}
+
+	c.Send(&tele.Document{
+		File:     tele.FromReader(pdf),
+		FileName: "etiquetas.pdf",
+		MIME:     "application/pdf",
+	})
+	c.Send(&tele.Document{
+		File:     tele.FromReader(zipped),
+		FileName: "etiquetas-png.zip",
+		MIME:     "application/zip",
+	})
+	log.WithField("Labels", len(gameLabels)).Info("Labels generated")
+	return nil
+}
+
+// inlineResultsPerPage is the maximum number of results accepted by telegram on each answer
+const inlineResultsPerPage = 50
+
+// OnQuery answers inline queries such as "@acnilbot catan" sent from any chat
+func (h *Handler) OnQuery(c tele.Context) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Query"), c.Sender()).WithField(ilog.FieldText, c.Query().Text)
+
+	member, err := h.MembersDB.Get(context.Background(), c.Sender().ID)
+	if err != nil {
+		log.WithError(err).Error("Cannot check membersDB")
+		return c.Answer(&tele.QueryResponse{Results: tele.Results{}, CacheTime: 0, IsPersonal: true})
+	}
+	if member == nil || !member.Permissions.IsAuthorised() {
+		log.Info("Permission denied")
+		return c.Answer(&tele.QueryResponse{
+			Results:           tele.Results{},
+			IsPersonal:        true,
+			SwitchPMText:      "Regístrate en el bot para buscar juegos",
+			SwitchPMParameter: "register",
+		})
+	}
+
+	text := strings.TrimSpace(c.Query().Text)
+	if text == "" {
+		return c.Answer(&tele.QueryResponse{Results: tele.Results{}, IsPersonal: true})
+	}
+
+	gameList, err := h.GameDB.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Answer(&tele.QueryResponse{Results: tele.Results{}, IsPersonal: true})
+	}
+
+	found := Games(gameList).FindSimilar(text)
+
+	offset, _ := strconv.Atoi(c.Query().Offset)
+	if offset > len(found) {
+		offset = len(found)
+	}
+	end := offset + inlineResultsPerPage
+	nextOffset := strconv.Itoa(end)
+	if end >= len(found) {
+		end = len(found)
+		nextOffset = ""
+	}
+
+	results := tele.Results{}
+	for i, g := range found[offset:end] {
+		title := fmt.Sprintf("%s: %s", g.ID, g.Name)
+		if g.MatchedAlias != "" {
+			title = fmt.Sprintf("%s (%s)", title, g.MatchedAlias)
+		}
+		result := &tele.ArticleResult{
+			Title:       title,
+			Description: g.Status(),
+			Text:        g.InlineCard(),
+		}
+		if h.BotName != "" {
+			selector := &tele.ReplyMarkup{}
+			selector.Inline(selector.Row(selector.URL("Ver en el bot", g.DeepLink(h.BotName))))
+			result.SetReplyMarkup(selector)
+		}
+		result.SetResultID(fmt.Sprintf("%d", offset+i))
+		results = append(results, result)
+	}
+
+	log.WithField("Results", len(found)).Info("Answering inline query")
+	return c.Answer(&tele.QueryResponse{
+		Results:    results,
+		CacheTime:  60,
+		IsPersonal: true,
+		NextOffset: nextOffset,
+	})
 }
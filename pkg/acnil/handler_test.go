@@ -2,6 +2,9 @@ package acnil_test
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/acnil/acnil-bot/pkg/acnil"
@@ -121,6 +124,57 @@ var _ = Describe("Handler", func() {
 			})
 		})
 	})
+	Describe("An unknown user using inline mode", func() {
+		BeforeEach(func() {
+			sender := &tele.User{ID: 1, FirstName: "New"}
+			mockTeleContext.EXPECT().Sender().Return(sender).AnyTimes()
+			mockTeleContext.EXPECT().Query().Return(&tele.Query{Text: "game"}).AnyTimes()
+			mockMembersDatabase.EXPECT().Get(gomock.Any(), sender.ID).Return(nil, nil)
+		})
+		It("Should be asked to register", func() {
+			mockTeleContext.EXPECT().Answer(gomock.Any()).DoAndReturn(func(resp *tele.QueryResponse) error {
+				Expect(resp.Results).To(BeEmpty())
+				Expect(resp.SwitchPMText).ToNot(BeEmpty())
+				return nil
+			})
+			err := h.OnQuery(mockTeleContext)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+	Describe("A new member coming from a deep link", func() {
+		var (
+			sender *tele.User
+		)
+		BeforeEach(func() {
+			sender = &tele.User{
+				ID:        1,
+				FirstName: "New",
+				LastName:  "User",
+			}
+			text := "/start loc_centro"
+			mockTeleContext.EXPECT().Sender().Return(sender).AnyTimes()
+			mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+			mockTeleContext.EXPECT().Message().Return(&tele.Message{
+				Sender:  sender,
+				Text:    text,
+				Payload: "loc_centro",
+				Chat: &tele.Chat{
+					Type: tele.ChatPrivate,
+				},
+			}).AnyTimes()
+			mockMembersDatabase.EXPECT().Get(gomock.Any(), sender.ID).Return(nil, nil)
+			mockMembersDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Member{}, nil)
+			mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+		})
+		It("Should remember the target until it is authorised", func() {
+			mockMembersDatabase.EXPECT().Append(gomock.Any(), gomock.AssignableToTypeOf(acnil.Member{})).Return(nil).Do(func(_ context.Context, member acnil.Member) {
+				Expect(member.State.Is(acnil.StateActionPendingStart)).To(BeTrue())
+				Expect(member.State.Data).To(Equal("loc_centro"))
+			})
+			err := h.Start(mockTeleContext)
+			Expect(err).To(BeNil())
+		})
+	})
 	Describe("A new member with username", func() {
 		var (
 			newMember *acnil.Member
@@ -308,9 +362,26 @@ var _ = Describe("Handler", func() {
 					Expect(sent).To(ContainSubstring("Game3"))
 					return nil
 				}).Times(1)
+				mockTeleContext.EXPECT().Send(ContainsString("Hay 1 juegos pendientes de devolver"), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+					Expect(buttons).To(ContainElement(HaveField("Unique", "remind-overdue")))
+					return nil
+				})
 				err := h.OnForgotten(mockTeleContext)
 				Expect(err).To(BeNil())
 			})
+			It("sends a reminder to the members that hold the games", func() {
+				other := acnil.Member{Nickname: "other user", TelegramID: "7", Permissions: acnil.PermissionYes}
+				mockTeleContext.EXPECT().Update().Return(tele.Update{}).AnyTimes()
+				mockTeleContext.EXPECT().Get(gomock.Any()).Return(context.Background()).AnyTimes()
+				mockMembersDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Member{*admin, other}, nil)
+				mockSender.EXPECT().Send(&other, ContainsString("Game3")).Return(nil, nil)
+				mockTeleContext.EXPECT().Respond()
+				mockTeleContext.EXPECT().Send(ContainsString("He enviado un recordatorio a 1 socios")).Return(nil)
+
+				err := h.OnRemindOverdue(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
 		})
 		Describe("Attempts to extend lease a game owned by other user", func() {
 			var (
@@ -324,7 +395,8 @@ var _ = Describe("Handler", func() {
 					TakeDate:   time.Now().Add(-21 * 24 * time.Hour),
 					ReturnDate: time.Now(),
 				}
-				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(game, nil)
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(game, nil)
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(*game).Encode("", "", "")).AnyTimes()
 				mockTeleContext.EXPECT().Message().Return(&tele.Message{
 					Sender: sender,
 					Chat: &tele.Chat{
@@ -412,6 +484,59 @@ var _ = Describe("Handler", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
+		Describe("When /start is received from a game deep link", func() {
+			BeforeEach(func() {
+				text := "/start game_0042"
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender:  sender,
+					Text:    text,
+					Payload: "game_0042",
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Unixtime: time.Now().Unix(),
+				}).AnyTimes()
+			})
+			It("Should reply with the game card", func() {
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
+					{ID: "1", Name: "Game1"},
+					{ID: "42", Name: "Game42"},
+				}, nil)
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Game42"))
+					buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+					Expect(buttons).To(ContainElement(WithButtonText("Tomar Prestado")))
+					return nil
+				})
+				err := h.Start(mockTeleContext)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+		Describe("When an inline query is received", func() {
+			BeforeEach(func() {
+				mockTeleContext.EXPECT().Query().Return(&tele.Query{Text: "game"}).AnyTimes()
+			})
+			It("Should answer with the matching games", func() {
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
+					{ID: "1", Name: "Game1", Location: string(acnil.LocationCentro)},
+					{ID: "2", Name: "Game2", Holder: "Other"},
+					{ID: "3", Name: "Other"},
+				}, nil)
+				mockTeleContext.EXPECT().Answer(gomock.Any()).DoAndReturn(func(resp *tele.QueryResponse) error {
+					Expect(resp.Results).To(HaveLen(2))
+					first := resp.Results[0].(*tele.ArticleResult)
+					Expect(first.Title).To(Equal("1: Game1"))
+					Expect(first.Description).To(ContainSubstring("Disponible en Centro"))
+					Expect(first.Text).To(ContainSubstring("Game1"))
+					second := resp.Results[1].(*tele.ArticleResult)
+					Expect(second.Description).To(ContainSubstring("Prestado a Other"))
+					return nil
+				})
+				err := h.OnQuery(mockTeleContext)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
 		Describe("When Text is sent", func() {
 			It("Should reply with game details", func() {
 				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
@@ -437,6 +562,53 @@ var _ = Describe("Handler", func() {
 				err := h.OnText(mockTeleContext)
 				Expect(err).ToNot(HaveOccurred())
 			})
+			It("Should filter the games if the text is a query", func() {
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
+					{ID: "1", Name: "Game1", MinPlayers: 2, MaxPlayers: 4},
+					{ID: "2", Name: "Game2", MinPlayers: 2, MaxPlayers: 6},
+				}, nil)
+				text := "jugadores:5"
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Text:   text,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Unixtime: time.Now().Unix(),
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Game2"))
+					Expect(sent).ToNot(ContainSubstring("Game1"))
+					return nil
+				})
+				err := h.OnText(mockTeleContext)
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("Should suggest a similar game if nothing matches", func() {
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
+					{
+						ID:   "12",
+						Name: "Carcassonne",
+					},
+				}, nil)
+				text := "karkason"
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Text:   text,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Unixtime: time.Now().Unix(),
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("¿Quisiste decir Carcassonne? /0012"))
+					return nil
+				})
+				err := h.OnText(mockTeleContext)
+				Expect(err).ToNot(HaveOccurred())
+			})
 			Describe("If the state is UpdateComment", func() {
 				BeforeEach(func() {
 					game := acnil.Game{
@@ -475,6 +647,161 @@ var _ = Describe("Handler", func() {
 
 			})
 		})
+		Describe("If the state is AddAlias", func() {
+			var game acnil.Game
+			BeforeEach(func() {
+				game = acnil.Game{
+					ID:   "1",
+					Name: "Aventureros al tren",
+				}
+				member.Permissions = acnil.PermissionAdmin
+				member.State.SetAddAlias(game)
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Aventureros al tren").Return(&game, nil)
+				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, member acnil.Member) error {
+					Expect(member.State.Action).To(BeEmpty())
+					return nil
+				})
+			})
+			It("Must add the aliases to the game", func() {
+				text := "Ticket to Ride; Zug um Zug"
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Text:   text,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Unixtime: time.Now().Unix(),
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, game acnil.Game) error {
+					Expect(game.Aliases).To(Equal([]string{"Ticket to Ride", "Zug um Zug"}))
+					return nil
+				})
+				mockTeleContext.EXPECT().Send(ContainsString("Ticket to Ride, Zug um Zug"), gomock.Any())
+				mockTeleContext.EXPECT().Send(ContainsString("Aventureros al tren"), gomock.Any())
+				err := h.OnText(mockTeleContext)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+		Describe("If the state is LinkHolder", func() {
+			BeforeEach(func() {
+				member.Permissions = acnil.PermissionAdmin
+				member.State.SetLinkHolder(acnil.Game{ID: "1", Name: "Game1"})
+				mockMembersDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Member{
+					*member,
+					{Nickname: "Juan", TelegramID: "2"},
+				}, nil)
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
+					{ID: "1", Name: "Game1", Holder: "juan garcia"},
+					{ID: "2", Name: "Game2", Holder: "Juan Garcia "},
+					{ID: "3", Name: "Game3", Holder: "Juan"},
+				}, nil)
+			})
+			It("Must link the loans with the same holder to the member", func() {
+				text := "juan"
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Text:   text,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Unixtime: time.Now().Unix(),
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, member acnil.Member) error {
+					Expect(member.State.Action).To(BeEmpty())
+					return nil
+				})
+				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, games ...acnil.Game) error {
+					Expect(games[0].ID).To(Equal("1"))
+					Expect(games[1].ID).To(Equal("2"))
+					for _, g := range games {
+						Expect(g.HolderID).To(Equal("2"))
+					}
+					return nil
+				})
+				mockTeleContext.EXPECT().Send(ContainsString("he vinculado 2 préstamos de juan garcia a Juan"), gomock.Any())
+
+				Expect(h.OnText(mockTeleContext)).To(Succeed())
+			})
+		})
+		Describe("If the state is Rename", func() {
+			var games []acnil.Game
+			BeforeEach(func() {
+				member.State.SetRename()
+				games = []acnil.Game{
+					{ID: "1", Name: "Game1", Holder: member.Nickname},
+					{ID: "2", Name: "Game2", Holder: "Other User"},
+					{ID: "3", Name: "Game3"},
+				}
+				mockMembersDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Member{
+					*member,
+					{Nickname: "Other", TelegramID: "2", PreviousNicknames: []string{"Pepe"}},
+				}, nil)
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return(games, nil).AnyTimes()
+			})
+			rename := func(text string) error {
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Text:   text,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Unixtime: time.Now().Unix(),
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				return h.OnText(mockTeleContext)
+			}
+			It("Must move the games held to the new name and keep the previous one", func() {
+				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, games ...acnil.Game) error {
+					Expect(games).To(HaveLen(1))
+					Expect(games[0].ID).To(Equal("1"))
+					Expect(games[0].Holder).To(Equal("Blueberry"))
+					return nil
+				})
+				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m acnil.Member) error {
+					Expect(m.Nickname).To(Equal("Blueberry"))
+					Expect(m.PreviousNicknames).To(Equal([]string{"MetalBlueberry"}))
+					Expect(m.State.Action).To(BeEmpty())
+					return nil
+				})
+				mockTeleContext.EXPECT().Send(ContainsString("ahora te llamas Blueberry"), gomock.Any())
+
+				Expect(rename("Blueberry")).To(Succeed())
+			})
+			It("Must move the games back if the member can't be updated", func() {
+				holders := []string{}
+				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, games ...acnil.Game) error {
+					holders = append(holders, games[0].Holder)
+					return nil
+				}).Times(2)
+				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).Return(errors.New("failed"))
+				mockTeleContext.EXPECT().Send(ContainsString("No he podido cambiarte el nombre"), gomock.Any())
+
+				Expect(rename("Blueberry")).To(Succeed())
+				Expect(holders).To(Equal([]string{"Blueberry", "MetalBlueberry"}))
+			})
+			It("Must not take the name as a location list button", func() {
+				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m acnil.Member) error {
+					Expect(m.Nickname).To(Equal("Lista de Gamonal"))
+					return nil
+				})
+				mockTeleContext.EXPECT().Send(ContainsString("ahora te llamas Lista de Gamonal"), gomock.Any())
+
+				Expect(rename("Lista de Gamonal")).To(Succeed())
+			})
+			It("Must reject the name of other member", func() {
+				mockTeleContext.EXPECT().Send(ContainsString("Ese nombre ya lo usa otro socio"), gomock.Any())
+
+				Expect(rename("pepe")).To(Succeed())
+			})
+			It("Must reject the name of a holder", func() {
+				mockTeleContext.EXPECT().Send(ContainsString("Ya hay juegos prestados a Other User"), gomock.Any())
+
+				Expect(rename("Other User")).To(Succeed())
+			})
+		})
 		Describe("When Text is sent with multiple lines", func() {
 			BeforeEach(func() {
 				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
@@ -630,10 +957,11 @@ var _ = Describe("Handler", func() {
 		})
 		Describe("When an user attempts to take a game that is available", func() {
 			BeforeEach(func() {
-				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
 					ID:   "1",
 					Name: "Game1",
 				}, nil)
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1"}).Encode("", "", "")).AnyTimes()
 
 				mockTeleContext.EXPECT().Message().Return(&tele.Message{
 					Sender: sender,
@@ -666,7 +994,9 @@ var _ = Describe("Handler", func() {
 		})
 		Describe("When an user attempts to take a game that is NOT available", func() {
 			BeforeEach(func() {
-				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+				// Buttons sent before the payloads were added only have the card
+				mockTeleContext.EXPECT().Data().Return("").AnyTimes()
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
 					ID:     "1",
 					Name:   "Game1",
 					Holder: "Other Person",
@@ -702,7 +1032,8 @@ var _ = Describe("Handler", func() {
 		})
 		Describe("When an user attempts to take a game that doesn't exist", func() {
 			BeforeEach(func() {
-				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(nil, nil)
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(nil, nil)
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1", Holder: "Other Person"}).Encode("", "", "")).AnyTimes()
 				mockTeleContext.EXPECT().Message().Return(&tele.Message{
 					Sender: sender,
 					Chat: &tele.Chat{
@@ -728,37 +1059,33 @@ var _ = Describe("Handler", func() {
 			})
 		})
 		Describe("When an user returns a game that is owned by himself", func() {
+			var game acnil.Game
 			BeforeEach(func() {
-				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+				game = acnil.Game{
 					ID:       "1",
 					Name:     "Game1",
 					Holder:   member.Nickname,
 					TakeDate: time.Date(2023, 2, 11, 0, 0, 0, 0, time.UTC),
-				}, nil)
+				}
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&game, nil)
 
 				mockTeleContext.EXPECT().Message().Return(&tele.Message{
 					Sender: sender,
 					Chat: &tele.Chat{
 						Type: tele.ChatPrivate,
 					},
-					Text: acnil.Game{
-						ID:   "1",
-						Name: "Game1",
-					}.Card(),
+					Text: game.Card(),
 				}).AnyTimes()
 			})
-			It("the game must be updated with empty holder", func() {
-				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{
-					ID:   "1",
-					Name: "Game1",
-				})).Do(func(_ context.Context, g acnil.Game) {
-					Expect(g.Name).To(Equal("Game1"))
-					Expect(g.Holder).To(BeEmpty())
-					Expect(g.TakeDate).To(BeZero())
-				})
+			It("must ask where the game has been returned", func() {
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(game).Encode("", "", "")).AnyTimes()
 				mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
 					Expect(sent).To(ContainSubstring("Game1"))
-					Expect(sent).ToNot(ContainSubstring(member.Nickname))
+					Expect(opt[0]).To(BeAssignableToTypeOf(&tele.ReplyMarkup{}))
+
+					buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+					Expect(buttons).To(ContainElement(WithButtonText("Devuelto en Gamonal")))
+					Expect(buttons).To(ContainElement(WithButtonText("Devuelto en Centro")))
 					return nil
 				})
 				mockTeleContext.EXPECT().Respond(gomock.Any())
@@ -766,25 +1093,50 @@ var _ = Describe("Handler", func() {
 				err := h.OnReturn(mockTeleContext)
 				Expect(err).To(BeNil())
 			})
+			Describe("and selects the location", func() {
+				BeforeEach(func() {
+					mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(game).Encode("", "", "") + "|" + string(acnil.LocationCentro)).AnyTimes()
+				})
+				It("the game must be updated with empty holder and the new location", func() {
+					mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{
+						ID:   "1",
+						Name: "Game1",
+					})).Do(func(_ context.Context, g acnil.Game) {
+						Expect(g.Name).To(Equal("Game1"))
+						Expect(g.Holder).To(BeEmpty())
+						Expect(g.TakeDate).To(BeZero())
+						Expect(g.Location).To(Equal(string(acnil.LocationCentro)))
+					})
+					mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+						Expect(sent).To(ContainSubstring("Game1"))
+						Expect(sent).ToNot(ContainSubstring(member.Nickname))
+						return nil
+					})
+					mockTeleContext.EXPECT().Respond(gomock.Any())
+
+					err := h.OnReturnLocation(mockTeleContext)
+					Expect(err).To(BeNil())
+				})
+			})
 		})
 		Describe("When an user returns a game that is owned not owned by himself", func() {
+			var game acnil.Game
 			BeforeEach(func() {
-				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+				game = acnil.Game{
 					ID:     "1",
 					Name:   "Game1",
 					Holder: "Other User",
-				}, nil)
+				}
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&game, nil)
 
 				mockTeleContext.EXPECT().Message().Return(&tele.Message{
 					Sender: sender,
 					Chat: &tele.Chat{
 						Type: tele.ChatPrivate,
 					},
-					Text: acnil.Game{
-						ID:   "1",
-						Name: "Game1",
-					}.Card(),
+					Text: game.Card(),
 				}).AnyTimes()
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(game).Encode("", "", "") + "|" + string(acnil.LocationGamonal)).AnyTimes()
 
 			})
 			It("the game must be updated with empty holder", func() {
@@ -795,6 +1147,7 @@ var _ = Describe("Handler", func() {
 					Expect(g.Name).To(Equal("Game1"))
 					Expect(g.Holder).To(BeEmpty())
 					Expect(g.TakeDate).To(BeZero())
+					Expect(g.Location).To(Equal(string(acnil.LocationGamonal)))
 				})
 				mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
 					Expect(sent).To(ContainSubstring("Game1"))
@@ -803,11 +1156,36 @@ var _ = Describe("Handler", func() {
 				})
 				mockTeleContext.EXPECT().Respond(gomock.Any())
 
-				err := h.OnReturn(mockTeleContext)
+				err := h.OnReturnLocation(mockTeleContext)
 				Expect(err).To(BeNil())
 			})
 
 		})
+		Describe("When an user returns a game that has changed of holder since the card was sent", func() {
+			BeforeEach(func() {
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
+					ID:     "1",
+					Name:   "Game1",
+					Holder: "Other User",
+				}, nil)
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1", Holder: member.Nickname}).Encode("", "", "") + "|" + string(acnil.LocationGamonal)).AnyTimes()
+			})
+			It("must not return the game and send updated data", func() {
+				mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("te envío los últimos actualizados"))
+					return nil
+				})
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Other User"))
+					return nil
+				})
+				mockTeleContext.EXPECT().Respond(gomock.Any())
+
+				err := h.OnReturnLocation(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+		})
+
 		Describe("When an user list games held by him", func() {
 			It("must list only games held", func() {
 				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
@@ -917,11 +1295,34 @@ var _ = Describe("Handler", func() {
 				err := h.MyGames(mockTeleContext)
 				Expect(err).To(BeNil())
 			})
+			It("must split long lists so the IDs fit in the buttons", func() {
+				h.PayloadSecret = "secret"
+				games := []acnil.Game{}
+				for i := 1000; i < 1030; i++ {
+					games = append(games, acnil.Game{ID: strconv.Itoa(i), Name: "Game", Holder: member.Nickname})
+				}
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return(games, nil)
+				sent := 0
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(text string, opt ...interface{}) error {
+					buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+					Expect(buttons).To(ContainElement(WithButtonText("Devolver todos")))
+					for _, b := range buttons {
+						Expect(len("\f" + b.Unique + "|" + b.Data)).To(BeNumerically("<=", 64))
+					}
+					sent += strings.Count(text, "\n") + 1
+					return nil
+				}).MinTimes(2)
+
+				err := h.MyGames(mockTeleContext)
+				Expect(err).To(BeNil())
+				Expect(sent).To(Equal(len(games)))
+			})
 		})
 
 		Describe("When a page is requested for a game card", func() {
 			BeforeEach(func() {
-				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1", Holder: member.Nickname}).Encode("", "", "")).AnyTimes()
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
 					ID:       "1",
 					Name:     "Game1",
 					Holder:   member.Nickname,
@@ -971,52 +1372,138 @@ var _ = Describe("Handler", func() {
 			})
 		})
 
-		Describe("When a game is switched locations", func() {
-
+		Describe("When the list of a registered location is requested", func() {
 			BeforeEach(func() {
-
+				h.LocationDB = acnil.StaticLocationDatabase{
+					{Name: acnil.LocationGamonal},
+					{Name: acnil.LocationCentro},
+					{Name: "Almacén"},
+				}
+				text := "Lista de Almacén"
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
 				mockTeleContext.EXPECT().Message().Return(&tele.Message{
 					Sender: sender,
+					Text:   text,
 					Chat: &tele.Chat{
 						Type: tele.ChatPrivate,
 					},
-					Text: acnil.Game{
-						ID:   "1",
-						Name: "Game1",
-					}.Card(),
 				}).AnyTimes()
-			})
-			Describe("When it was in Gamonal", func() {
-				BeforeEach(func() {
-					mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{
+					{
 						ID:       "1",
 						Name:     "Game1",
-						Holder:   member.Nickname,
-						TakeDate: time.Date(2023, 2, 11, 0, 0, 0, 0, time.UTC),
-						Location: string(acnil.LocationGamonal),
-					}, nil)
+						Location: "Almacén",
+					},
+					{
+						ID:       "2",
+						Name:     "Game2",
+						Location: string(acnil.LocationCentro),
+					},
+				}, nil)
+			})
+			It("must list only the games in that location", func() {
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Juegos en Almacén (1)"))
+
+					buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+					Expect(buttons).To(ContainElement(HaveField("Text", ContainSubstring("Game1"))))
+					Expect(buttons).ToNot(ContainElement(HaveField("Text", ContainSubstring("Game2"))))
+					return nil
 				})
-				It("Must be moved to Centro", func() {
-					mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{
+
+				err := h.OnText(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+			It("must change the page of the list", func() {
+				mockTeleContext.EXPECT().Data().Return("l|0|d|Almacén").AnyTimes()
+				mockTeleContext.EXPECT().Respond()
+				mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Juegos en Almacén (1)"))
+
+					buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+					Expect(buttons).To(ContainElement(WithButtonData("l|1")))
+					Expect(buttons).To(ContainElement(And(WithButtonText("📋 Todos"), WithButtonData("l|0|-|Almacén"))))
+					return nil
+				})
+
+				err := h.OnListPage(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+			It("must open the game pressed in the list", func() {
+				mockTeleContext.EXPECT().Data().Return("l|1").AnyTimes()
+				mockTeleContext.EXPECT().Respond()
+				mockTeleContext.EXPECT().Send(ContainsString("Game1"), gomock.Any())
+
+				err := h.OnListOpen(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("When a game is switched locations", func() {
+
+			BeforeEach(func() {
+
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Text: acnil.Game{
 						ID:   "1",
 						Name: "Game1",
-					})).Do(func(_ context.Context, g acnil.Game) {
-						Expect(g.Name).To(Equal("Game1"))
-						Expect(g.Location).To(Equal(string(acnil.LocationCentro)))
-					})
+					}.Card(),
+				}).AnyTimes()
+			})
 
-					mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
-						Expect(sent).To(ContainSubstring("Game1"))
-						Expect(sent).To(ContainSubstring(member.Nickname))
-						Expect(sent).To(ContainSubstring(string(acnil.LocationCentro)))
+			expectTransfer := func(from string, to acnil.Location) {
+				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{
+					ID:   "1",
+					Name: "Game1",
+				})).Do(func(_ context.Context, g acnil.Game) {
+					Expect(g.Name).To(Equal("Game1"))
+					Expect(g.Location).To(Equal(from))
+					Expect(g.TransferTo).To(Equal(string(to)))
+					Expect(g.TransferDate.IsZero()).To(BeFalse())
+					Expect(g.TransferBy).To(Equal(member.TelegramID))
+				})
 
-						Expect(opt[0]).To(BeAssignableToTypeOf(&tele.ReplyMarkup{}))
+				mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Game1"))
+					Expect(sent).To(ContainSubstring(member.Nickname))
+					Expect(sent).To(ContainSubstring("En tránsito → " + string(to)))
 
-						buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
-						Expect(buttons).To(ContainElement(WithButtonText("Mover a Gamonal")))
-						return nil
-					})
-					mockTeleContext.EXPECT().Respond(gomock.Any())
+					Expect(opt[0]).To(BeAssignableToTypeOf(&tele.ReplyMarkup{}))
+
+					buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+					Expect(buttons).To(ContainElement(WithButtonText("Confirmar llegada a " + string(to))))
+					Expect(buttons).To(ContainElement(WithButtonText("Cancelar traslado")))
+					return nil
+				})
+				mockTeleContext.EXPECT().Respond(gomock.Any())
+
+				admin := acnil.Member{
+					Nickname:    "Admin",
+					TelegramID:  "2",
+					Permissions: acnil.PermissionAdmin,
+				}
+				mockMembersDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Member{*member, admin}, nil)
+				mockSender.EXPECT().Send(&admin, gomock.Any()).Return(nil, nil)
+				mockSender.EXPECT().Send(&admin, gomock.Any(), gomock.Any()).Return(nil, nil)
+			}
+
+			Describe("When it was in Gamonal", func() {
+				BeforeEach(func() {
+					mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1", Holder: member.Nickname}).Encode("", "", "") + "|" + string(acnil.LocationCentro)).AnyTimes()
+					mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
+						ID:       "1",
+						Name:     "Game1",
+						Holder:   member.Nickname,
+						TakeDate: time.Date(2023, 2, 11, 0, 0, 0, 0, time.UTC),
+						Location: string(acnil.LocationGamonal),
+					}, nil)
+				})
+				It("Must be in transit to Centro", func() {
+					expectTransfer(string(acnil.LocationGamonal), acnil.LocationCentro)
 
 					err := h.OnSwitchLocation(mockTeleContext)
 					Expect(err).To(BeNil())
@@ -1024,7 +1511,8 @@ var _ = Describe("Handler", func() {
 			})
 			Describe("When it was in Centro", func() {
 				BeforeEach(func() {
-					mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+					mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1", Holder: member.Nickname}).Encode("", "", "") + "|" + string(acnil.LocationGamonal)).AnyTimes()
+					mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
 						ID:       "1",
 						Name:     "Game1",
 						Holder:   member.Nickname,
@@ -1032,27 +1520,8 @@ var _ = Describe("Handler", func() {
 						Location: string(acnil.LocationCentro),
 					}, nil)
 				})
-				It("Must be moved to Gamonal", func() {
-					mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{
-						ID:   "1",
-						Name: "Game1",
-					})).Do(func(_ context.Context, g acnil.Game) {
-						Expect(g.Name).To(Equal("Game1"))
-						Expect(g.Location).To(Equal(string(acnil.LocationGamonal)))
-					})
-
-					mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
-						Expect(sent).To(ContainSubstring("Game1"))
-						Expect(sent).To(ContainSubstring(member.Nickname))
-						Expect(sent).To(ContainSubstring(string(acnil.LocationGamonal)))
-
-						Expect(opt[0]).To(BeAssignableToTypeOf(&tele.ReplyMarkup{}))
-
-						buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
-						Expect(buttons).To(ContainElement(WithButtonText("Mover al Centro")))
-						return nil
-					})
-					mockTeleContext.EXPECT().Respond(gomock.Any())
+				It("Must be in transit to Gamonal", func() {
+					expectTransfer(string(acnil.LocationCentro), acnil.LocationGamonal)
 
 					err := h.OnSwitchLocation(mockTeleContext)
 					Expect(err).To(BeNil())
@@ -1060,7 +1529,8 @@ var _ = Describe("Handler", func() {
 			})
 			Describe("When it was in an unknown location", func() {
 				BeforeEach(func() {
-					mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "Game1").Return(&acnil.Game{
+					mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1", Holder: member.Nickname}).Encode("", "", "") + "|" + string(acnil.LocationGamonal)).AnyTimes()
+					mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
 						ID:       "1",
 						Name:     "Game1",
 						Holder:   member.Nickname,
@@ -1068,29 +1538,148 @@ var _ = Describe("Handler", func() {
 						Location: "Invalid location",
 					}, nil)
 				})
-				It("Must be moved to Gamonal", func() {
-					mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{
+				It("Must be in transit to Gamonal", func() {
+					expectTransfer("Invalid location", acnil.LocationGamonal)
+
+					err := h.OnSwitchLocation(mockTeleContext)
+					Expect(err).To(BeNil())
+				})
+			})
+		})
+		Describe("When the arrival of a game is confirmed", func() {
+			BeforeEach(func() {
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+					Text: acnil.Game{
 						ID:   "1",
 						Name: "Game1",
-					})).Do(func(_ context.Context, g acnil.Game) {
-						Expect(g.Name).To(Equal("Game1"))
-						Expect(g.Location).To(Equal(string(acnil.LocationGamonal)))
+					}.Card(),
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1"}).Encode("", "", "")).AnyTimes()
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
+					ID:           "1",
+					Name:         "Game1",
+					Location:     string(acnil.LocationGamonal),
+					TransferTo:   string(acnil.LocationCentro),
+					TransferDate: time.Now(),
+				}, nil)
+			})
+			Describe("By a custodian of the destination", func() {
+				BeforeEach(func() {
+					h.LocationDB = acnil.StaticLocationDatabase{
+						{Name: acnil.LocationGamonal},
+						{Name: acnil.LocationCentro, Custodians: "Other, " + member.Nickname},
+					}
+				})
+				It("Must be moved to Centro", func() {
+					mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{})).Do(func(_ context.Context, g acnil.Game) {
+						Expect(g.Location).To(Equal(string(acnil.LocationCentro)))
+						Expect(g.IsInTransit()).To(BeFalse())
 					})
-
 					mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
-						Expect(sent).To(ContainSubstring("Game1"))
-						Expect(sent).To(ContainSubstring(member.Nickname))
-						Expect(sent).To(ContainSubstring(string(acnil.LocationGamonal)))
+						Expect(sent).ToNot(ContainSubstring("En tránsito"))
+						buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
+						Expect(buttons).To(ContainElement(WithButtonText("Tomar Prestado")))
+						return nil
+					})
+					mockTeleContext.EXPECT().Respond()
 
-						Expect(opt[0]).To(BeAssignableToTypeOf(&tele.ReplyMarkup{}))
+					err := h.OnConfirmTransfer(mockTeleContext)
+					Expect(err).To(BeNil())
+				})
+			})
+			Describe("By a custodian listed by telegram ID", func() {
+				BeforeEach(func() {
+					h.LocationDB = acnil.StaticLocationDatabase{
+						{Name: acnil.LocationGamonal},
+						{Name: acnil.LocationCentro, Custodians: "Other, " + member.TelegramID},
+					}
+				})
+				It("Must be moved to Centro", func() {
+					mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{})).Do(func(_ context.Context, g acnil.Game) {
+						Expect(g.Location).To(Equal(string(acnil.LocationCentro)))
+					})
+					mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any())
+					mockTeleContext.EXPECT().Respond()
 
-						buttons := ToOneDimension(opt[0].(*tele.ReplyMarkup).InlineKeyboard)
-						Expect(buttons).To(ContainElement(WithButtonText("Mover al Centro")))
+					err := h.OnConfirmTransfer(mockTeleContext)
+					Expect(err).To(BeNil())
+				})
+			})
+			Describe("By someone who is not a custodian", func() {
+				BeforeEach(func() {
+					h.LocationDB = acnil.StaticLocationDatabase{
+						{Name: acnil.LocationGamonal},
+						{Name: acnil.LocationCentro, Custodians: "Other"},
+					}
+				})
+				It("Must not be moved", func() {
+					mockTeleContext.EXPECT().Respond(gomock.Any()).DoAndReturn(func(resp ...*tele.CallbackResponse) error {
+						Expect(resp[0].Text).To(ContainSubstring("Solo los responsables de Centro"))
 						return nil
 					})
-					mockTeleContext.EXPECT().Respond(gomock.Any())
 
-					err := h.OnSwitchLocation(mockTeleContext)
+					err := h.OnConfirmTransfer(mockTeleContext)
+					Expect(err).To(BeNil())
+				})
+			})
+		})
+		Describe("When a transfer is cancelled", func() {
+			var transferBy string
+			BeforeEach(func() {
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1"}).Encode("", "", "")).AnyTimes()
+				h.LocationDB = acnil.StaticLocationDatabase{
+					{Name: acnil.LocationGamonal},
+					{Name: acnil.LocationCentro, Custodians: "Other"},
+				}
+			})
+			JustBeforeEach(func() {
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{
+					ID:           "1",
+					Name:         "Game1",
+					Location:     string(acnil.LocationGamonal),
+					TransferTo:   string(acnil.LocationCentro),
+					TransferDate: time.Now(),
+					TransferBy:   transferBy,
+				}, nil)
+			})
+			Describe("By the member that sent it", func() {
+				BeforeEach(func() {
+					transferBy = member.TelegramID
+				})
+				It("Must stay in Gamonal", func() {
+					mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.AssignableToTypeOf(acnil.Game{})).Do(func(_ context.Context, g acnil.Game) {
+						Expect(g.Location).To(Equal(string(acnil.LocationGamonal)))
+						Expect(g.IsInTransit()).To(BeFalse())
+						Expect(g.TransferBy).To(BeEmpty())
+					})
+					mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any())
+					mockTeleContext.EXPECT().Respond()
+
+					err := h.OnCancelTransfer(mockTeleContext)
+					Expect(err).To(BeNil())
+				})
+			})
+			Describe("By someone else", func() {
+				BeforeEach(func() {
+					transferBy = "999"
+				})
+				It("Must keep the transfer", func() {
+					mockTeleContext.EXPECT().Respond(gomock.Any()).DoAndReturn(func(resp ...*tele.CallbackResponse) error {
+						Expect(resp[0].Text).To(ContainSubstring("pueden cancelar el traslado"))
+						return nil
+					})
+
+					err := h.OnCancelTransfer(mockTeleContext)
 					Expect(err).To(BeNil())
 				})
 			})
@@ -1109,6 +1698,8 @@ var _ = Describe("Handler", func() {
 				}).AnyTimes()
 			})
 			It("Must ask for the new comment", func() {
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1"}).Encode("", "", ""))
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(&acnil.Game{ID: "1", Name: "Game1"}, nil)
 				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, member acnil.Member) error {
 					Expect(member.State.Data).To(ContainSubstring("Game1"))
 					return nil
@@ -1123,6 +1714,264 @@ var _ = Describe("Handler", func() {
 				Expect(err).To(BeNil())
 			})
 		})
+
+		Describe("When the details of a game that doesn't exist anymore are requested", func() {
+			It("Must tell the game is not found", func() {
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(acnil.Game{ID: "1", Name: "Game1"}).Encode("", "", "")).AnyTimes()
+				mockGameDatabase.EXPECT().Get(gomock.Any(), "1", "").Return(nil, nil)
+				mockTeleContext.EXPECT().Edit(gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("No he podido encontrar el juego"))
+					return nil
+				})
+				mockTeleContext.EXPECT().Respond()
+
+				err := h.OnMore(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("When all the games of a list are taken", func() {
+			var games []acnil.Game
+			BeforeEach(func() {
+				games = []acnil.Game{
+					{ID: "1", Name: "Game1"},
+					{ID: "2", Name: "Game2"},
+				}
+				mockTeleContext.EXPECT().Data().Return(acnil.NewGamesPayload(games).Encode("", "", "")).AnyTimes()
+				mockTeleContext.EXPECT().Respond()
+			})
+			It("Must take the games of the payload", func() {
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return(append([]acnil.Game{{ID: "3", Name: "Game3"}}, games...), nil)
+				mockGameDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, taken ...acnil.Game) error {
+					Expect(taken).To(HaveLen(2))
+					for _, g := range taken {
+						Expect(g.ID).ToNot(Equal("3"))
+						Expect(g.Holder).To(Equal(member.Nickname))
+						Expect(g.HolderID).To(Equal(member.TelegramID))
+					}
+					return nil
+				})
+				mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Game1"))
+					Expect(sent).To(ContainSubstring("Game2"))
+					return nil
+				})
+
+				err := h.OnTakeAll(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+			It("Must not take them if a game has changed", func() {
+				games[1].Holder = "Other"
+				mockGameDatabase.EXPECT().List(gomock.Any()).Return(games, nil)
+				mockTeleContext.EXPECT().Send(gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Parece que los datos han cambiado"))
+					return nil
+				})
+				mockTeleContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Other"))
+					return nil
+				})
+
+				err := h.OnTakeAll(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("When Juegatron mode is requested", func() {
+			BeforeEach(func() {
+				text := "Juegatron!"
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Text:   text,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+				}).AnyTimes()
+				h.Events = acnil.StaticEventDatabase{
+					{ID: "juegatron-2023", Name: "Juegatron 2023", SheetID: "sheet"},
+					{ID: "past", Name: "Past event", SheetID: "sheet", End: time.Now().AddDate(0, 0, -2)},
+					{ID: "other", Name: "Other volunteers", SheetID: "sheet", Volunteers: "Someone"},
+				}
+				h.OpenEvent = func(info acnil.EventInfo) *acnil.JuegatronEvent {
+					return &acnil.JuegatronEvent{Info: info}
+				}
+			})
+			It("Must enter the only event available to the member", func() {
+				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, member acnil.Member) error {
+					Expect(member.State.Is(acnil.StateActionJuegatron)).To(BeTrue())
+					Expect(member.State.JuegatronEventID()).To(Equal("juegatron-2023"))
+					return nil
+				})
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Juegatron 2023"))
+					return nil
+				})
+				err := h.OnJuegatron(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+			It("Must ask for the event if there are several", func() {
+				h.Events = append(h.Events.(acnil.StaticEventDatabase), acnil.EventInfo{ID: "second", Name: "Second", SheetID: "sheet"})
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					markup := opt[0].(*tele.ReplyMarkup)
+					Expect(markup.InlineKeyboard).To(HaveLen(2))
+					Expect(markup.InlineKeyboard[1][0].Data).To(Equal("second"))
+					return nil
+				})
+				err := h.OnJuegatron(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("When the member is in an event that no longer exists", func() {
+			BeforeEach(func() {
+				member.State.SetJuegatron("removed")
+				h.Events = acnil.StaticEventDatabase{}
+				text := "Catan"
+				mockTeleContext.EXPECT().Text().Return(text).AnyTimes()
+				mockTeleContext.EXPECT().Callback().Return(nil).AnyTimes()
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Text:   text,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+				}).AnyTimes()
+			})
+			It("Must leave the event mode", func() {
+				mockMembersDatabase.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, member acnil.Member) error {
+					Expect(member.State.Action).To(BeEmpty())
+					return nil
+				})
+				mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("vuelve a entrar en modo Juegatron"))
+					return nil
+				})
+				err := h.OnText(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Describe("When a Juegatron button is pressed", func() {
+			BeforeEach(func() {
+				h.Events = acnil.StaticEventDatabase{
+					{ID: "other", Name: "Other volunteers", SheetID: "sheet", Volunteers: "Someone"},
+				}
+				h.OpenEvent = func(info acnil.EventInfo) *acnil.JuegatronEvent {
+					return &acnil.JuegatronEvent{Info: info}
+				}
+				mockTeleContext.EXPECT().Message().Return(&tele.Message{
+					Sender: sender,
+					Chat: &tele.Chat{
+						Type: tele.ChatPrivate,
+					},
+				}).AnyTimes()
+				mockTeleContext.EXPECT().Callback().Return(&tele.Callback{Unique: "undo-juegatron"}).AnyTimes()
+				mockTeleContext.EXPECT().Respond().AnyTimes()
+			})
+			It("Must keep the state of the member if the event no longer exists", func() {
+				member.State.SetRename()
+				mockTeleContext.EXPECT().Data().Return("1|Game1|removed").AnyTimes()
+				mockTeleContext.EXPECT().Send(gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("Este botón ha caducado"))
+					return nil
+				})
+				err := h.OnUndoJuegatron(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+			It("Must load the event from the button and check the member is a volunteer", func() {
+				mockTeleContext.EXPECT().Data().Return("1|Game1|other").AnyTimes()
+				mockTeleContext.EXPECT().Send(gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+					Expect(sent).To(ContainSubstring("No eres voluntario"))
+					return nil
+				})
+				err := h.OnUndoJuegatron(mockTeleContext)
+				Expect(err).To(BeNil())
+			})
+			Describe("To undo a loan", func() {
+				var loanLog *memoryJuegatronLog
+				BeforeEach(func() {
+					loanLog = &memoryJuegatronLog{entries: []acnil.JuegatronAuditEntry{{ID: "1", Holder: "Alice", Actor: member.Nickname}}}
+					catalogue := &memoryCatalogue{{ID: "1", Name: "Game1"}}
+					h.Events = acnil.StaticEventDatabase{
+						{ID: "juegatron", Name: "Juegatron", SheetID: "sheet"},
+					}
+					h.OpenEvent = func(info acnil.EventInfo) *acnil.JuegatronEvent {
+						return &acnil.JuegatronEvent{
+							Info:      info,
+							Audit:     &acnil.JuegatronAudit{AuditDB: loanLog, GameDB: catalogue},
+							Catalogue: catalogue,
+						}
+					}
+					game := acnil.Game{ID: "1", Name: "Game1", Holder: "Alice"}
+					mockTeleContext.EXPECT().Data().Return(acnil.NewGamePayload(game).Encode("", "", "juegatron") + "|juegatron").AnyTimes()
+					mockTeleContext.EXPECT().Edit(gomock.Any()).AnyTimes()
+					mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).AnyTimes()
+				})
+				It("Must read the game from the payload of the button", func() {
+					Expect(h.OnUndoJuegatron(mockTeleContext)).To(Succeed())
+					Expect(loanLog.entries).To(HaveLen(2))
+					Expect(loanLog.entries[1].ID).To(Equal("1"))
+					Expect(loanLog.entries[1].Action).To(Equal(acnil.JuegatronActionUndo))
+				})
+			})
+			Describe("To close the event by an admin", func() {
+				var loanLog *memoryJuegatronLog
+				BeforeEach(func() {
+					member.Permissions = acnil.PermissionAdmin
+					loanLog = &memoryJuegatronLog{entries: []acnil.JuegatronAuditEntry{
+						{ID: "1", Holder: "Alice", Actor: "Pepe"},
+						{ID: "2", Holder: "Bob", Actor: "Pepe"},
+						{ID: "2", Holder: acnil.JuegatronLostHolder, Actor: "Pepe"},
+					}}
+					catalogue := &memoryCatalogue{{ID: "1", Name: "Game1"}, {ID: "2", Name: "Game2"}}
+					h.Events = acnil.StaticEventDatabase{
+						{ID: "juegatron", Name: "Juegatron", SheetID: "sheet"},
+					}
+					h.OpenEvent = func(info acnil.EventInfo) *acnil.JuegatronEvent {
+						return &acnil.JuegatronEvent{
+							Info:      info,
+							Audit:     &acnil.JuegatronAudit{AuditDB: loanLog, GameDB: catalogue},
+							Catalogue: catalogue,
+						}
+					}
+					mockTeleContext.EXPECT().Data().Return("return|juegatron").AnyTimes()
+				})
+				It("Must return the games that are not lost through the loans of the event", func() {
+					mockTeleContext.EXPECT().Edit(gomock.Any()).DoAndReturn(func(sent string, opt ...interface{}) error {
+						Expect(sent).To(ContainSubstring("He marcado 1 juegos como devueltos"))
+						return nil
+					})
+					Expect(h.OnJuegatronCloseConfirm(mockTeleContext)).To(Succeed())
+					Expect(loanLog.entries).To(HaveLen(4))
+					Expect(loanLog.entries[3].ID).To(Equal("1"))
+					Expect(loanLog.entries[3].IsReturn()).To(BeTrue())
+					Expect(loanLog.entries[3].Actor).To(Equal(member.Nickname))
+				})
+			})
+			Describe("To close the event by a member that is not admin", func() {
+				BeforeEach(func() {
+					h.Events = acnil.StaticEventDatabase{
+						{ID: "other", Name: "Anyone can lend", SheetID: "sheet"},
+					}
+					h.OpenEvent = func(info acnil.EventInfo) *acnil.JuegatronEvent {
+						Fail("the event must not be opened")
+						return nil
+					}
+					mockTeleContext.EXPECT().Data().Return("return|other").AnyTimes()
+					mockTeleContext.EXPECT().Text().Return("").AnyTimes()
+					mockGameDatabase.EXPECT().List(gomock.Any()).Return([]acnil.Game{}, nil).AnyTimes()
+					mockTeleContext.EXPECT().Send(gomock.Any(), gomock.Any()).AnyTimes()
+					mockTeleContext.EXPECT().Send(gomock.Any()).AnyTimes()
+				})
+				It("Must not ask for confirmation", func() {
+					Expect(h.OnJuegatronClose(mockTeleContext)).To(Succeed())
+				})
+				It("Must not close the loans", func() {
+					Expect(h.OnJuegatronCloseConfirm(mockTeleContext)).To(Succeed())
+				})
+			})
+		})
 	})
 
 })
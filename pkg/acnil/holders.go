@@ -0,0 +1,348 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v3"
+)
+
+// HolderResolver finds the member that holds a game, by HolderID or by the free text in Holder
+type HolderResolver struct {
+	Members []Member
+}
+
+func NewHolderResolver(members []Member) HolderResolver {
+	return HolderResolver{Members: members}
+}
+
+// Resolve returns the member that holds the game. ok is false if the game is available,
+// or the holder doesn't match exactly one member by its current or previous nicknames.
+// The HolderID is used while the Holder is still one of the names of that member, otherwise the link is stale and the holder is found by name
+func (r HolderResolver) Resolve(g Game) (_ Member, ok bool) {
+	if g.IsAvailable() {
+		return Member{}, false
+	}
+	if m, ok := r.linked(g); ok {
+		return m, true
+	}
+	return r.ByName(g.Holder)
+}
+
+// linked returns the member of the HolderID, if it still has the name of the holder
+func (r HolderResolver) linked(g Game) (Member, bool) {
+	if g.HolderID == "" {
+		return Member{}, false
+	}
+	m, ok := r.ByTelegramID(g.HolderID)
+	if !ok || !m.HasName(g.Holder) {
+		return Member{}, false
+	}
+	return m, true
+}
+
+// IsStale returns true if the loan is linked to a member that doesn't exist or doesn't have the name of the holder,
+// usually because the holder has been changed by hand in the sheet. The loan must be linked again
+func (r HolderResolver) IsStale(g Game) bool {
+	if g.IsAvailable() || g.HolderID == "" {
+		return false
+	}
+	_, ok := r.linked(g)
+	return !ok
+}
+
+// NeedsLink returns true if the loan is not linked to a member or the link is stale
+func (r HolderResolver) NeedsLink(g Game) bool {
+	return !g.IsAvailable() && (g.HolderID == "" || r.IsStale(g))
+}
+
+// ByTelegramID returns the member with the telegram ID
+func (r HolderResolver) ByTelegramID(id string) (Member, bool) {
+	for _, m := range r.Members {
+		if m.TelegramID == strings.TrimSpace(id) {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// ByName returns the member with the name, ok is false if there is none or several
+func (r HolderResolver) ByName(name string) (Member, bool) {
+	found := []Member{}
+	for _, m := range r.Members {
+		if m.HasName(name) {
+			found = append(found, m)
+		}
+	}
+	if len(found) != 1 {
+		return Member{}, false
+	}
+	return found[0], true
+}
+
+// Link sets the HolderID of the loans that are not linked, or have a stale link, and can be resolved by name.
+// It returns the games that have been linked and the loans that still need to be linked by hand
+func (r HolderResolver) Link(games []Game) (linked []Game, unresolved []Game) {
+	linked = []Game{}
+	unresolved = []Game{}
+	for _, g := range games {
+		if !r.NeedsLink(g) {
+			continue
+		}
+		m, ok := r.ByName(g.Holder)
+		if !ok {
+			unresolved = append(unresolved, g)
+			continue
+		}
+		g.HolderID = m.TelegramID
+		linked = append(linked, g)
+	}
+	return linked, unresolved
+}
+
+// GroupByHolder groups the games by the member that holds them. The games that can't be resolved are returned apart
+func (r HolderResolver) GroupByHolder(games []Game) (held map[string][]Game, unresolved []Game) {
+	held = map[string][]Game{}
+	unresolved = []Game{}
+	for _, g := range games {
+		if g.IsAvailable() {
+			continue
+		}
+		m, ok := r.Resolve(g)
+		if !ok {
+			unresolved = append(unresolved, g)
+			continue
+		}
+		held[m.TelegramID] = append(held[m.TelegramID], g)
+	}
+	return held, unresolved
+}
+
+func (h *Handler) OnLinkHolders(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onLinkHolders))(c)
+}
+
+// onLinkHolders links the loans to the members that can be found by name, and lists the ones that can't
+func (h *Handler) onLinkHolders(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "LinkHolders"), c.Sender())
+
+	resolver, games, err := h.holderResolver(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to load members and games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+
+	linked, unresolved := resolver.Link(games)
+	// Stale links that can't be resolved are removed, so the card offers to link them by hand
+	changed := append([]Game{}, linked...)
+	for i := range unresolved {
+		if unresolved[i].HolderID != "" {
+			unresolved[i].HolderID = ""
+			changed = append(changed, unresolved[i])
+		}
+	}
+	if err := h.updateGames(ctx, changed); err != nil {
+		log.WithError(err).Error("Failed to update gameDB")
+		return c.Send("No he podido actualizar la base de datos, vuelve a intentarlo")
+	}
+	log.WithField("Linked", len(linked)).WithField("Unresolved", len(unresolved)).Info("Holders linked")
+
+	if len(unresolved) == 0 {
+		return c.Send(fmt.Sprintf("He vinculado %d préstamos, todos los préstamos tienen un socio", len(linked)), adminMenuReplyMarkup(member))
+	}
+	c.Send(fmt.Sprintf("He vinculado %d préstamos. Estos %d no tienen un socio claro, abre cada juego y usa \"Vincular socio\" en la segunda página", len(linked), len(unresolved)), adminMenuReplyMarkup(member))
+	for _, block := range SendList(unresolved) {
+		if err := c.Send(block); err != nil {
+			log.Error(err)
+		}
+	}
+	return nil
+}
+
+// holderResolver loads the members and the games
+func (h *Handler) holderResolver(ctx context.Context) (HolderResolver, []Game, error) {
+	members, err := h.MembersDB.List(ctx)
+	if err != nil {
+		return HolderResolver{}, nil, fmt.Errorf("failed to list members, %w", err)
+	}
+	games, err := h.GameDB.List(ctx)
+	if err != nil {
+		return HolderResolver{}, nil, fmt.Errorf("failed to list games, %w", err)
+	}
+	return NewHolderResolver(members), games, nil
+}
+
+func (h *Handler) OnLinkHolderButton(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onLinkHolderButton))(c)
+}
+
+// onLinkHolderButton asks for the member that holds the game of the card
+func (h *Handler) onLinkHolderButton(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "LinkHolderButton"), c.Sender())
+	defer c.Respond()
+
+	payload, _, err := h.gamePayloadFromCallback(c)
+	if err != nil {
+		c.Edit("Wops! Algo ha ido mal....\nInténtalo de nuevo")
+		return fmt.Errorf("failed to read the button payload, %w", err)
+	}
+	log = log.WithField("ID", payload.ID)
+
+	getResult, err := payload.Find(h.gameGetter(context.TODO()))
+	if err != nil {
+		log.WithError(err).Error("Unable to get from GameDB")
+		return c.Send(err.Error())
+	}
+	if getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+	}
+	g := *getResult
+	log = log.WithField("Game", g.Name)
+
+	if g.IsAvailable() {
+		return c.Send("Este juego no está prestado", h.mainMenu(c, member))
+	}
+
+	member.State.SetLinkHolder(g)
+	if err := h.MembersDB.Update(context.Background(), member); err != nil {
+		log.Error("Failed to updated memberDB")
+		return err
+	}
+
+	return c.Send(fmt.Sprintf("¿Quién es %s? Dime el nombre o el ID de telegram del socio que tiene %s", strings.TrimSpace(g.Holder), g.Name), cancelMenu)
+}
+
+// onLinkHolder links the loan of the game, and the other loans with the same holder that need a link, to the member written by the admin
+func (h *Handler) onLinkHolder(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "LinkHolder"), c.Sender())
+
+	g := NewGameFromLineData(member.State.Data)
+	log = log.WithField("Game", g.Name)
+
+	resolver, games, err := h.holderResolver(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to load members and games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+
+	holder, ok := resolver.ByTelegramID(c.Text())
+	if !ok {
+		holder, ok = resolver.ByName(c.Text())
+	}
+	if !ok {
+		log.WithField(ilog.FieldName, c.Text()).Info("Member not found")
+		return c.Send("No encuentro a un único socio con ese nombre, prueba con su ID de telegram", cancelMenu)
+	}
+
+	getResult, err := Games(games).Get(g.ID, g.Name)
+	if err != nil || getResult == nil {
+		log.Warn("Unable to find game")
+		return c.Send("No he podido encontrar el juego. Intenta volver a buscarlo, tal vez se ha modificado el excel")
+	}
+	g = *getResult
+
+	member.State.Clear()
+	if err := h.MembersDB.Update(ctx, member); err != nil {
+		log.Error("Failed to updated memberDB")
+		return err
+	}
+
+	if g.IsAvailable() {
+		return c.Send("Este juego ya no está prestado", h.mainMenu(c, member))
+	}
+
+	linked := []Game{}
+	for _, other := range games {
+		if !resolver.NeedsLink(other) || Norm(strings.TrimSpace(other.Holder)) != Norm(strings.TrimSpace(g.Holder)) {
+			continue
+		}
+		other.HolderID = holder.TelegramID
+		linked = append(linked, other)
+	}
+	if len(linked) == 0 {
+		g.HolderID = holder.TelegramID
+		linked = append(linked, g)
+	}
+	if err := h.updateGames(ctx, linked); err != nil {
+		log.WithError(err).Error("Failed to update gameDB")
+		return c.Send("No he podido actualizar la base de datos, vuelve a intentarlo", h.mainMenu(c, member))
+	}
+	log.WithField(ilog.FieldName, holder.Nickname).WithField("Games", len(linked)).Info("Holder linked")
+
+	return c.Send(fmt.Sprintf("Hecho, he vinculado %d préstamos de %s a %s", len(linked), strings.TrimSpace(g.Holder), holder.Nickname), h.mainMenu(c, member))
+}
+
+// remindOverdueButtons offers to send a reminder to the members with overdue games
+func remindOverdueButtons() *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	selector.Inline(selector.Row(selector.Data("📨 Enviar recordatorio a los socios", "remind-overdue")))
+	return selector
+}
+
+func (h *Handler) OnRemindOverdue(c tele.Context) error {
+	return h.IsAuthorized(h.IsAdmin(h.onRemindOverdue))(c)
+}
+
+// onRemindOverdue sends a message to every member that should have returned a game already
+func (h *Handler) onRemindOverdue(c tele.Context, member Member) error {
+	ctx, cancel := GetContext(c)
+	defer cancel()
+	defer c.Respond()
+
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "RemindOverdue"), c.Sender())
+
+	resolver, games, err := h.holderResolver(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to load members and games")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+
+	overdue := []Game{}
+	for _, g := range games {
+		if !g.IsAvailable() && g.IsLeaseExpired() {
+			overdue = append(overdue, g)
+		}
+	}
+	held, unresolved := resolver.GroupByHolder(overdue)
+
+	ids := make([]string, 0, len(held))
+	for id := range held {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	sent := 0
+	for _, id := range ids {
+		holder, _ := resolver.ByTelegramID(id)
+		msg := fmt.Sprintf("⏰ Hola %s, ya deberías haber devuelto estos juegos:\n%s\nSi necesitas mas tiempo, pulsa \"Dar mas tiempo\" en el juego", holder.Nickname, JoinList(held[id]))
+		if _, err := h.Bot.Send(&holder, msg); err != nil {
+			log.WithError(err).WithField(ilog.FieldName, holder.Nickname).Error("Failed to send reminder")
+			unresolved = append(unresolved, held[id]...)
+			continue
+		}
+		sent++
+	}
+	log.WithField("Sent", sent).WithField("Unresolved", len(unresolved)).Info("Reminders sent")
+
+	if len(unresolved) == 0 {
+		return c.Send(fmt.Sprintf("He enviado un recordatorio a %d socios", sent))
+	}
+	c.Send(fmt.Sprintf("He enviado un recordatorio a %d socios. No he podido avisar de estos préstamos, usa \"Vincular socio\" en el juego", sent))
+	for _, block := range SendList(unresolved) {
+		if err := c.Send(block); err != nil {
+			log.Error(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package acnil_test
+
+import (
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/acnil/acnil-bot/pkg/acnil/matchers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Holder resolver", func() {
+	var (
+		resolver acnil.HolderResolver
+		pepe     acnil.Member
+	)
+
+	BeforeEach(func() {
+		pepe = acnil.Member{Nickname: "Pepe", TelegramID: "1", PreviousNicknames: []string{"José"}}
+		resolver = acnil.NewHolderResolver([]acnil.Member{
+			pepe,
+			{Nickname: "Juan", TelegramID: "2"},
+			{Nickname: "Juan", TelegramID: "3"},
+		})
+	})
+
+	It("Must resolve the holder by ID before the name", func() {
+		m, ok := resolver.Resolve(acnil.Game{Holder: "Juan", HolderID: "3"})
+		Expect(ok).To(BeTrue())
+		Expect(m.TelegramID).To(Equal("3"))
+	})
+
+	It("Must resolve by name if the holder is no longer a name of the linked member", func() {
+		g := acnil.Game{Holder: "José", HolderID: "2"}
+		Expect(resolver.IsStale(g)).To(BeTrue())
+		m, ok := resolver.Resolve(g)
+		Expect(ok).To(BeTrue())
+		Expect(m.TelegramID).To(Equal("1"))
+
+		Expect(resolver.IsStale(acnil.Game{Holder: "José", HolderID: "1"})).To(BeFalse())
+		Expect(resolver.IsStale(acnil.Game{Holder: "José", HolderID: "4"})).To(BeTrue())
+	})
+
+	It("Must resolve the holder by current or previous nickname", func() {
+		m, ok := resolver.Resolve(acnil.Game{Holder: " jose "})
+		Expect(ok).To(BeTrue())
+		Expect(m.TelegramID).To(Equal("1"))
+	})
+
+	It("Must not resolve names shared by several members or unknown", func() {
+		_, ok := resolver.Resolve(acnil.Game{Holder: "Juan"})
+		Expect(ok).To(BeFalse())
+		_, ok = resolver.Resolve(acnil.Game{Holder: "Manual input user"})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Must link only the loans that are not linked and can be resolved", func() {
+		linked, unresolved := resolver.Link([]acnil.Game{
+			{ID: "1", Holder: "Pepe"},
+			{ID: "2", Holder: "Juan"},
+			{ID: "3", Holder: "Pepe", HolderID: "1"},
+			{ID: "4"},
+			{ID: "5", Holder: "Pepe", HolderID: "2"},
+			{ID: "6", Holder: "Juan", HolderID: "1"},
+		})
+		Expect(linked).To(HaveLen(2))
+		Expect(linked[0].ID).To(Equal("1"))
+		Expect(linked[0].HolderID).To(Equal("1"))
+		Expect(linked[1].ID).To(Equal("5"))
+		Expect(linked[1].HolderID).To(Equal("1"))
+		Expect(unresolved).To(HaveLen(2))
+		Expect(unresolved[0].ID).To(Equal("2"))
+		Expect(unresolved[1].ID).To(Equal("6"))
+	})
+
+	Describe("A game held by ID", func() {
+		It("Must be held by the member even if the nickname is different", func() {
+			g := acnil.Game{ID: "1", Name: "Game1"}
+			g.TakeBy(pepe)
+			Expect(g.HolderID).To(Equal("1"))
+
+			pepe.Rename("Pepito")
+			Expect(g.IsHeldBy(pepe)).To(BeTrue())
+			Expect(g.IsHeldBy(acnil.Member{Nickname: "Pepe", TelegramID: "4"})).To(BeFalse())
+
+			g.Return()
+			Expect(g.HolderID).To(BeEmpty())
+		})
+
+		It("Must not trust the ID if the holder has been changed by hand", func() {
+			g := acnil.Game{ID: "1", Name: "Game1"}
+			g.TakeBy(pepe)
+			g.Holder = "Juan"
+			Expect(g.IsHeldBy(pepe)).To(BeFalse())
+		})
+
+		It("Must offer admins to link the loans that are not linked", func() {
+			admin := acnil.Member{Permissions: acnil.PermissionAdmin}
+			g := acnil.Game{ID: "1", Name: "Game1", Holder: "Juan"}
+			Expect(ToOneDimension(g.ButtonsForPage(admin, 2, "").InlineKeyboard)).To(ContainElement(WithButtonText("Vincular socio")))
+
+			g.HolderID = "2"
+			Expect(ToOneDimension(g.ButtonsForPage(admin, 2, "").InlineKeyboard)).ToNot(ContainElement(WithButtonText("Vincular socio")))
+		})
+	})
+})
@@ -0,0 +1,173 @@
+package acnil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/sheetsparser"
+	"google.golang.org/api/sheets/v4"
+)
+
+var ErrInvalidDNI = errors.New("invalid DNI/NIE")
+
+const dniLetters = "TRWAGMYFPDXBNJZSQVHLCKE"
+
+var dniFormat = regexp.MustCompile(`^([XYZ]|\d)(\d{7})([A-Z])$`)
+
+// ValidateDNI checks the control letter of a spanish DNI or NIE and returns it normalised,
+// in upper case and without spaces or dashes
+func ValidateDNI(id string) (string, error) {
+	id = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", ".", "").Replace(strings.TrimSpace(id)))
+	match := dniFormat.FindStringSubmatch(id)
+	if match == nil {
+		return "", fmt.Errorf("%w, %s", ErrInvalidDNI, id)
+	}
+
+	// NIE prefixes are replaced by a digit to compute the letter
+	prefix := strings.NewReplacer("X", "0", "Y", "1", "Z", "2").Replace(match[1])
+	n, err := strconv.Atoi(prefix + match[2])
+	if err != nil {
+		return "", fmt.Errorf("%w, %s", ErrInvalidDNI, id)
+	}
+	if string(dniLetters[n%23]) != match[3] {
+		return "", fmt.Errorf("%w, wrong control letter %s", ErrInvalidDNI, id)
+	}
+	return id, nil
+}
+
+// Attendee is a person registered in the Juegatron that can take games
+type Attendee struct {
+	// Row represents the row definition on google sheets
+	Row string
+
+	DNI  string `col:"0"`
+	Name string `col:"1"`
+}
+
+// Holder is the text written as holder of the games taken by the attendee
+func (a Attendee) Holder() string {
+	return fmt.Sprintf("%s [%s]", a.Name, a.DNI)
+}
+
+var holderDNI = regexp.MustCompile(`\[([^\]]+)\]\s*$`)
+
+// HolderDNI returns the DNI of the attendee holding a game, false if the holder was not registered
+func HolderDNI(holder string) (string, bool) {
+	match := holderDNI.FindStringSubmatch(holder)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// Holds returns true if the game is currently taken by the attendee
+func (a Attendee) Holds(g Game) bool {
+	dni, ok := HolderDNI(g.Holder)
+	return ok && dni == a.DNI
+}
+
+// Games returns the games held by the attendee
+func (a Attendee) Games(games []Game) []Game {
+	held := []Game{}
+	for _, g := range games {
+		if a.Holds(g) {
+			held = append(held, g)
+		}
+	}
+	return held
+}
+
+type Attendees []Attendee
+
+// Get returns the attendee registered with the given DNI
+func (attendees Attendees) Get(dni string) (Attendee, bool) {
+	for _, a := range attendees {
+		if a.DNI == dni {
+			return a, true
+		}
+	}
+	return Attendee{}, false
+}
+
+// Find returns the attendees whose name contains the given text, ignoring case and accents
+func (attendees Attendees) Find(name string) Attendees {
+	found := Attendees{}
+	name = Norm(strings.TrimSpace(name))
+	if name == "" {
+		return found
+	}
+	for _, a := range attendees {
+		if strings.Contains(Norm(a.Name), name) {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+// SheetAttendeeDatabase stores the attendees registered for the Juegatron
+type SheetAttendeeDatabase struct {
+	SRV       *sheets.Service
+	ReadRange string
+	Sheet     string
+	SheetID   string
+}
+
+func NewAttendeeDatabase(srv *sheets.Service, sheetID string) *SheetAttendeeDatabase {
+	return &SheetAttendeeDatabase{
+		SRV:       srv,
+		ReadRange: "A:B",
+		Sheet:     "Asistentes",
+		SheetID:   sheetID,
+	}
+}
+
+func (db *SheetAttendeeDatabase) fullReadRange() string {
+	return fmt.Sprintf("%s!%s", db.Sheet, db.ReadRange)
+}
+
+func (db *SheetAttendeeDatabase) rowReadRange(row int) string {
+	return fmt.Sprintf("%s!%d:%d", db.Sheet, row, row)
+}
+
+func (db *SheetAttendeeDatabase) List(ctx context.Context) ([]Attendee, error) {
+	resp, err := db.SRV.Spreadsheets.Values.Get(db.SheetID, db.fullReadRange()).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve data from sheet: %w", err)
+	}
+	attendees := []Attendee{}
+
+	if len(resp.Values) == 0 {
+		return attendees, nil
+	}
+
+	for i, row := range resp.Values[1:] {
+		if len(row) < 2 {
+			continue
+		}
+		a := Attendee{
+			Row: db.rowReadRange(i + 2),
+		}
+		if err := sheetsparser.Unmarshal(row, &a); err != nil {
+			return nil, err
+		}
+		attendees = append(attendees, a)
+	}
+	return attendees, nil
+}
+
+func (db *SheetAttendeeDatabase) Append(ctx context.Context, attendee Attendee) error {
+	row, err := sheetsparser.Marshal(&attendee)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal attendee, %w", err)
+	}
+
+	_, err = db.SRV.Spreadsheets.Values.Append(db.SheetID, db.fullReadRange(), &sheets.ValueRange{Values: [][]interface{}{row}}).ValueInputOption("RAW").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Unable to append data to sheet: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package acnil_test
+
+import (
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Juegatron attendees", func() {
+	DescribeTable("DNI validation",
+		func(in string, expected string, valid bool) {
+			dni, err := acnil.ValidateDNI(in)
+			if !valid {
+				Expect(err).To(MatchError(acnil.ErrInvalidDNI))
+				return
+			}
+			Expect(err).To(BeNil())
+			Expect(dni).To(Equal(expected))
+		},
+		Entry("DNI", "12345678Z", "12345678Z", true),
+		Entry("DNI in lower case with dash", "12345678-z", "12345678Z", true),
+		Entry("NIE starting with X", "X1234567L", "X1234567L", true),
+		Entry("NIE starting with Y", "y 1234567 x", "Y1234567X", true),
+		Entry("wrong control letter", "12345678A", "", false),
+		Entry("too short", "1234567Z", "", false),
+		Entry("a name", "Pepe", "", false),
+	)
+
+	var (
+		alice     acnil.Attendee
+		attendees acnil.Attendees
+	)
+
+	BeforeEach(func() {
+		alice = acnil.Attendee{DNI: "12345678Z", Name: "Alicia López"}
+		attendees = acnil.Attendees{
+			alice,
+			{DNI: "X1234567L", Name: "Bob"},
+		}
+	})
+
+	It("Must find attendees by DNI", func() {
+		a, ok := attendees.Get("X1234567L")
+		Expect(ok).To(BeTrue())
+		Expect(a.Name).To(Equal("Bob"))
+	})
+
+	It("Must find attendees by name ignoring accents", func() {
+		found := attendees.Find("lopez")
+		Expect(found).To(Equal(acnil.Attendees{alice}))
+	})
+
+	It("Must recover the DNI from the holder", func() {
+		dni, ok := acnil.HolderDNI(alice.Holder())
+		Expect(ok).To(BeTrue())
+		Expect(dni).To(Equal("12345678Z"))
+
+		_, ok = acnil.HolderDNI("Someone typed by hand")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Must list the games held by the attendee", func() {
+		games := []acnil.Game{
+			{ID: "1", Name: "Catan", Holder: alice.Holder()},
+			{ID: "2", Name: "Virus", Holder: "Bob [X1234567L]"},
+			{ID: "3", Name: "Dixit"},
+		}
+		held := alice.Games(games)
+		Expect(held).To(HaveLen(1))
+		Expect(held[0].ID).To(Equal("1"))
+	})
+})
@@ -2,9 +2,12 @@ package acnil
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/acnil/acnil-bot/pkg/ilog"
 	"github.com/acnil/acnil-bot/pkg/sheetsparser"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/api/sheets/v4"
@@ -13,9 +16,9 @@ import (
 type JuegatronAuditDatabase interface {
 	Append(ctx context.Context, entries []JuegatronAuditEntry) error
 	List(ctx context.Context) ([]JuegatronAuditEntry, error)
-	Delete(ctx context.Context, entry JuegatronAuditEntry) error
 }
 
+// JuegatronAuditEntry is a row of the loan log. The log is append only, mistakes are fixed with new entries
 type JuegatronAuditEntry struct {
 	// Row represents the row definition on google sheets
 	Row       string
@@ -23,10 +26,30 @@ type JuegatronAuditEntry struct {
 	Holder    string `col:"2"`
 	Actor     string `col:"3"`
 	Timestamp string `col:"4"`
+	// Action is empty for regular takes and returns
+	Action JuegatronAction `col:"5"`
 }
 
+// JuegatronAction tells why an entry was written when it is not a regular take or return
+type JuegatronAction string
+
+const (
+	// JuegatronActionUndo reverts the previous entry of the game, the holder is the one before that entry
+	JuegatronActionUndo JuegatronAction = "deshacer"
+	// JuegatronActionCorrection changes the holder of an active loan
+	JuegatronActionCorrection JuegatronAction = "corrección"
+)
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// JuegatronReturnedHolder is the holder written to the log when a game is returned
+const JuegatronReturnedHolder = "devuelto"
+
+// JuegatronAudit computes the state of the Juegatron games from the loan log.
+// GameDB is the catalogue of games, the holders on it are only used to check the log
 type JuegatronAudit struct {
 	AuditDB JuegatronAuditDatabase
+	GameDB  ROGameDatabase
 }
 
 func (e JuegatronAuditEntry) Game() *Game {
@@ -37,17 +60,173 @@ func (e JuegatronAuditEntry) Game() *Game {
 	}
 }
 
-func NewJuegatronAuditEntry(game Game, actor Member) JuegatronAuditEntry {
-	entry := JuegatronAuditEntry{
+// IsReturn returns true if the entry gives the game back
+func (e JuegatronAuditEntry) IsReturn() bool {
+	return e.Holder == "" || strings.EqualFold(e.Holder, JuegatronReturnedHolder)
+}
+
+// replayJuegatron applies the log in order and returns the active loan of each game.
+// The loan is the entry that gave the game away, with the holder updated by later corrections.
+// Rows left empty by old versions of the bot are ignored
+func replayJuegatron(entries []JuegatronAuditEntry) map[string]JuegatronAuditEntry {
+	active := map[string]JuegatronAuditEntry{}
+	// previous keeps the last returned loan of each game, so a return can be undone
+	previous := map[string]JuegatronAuditEntry{}
+
+	for _, entry := range entries {
+		if entry.ID == "" {
+			continue
+		}
+		loan, isActive := active[entry.ID]
+		switch {
+		case entry.Action == JuegatronActionUndo && entry.IsReturn():
+			delete(active, entry.ID)
+		case entry.Action == JuegatronActionUndo || entry.Action == JuegatronActionCorrection:
+			if !isActive {
+				loan, isActive = previous[entry.ID]
+			}
+			if !isActive {
+				loan = entry
+			}
+			loan.Holder = entry.Holder
+			active[entry.ID] = loan
+		case entry.IsReturn():
+			if isActive {
+				previous[entry.ID] = loan
+			}
+			delete(active, entry.ID)
+		default:
+			active[entry.ID] = entry
+		}
+	}
+	return active
+}
+
+// JuegatronState replays the loan log in order on top of the catalogue and returns the games with their current holder.
+// Entries of games that are not in the catalogue are ignored
+func JuegatronState(catalogue []Game, entries []JuegatronAuditEntry) []Game {
+	active := replayJuegatron(entries)
+
+	games := make([]Game, len(catalogue))
+	for i, g := range catalogue {
+		g.Return()
+		if loan, ok := active[g.ID]; ok {
+			g.Take(loan.Holder)
+			if t, err := time.Parse(time.RFC3339, loan.Timestamp); err == nil {
+				g.TakeDate = t
+			}
+		}
+		games[i] = g
+	}
+	return games
+}
+
+// JuegatronUndo returns the entry that reverts the last entry written by the actor for the game.
+// Only the last entry of the game can be undone, so newer changes from other volunteers are never overwritten
+func JuegatronUndo(entries []JuegatronAuditEntry, gameID string, actor Member) (JuegatronAuditEntry, error) {
+	last := -1
+	for i := range entries {
+		if entries[i].ID == gameID {
+			last = i
+		}
+	}
+	if last == -1 {
+		return JuegatronAuditEntry{}, fmt.Errorf("%w, game %s has no loans", ErrNothingToUndo, gameID)
+	}
+	if entries[last].Actor != actor.Nickname || entries[last].Action == JuegatronActionUndo {
+		return JuegatronAuditEntry{}, fmt.Errorf("%w, the last change of game %s was not made by %s", ErrNothingToUndo, gameID, actor.Nickname)
+	}
+
+	holder := JuegatronReturnedHolder
+	if loan, ok := replayJuegatron(entries[:last])[gameID]; ok {
+		holder = loan.Holder
+	}
+	return JuegatronAuditEntry{
+		ID:     gameID,
+		Holder: holder,
+		Actor:  actor.Nickname,
+		Action: JuegatronActionUndo,
+	}, nil
+}
+
+// NewJuegatronCorrection changes the holder of an active loan
+func NewJuegatronCorrection(game Game, holder string, actor Member) JuegatronAuditEntry {
+	return JuegatronAuditEntry{
 		ID:     game.ID,
-		Holder: game.Holder,
+		Holder: holder,
 		Actor:  actor.Nickname,
+		Action: JuegatronActionCorrection,
 	}
-	if entry.Holder == "" {
-		entry.Holder = "devuelto"
+}
+
+// JuegatronMismatch is a game whose holder in the sheet doesn't match the loan log
+type JuegatronMismatch struct {
+	ID          string
+	Name        string
+	SheetHolder string
+	LogHolder   string
+}
+
+// CompareJuegatronState returns the games from the sheet that have a different holder than the computed state
+func CompareJuegatronState(sheet []Game, computed []Game) []JuegatronMismatch {
+	holders := map[string]string{}
+	for _, g := range computed {
+		holders[g.ID] = g.Holder
 	}
+	mismatches := []JuegatronMismatch{}
+	for _, g := range sheet {
+		holder, ok := holders[g.ID]
+		if !ok || strings.TrimSpace(g.Holder) == strings.TrimSpace(holder) {
+			continue
+		}
+		mismatches = append(mismatches, JuegatronMismatch{
+			ID:          g.ID,
+			Name:        g.Name,
+			SheetHolder: g.Holder,
+			LogHolder:   holder,
+		})
+	}
+	return mismatches
+}
 
-	return entry
+// State returns the Juegatron games with the holders computed from the loan log
+func (a *JuegatronAudit) State(ctx context.Context) ([]Game, error) {
+	catalogue, err := a.GameDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list juegatron games, %w", err)
+	}
+	entries, err := a.AuditDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list juegatron loans, %w", err)
+	}
+	return JuegatronState(catalogue, entries), nil
+}
+
+// Do checks the computed state against the holders in the sheet and returns any difference
+func (a *JuegatronAudit) Do(ctx context.Context) ([]JuegatronMismatch, error) {
+	log := logrus.WithField(ilog.FieldHandler, "Juegatron Audit")
+	defer printDuration(log, time.Now())
+
+	sheet, err := a.GameDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list juegatron games, %w", err)
+	}
+	entries, err := a.AuditDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list juegatron loans, %w", err)
+	}
+
+	mismatches := CompareJuegatronState(sheet, JuegatronState(sheet, entries))
+	for _, m := range mismatches {
+		log.
+			WithField("ID", m.ID).
+			WithField("Game", m.Name).
+			WithField("SheetHolder", m.SheetHolder).
+			WithField("LogHolder", m.LogHolder).
+			Warn("Juegatron sheet doesn't match the loan log")
+	}
+	log.WithField("len", len(mismatches)).Info("Juegatron state checked")
+	return mismatches, nil
 }
 
 type JuegatronSheetAuditDatabase struct {
@@ -72,9 +251,9 @@ func NewJuegatronSheetAuditDatabase(srv *sheets.Service, sheetID string) *Juegat
 }
 
 func (db *JuegatronSheetAuditDatabase) List(ctx context.Context) ([]JuegatronAuditEntry, error) {
-	resp, err := db.SRV.Spreadsheets.Values.Get(db.SheetID, db.fullReadRange()).Do()
+	resp, err := db.SRV.Spreadsheets.Values.Get(db.SheetID, db.fullReadRange()).Context(ctx).Do()
 	if err != nil {
-		logrus.Fatalf("Unable to retrieve data from sheet: %v", err)
+		return nil, fmt.Errorf("Unable to retrieve data from sheet: %w", err)
 	}
 	games := []JuegatronAuditEntry{}
 
@@ -99,42 +278,6 @@ func (db *JuegatronSheetAuditDatabase) List(ctx context.Context) ([]JuegatronAud
 	return games, nil
 }
 
-func (db *JuegatronSheetAuditDatabase) Delete(ctx context.Context, entry JuegatronAuditEntry) error {
-	entry.Actor = ""
-	entry.Holder = ""
-	entry.ID = ""
-	entry.Timestamp = ""
-	return db.Update(ctx, entry)
-}
-
-func (db *JuegatronSheetAuditDatabase) Update(ctx context.Context, entries ...JuegatronAuditEntry) error {
-	batchUpdate := &sheets.BatchUpdateValuesRequest{
-		Data:             []*sheets.ValueRange{},
-		ValueInputOption: "USER_ENTERED",
-	}
-
-	for _, game := range entries {
-
-		rows := [][]interface{}{}
-		row, err := sheetsparser.Marshal(&game)
-		if err != nil {
-			return fmt.Errorf("Failed to marshal game, %w", err)
-		}
-		rows = append(rows, row)
-		batchUpdate.Data = append(batchUpdate.Data, &sheets.ValueRange{
-			Range:  game.Row,
-			Values: rows,
-		})
-	}
-
-	request := db.SRV.Spreadsheets.Values.BatchUpdate(db.SheetID, batchUpdate)
-	_, err := request.Do()
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func (db *JuegatronSheetAuditDatabase) Append(ctx context.Context, entries []JuegatronAuditEntry) error {
 	rows := [][]interface{}{}
 
@@ -0,0 +1,148 @@
+package acnil_test
+
+import (
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Juegatron state", func() {
+	var catalogue []acnil.Game
+
+	BeforeEach(func() {
+		catalogue = []acnil.Game{
+			{ID: "1", Name: "Catan", Holder: "From a formula"},
+			{ID: "2", Name: "Virus"},
+			{ID: "3", Name: "Dixit"},
+		}
+	})
+
+	It("Must start with all the games available", func() {
+		games := acnil.JuegatronState(catalogue, nil)
+		for _, g := range games {
+			Expect(g.IsAvailable()).To(BeTrue())
+		}
+	})
+
+	It("Must replay the log in order", func() {
+		games := acnil.JuegatronState(catalogue, []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Timestamp: "2023-11-04T10:00:00Z"},
+			{ID: "2", Holder: "Bob"},
+			{ID: "1", Holder: acnil.JuegatronReturnedHolder},
+			{ID: "1", Holder: "Carol"},
+			{ID: "2", Holder: acnil.JuegatronReturnedHolder},
+		})
+		Expect(games[0].Holder).To(Equal("Carol"))
+		Expect(games[1].IsAvailable()).To(BeTrue())
+		Expect(games[2].IsAvailable()).To(BeTrue())
+	})
+
+	It("Must use the log time as take date", func() {
+		games := acnil.JuegatronState(catalogue, []acnil.JuegatronAuditEntry{
+			{ID: "3", Holder: "Alice", Timestamp: "2023-11-04T10:00:00Z"},
+		})
+		Expect(games[2].TakeDate.Format("2006-01-02")).To(Equal("2023-11-04"))
+	})
+
+	It("Must skip undone entries and unknown games", func() {
+		games := acnil.JuegatronState(catalogue, []acnil.JuegatronAuditEntry{
+			{ID: "2", Holder: "Bob"},
+			{},
+			{ID: "99", Holder: "Nobody"},
+		})
+		Expect(games).To(HaveLen(3))
+		Expect(games[1].Holder).To(Equal("Bob"))
+	})
+
+	It("Must report games where the sheet doesn't match the log", func() {
+		computed := acnil.JuegatronState(catalogue, []acnil.JuegatronAuditEntry{
+			{ID: "2", Holder: "Bob"},
+		})
+		mismatches := acnil.CompareJuegatronState(catalogue, computed)
+		Expect(mismatches).To(ConsistOf(
+			acnil.JuegatronMismatch{ID: "1", Name: "Catan", SheetHolder: "From a formula", LogHolder: ""},
+			acnil.JuegatronMismatch{ID: "2", Name: "Virus", SheetHolder: "", LogHolder: "Bob"},
+		))
+	})
+})
+
+var _ = Describe("Juegatron undo and corrections", func() {
+	var (
+		catalogue []acnil.Game
+		pepe      acnil.Member
+		ana       acnil.Member
+	)
+
+	BeforeEach(func() {
+		catalogue = []acnil.Game{
+			{ID: "1", Name: "Catan"},
+			{ID: "2", Name: "Virus"},
+		}
+		pepe = acnil.Member{Nickname: "Pepe"}
+		ana = acnil.Member{Nickname: "Ana"}
+	})
+
+	It("Must undo a take", func() {
+		entries := []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Actor: "Pepe"},
+		}
+		undo, err := acnil.JuegatronUndo(entries, "1", pepe)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(undo.Action).To(Equal(acnil.JuegatronActionUndo))
+		Expect(undo.IsReturn()).To(BeTrue())
+
+		games := acnil.JuegatronState(catalogue, append(entries, undo))
+		Expect(games[0].IsAvailable()).To(BeTrue())
+	})
+
+	It("Must undo a return keeping the original take date", func() {
+		entries := []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Actor: "Ana", Timestamp: "2023-11-04T10:00:00Z"},
+			{ID: "1", Holder: acnil.JuegatronReturnedHolder, Actor: "Pepe", Timestamp: "2023-11-04T11:00:00Z"},
+		}
+		undo, err := acnil.JuegatronUndo(entries, "1", pepe)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(undo.Holder).To(Equal("Alice"))
+
+		games := acnil.JuegatronState(catalogue, append(entries, undo))
+		Expect(games[0].Holder).To(Equal("Alice"))
+		Expect(games[0].TakeDate.Hour()).To(Equal(10))
+	})
+
+	It("Must correct the holder of an active loan", func() {
+		entries := []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Actor: "Ana", Timestamp: "2023-11-04T10:00:00Z"},
+			acnil.NewJuegatronCorrection(catalogue[0], "Bob", pepe),
+		}
+		games := acnil.JuegatronState(catalogue, entries)
+		Expect(games[0].Holder).To(Equal("Bob"))
+		Expect(games[0].TakeDate.Hour()).To(Equal(10))
+
+		undo, err := acnil.JuegatronUndo(entries, "1", pepe)
+		Expect(err).ToNot(HaveOccurred())
+		games = acnil.JuegatronState(catalogue, append(entries, undo))
+		Expect(games[0].Holder).To(Equal("Alice"))
+	})
+
+	It("Must not undo changes made by other volunteers", func() {
+		entries := []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Actor: "Pepe"},
+			{ID: "1", Holder: acnil.JuegatronReturnedHolder, Actor: "Ana"},
+		}
+		_, err := acnil.JuegatronUndo(entries, "1", pepe)
+		Expect(err).To(MatchError(acnil.ErrNothingToUndo))
+
+		_, err = acnil.JuegatronUndo(entries, "2", ana)
+		Expect(err).To(MatchError(acnil.ErrNothingToUndo))
+	})
+
+	It("Must not undo an undo", func() {
+		entries := []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Actor: "Pepe"},
+		}
+		undo, err := acnil.JuegatronUndo(entries, "1", pepe)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = acnil.JuegatronUndo(append(entries, undo), "1", pepe)
+		Expect(err).To(MatchError(acnil.ErrNothingToUndo))
+	})
+})
@@ -0,0 +1,165 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GameSelection picks games from the inventory to publish them in an event.
+// Only one criteria is used, IDs first, then location and then name
+type GameSelection struct {
+	IDs      []string
+	Location Location
+	Name     string
+}
+
+var gameIDList = regexp.MustCompile(`^/?\d+([\s,]+/?\d+)*$`)
+
+// ParseGameSelection reads a selection written by an admin.
+// A list of IDs selects those games, a location name selects the games stored there and anything else filters by name
+func ParseGameSelection(text string, locations Locations) GameSelection {
+	text = strings.TrimSpace(text)
+	if gameIDList.MatchString(text) {
+		ids := []string{}
+		for _, id := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' }) {
+			ids = append(ids, strings.TrimLeft(id, "/0"))
+		}
+		return GameSelection{IDs: ids}
+	}
+	if location, ok := locations.Find(text); ok {
+		return GameSelection{Location: location.Name}
+	}
+	return GameSelection{Name: text}
+}
+
+// IsEmpty returns true if there is no criteria, an empty selection doesn't select anything
+func (s GameSelection) IsEmpty() bool {
+	return len(s.IDs) == 0 && s.Location == "" && strings.TrimSpace(s.Name) == ""
+}
+
+// Select returns the games that match the selection, in inventory order
+func (s GameSelection) Select(games []Game) []Game {
+	selected := []Game{}
+	switch {
+	case len(s.IDs) > 0:
+		ids := map[string]bool{}
+		for _, id := range s.IDs {
+			ids[strings.TrimLeft(id, "0")] = true
+		}
+		for _, g := range games {
+			if ids[strings.TrimLeft(g.ID, "0")] {
+				selected = append(selected, g)
+			}
+		}
+	case s.Location != "":
+		for _, g := range games {
+			if g.IsInLocation(s.Location) {
+				selected = append(selected, g)
+			}
+		}
+	case strings.TrimSpace(s.Name) != "":
+		selected = Games(games).Find(s.Name)
+	}
+	return selected
+}
+
+// ForCatalogue returns the game as it is listed in an event catalogue.
+// The ID, name, notes and BGG data are kept, the loan and storage details belong to the inventory
+func (g Game) ForCatalogue() Game {
+	return Game{
+		ID:                 g.ID,
+		Name:               g.Name,
+		Comments:           g.Comments,
+		Price:              g.Price,
+		Publisher:          g.Publisher,
+		BGG:                g.BGG,
+		AvgRate:            g.AvgRate,
+		AvgWeight:          g.AvgWeight,
+		Age:                g.Age,
+		MinPlayers:         g.MinPlayers,
+		MaxPlayers:         g.MaxPlayers,
+		Playingtime:        g.Playingtime,
+		Yearpublished:      g.Yearpublished,
+		LanguageDependence: g.LanguageDependence,
+		Categories:         g.Categories,
+		Mechanics:          g.Mechanics,
+		Designers:          g.Designers,
+		Families:           g.Families,
+		AlternateNames:     g.AlternateNames,
+		Aliases:            g.Aliases,
+	}
+}
+
+// PublishResult describes the changes made by PublishToEvent
+type PublishResult struct {
+	// Published are the games added to the catalogue
+	Published []Game
+	// AlreadyListed are the games that were in the catalogue before, they are reserved again anyway
+	AlreadyListed []Game
+	// Held are the games that are lent right now, someone must ask for them before the event
+	Held []Game
+}
+
+func (r PublishResult) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Publicados %d juegos", len(r.Published))
+	if len(r.AlreadyListed) > 0 {
+		fmt.Fprintf(b, ", %d ya estaban en el catálogo", len(r.AlreadyListed))
+	}
+	if len(r.Held) > 0 {
+		b.WriteString("\n\n⚠️ Estos juegos están prestados, hay que pedir que los devuelvan antes del evento:\n")
+		for _, g := range r.Held {
+			fmt.Fprintln(b, g.Line())
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// PublishToEvent copies the games to the catalogue of the event and reserves them in the inventory for the event dates.
+// Games that are already in the catalogue are not copied twice. Events without end date are rejected
+func PublishToEvent(ctx context.Context, inventory GameDatabase, event *JuegatronEvent, games []Game) (PublishResult, error) {
+	result := PublishResult{}
+	if event.Info.End.IsZero() {
+		return result, fmt.Errorf("%w, %s has no end date and the games would be reserved forever", ErrEventDates, event.Info.Name)
+	}
+
+	listed, err := event.Catalogue.List(ctx)
+	if err != nil {
+		return result, fmt.Errorf("Failed to list the catalogue of %s, %w", event.Info.Name, err)
+	}
+	inCatalogue := map[string]bool{}
+	for _, g := range listed {
+		inCatalogue[g.ID] = true
+	}
+
+	newGames := []Game{}
+	reserved := []Game{}
+	for _, g := range games {
+		if inCatalogue[g.ID] {
+			result.AlreadyListed = append(result.AlreadyListed, g)
+		} else {
+			inCatalogue[g.ID] = true
+			newGames = append(newGames, g.ForCatalogue())
+			result.Published = append(result.Published, g)
+		}
+		if !g.IsAvailable() {
+			result.Held = append(result.Held, g)
+		}
+		g.Reserve(event.Info)
+		reserved = append(reserved, g)
+	}
+
+	if len(newGames) > 0 {
+		if err := event.Catalogue.Append(ctx, newGames...); err != nil {
+			return result, fmt.Errorf("Failed to publish games to %s, %w", event.Info.Name, err)
+		}
+	}
+	if len(reserved) > 0 {
+		if err := inventory.Update(ctx, reserved...); err != nil {
+			return result, fmt.Errorf("Games published, but failed to reserve them in the inventory, %w", err)
+		}
+	}
+	return result, nil
+}
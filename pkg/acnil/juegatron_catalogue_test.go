@@ -0,0 +1,163 @@
+package acnil_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/acnil/acnil-bot/pkg/acnil/matchers"
+	"github.com/acnil/acnil-bot/pkg/acnil/mock_acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Juegatron catalogue", func() {
+	var inventory []acnil.Game
+
+	BeforeEach(func() {
+		inventory = []acnil.Game{
+			{ID: "1", Name: "Catan", Location: "Centro", BGG: "13", MinPlayers: 3},
+			{ID: "2", Name: "Virus", Location: "Gamonal", Holder: "Pepe"},
+			{ID: "3", Name: "Catan Junior", Location: "Gamonal"},
+		}
+	})
+
+	Describe("Selecting games", func() {
+		It("Must select games by ID", func() {
+			selection := acnil.ParseGameSelection("/0001 3", acnil.DefaultLocations)
+			Expect(selection.IDs).To(Equal([]string{"1", "3"}))
+			Expect(selection.Select(inventory)).To(Equal([]acnil.Game{inventory[0], inventory[2]}))
+		})
+
+		It("Must not select games with a similar name", func() {
+			selection := acnil.ParseGameSelection("virux", acnil.DefaultLocations)
+			Expect(selection.Select(inventory)).To(BeEmpty())
+		})
+
+		It("Must select games by location", func() {
+			selection := acnil.ParseGameSelection("gamonal", acnil.DefaultLocations)
+			Expect(selection.Location).To(Equal(acnil.LocationGamonal))
+			Expect(selection.Select(inventory)).To(Equal([]acnil.Game{inventory[1], inventory[2]}))
+		})
+
+		It("Must select games by name", func() {
+			selection := acnil.ParseGameSelection("catan", acnil.DefaultLocations)
+			Expect(selection.Select(inventory)).To(Equal([]acnil.Game{inventory[0], inventory[2]}))
+		})
+
+		It("Must not select anything without criteria", func() {
+			selection := acnil.ParseGameSelection("  ", acnil.DefaultLocations)
+			Expect(selection.IsEmpty()).To(BeTrue())
+			Expect(selection.Select(inventory)).To(BeEmpty())
+		})
+	})
+
+	Describe("Reserving games", func() {
+		var event acnil.EventInfo
+
+		BeforeEach(func() {
+			event = acnil.EventInfo{
+				ID:    "juegatron-2023",
+				Name:  "Juegatron 2023",
+				Start: time.Date(2023, 11, 4, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2023, 11, 5, 0, 0, 0, 0, time.UTC),
+			}
+		})
+
+		It("Must block the game until the last day of the event", func() {
+			g := inventory[0]
+			g.Reserve(event)
+			Expect(g.IsReservedAt(time.Date(2023, 10, 30, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(g.IsReservedAt(time.Date(2023, 11, 5, 20, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(g.IsReservedAt(time.Date(2023, 11, 6, 0, 0, 0, 0, time.UTC))).To(BeFalse())
+
+			g.CancelReservation()
+			Expect(g.IsReservedAt(time.Date(2023, 11, 4, 0, 0, 0, 0, time.UTC))).To(BeFalse())
+		})
+
+		It("Must not offer to take a reserved game", func() {
+			g := inventory[0]
+			g.Reserve(acnil.EventInfo{Name: "Juegatron"})
+			Expect(g.Card()).To(ContainSubstring("Reservado para Juegatron"))
+			buttons := ToOneDimension(g.Buttons(acnil.Member{}, "").InlineKeyboard)
+			Expect(buttons).ToNot(ContainElement(WithButtonText("Tomar Prestado")))
+			Expect(acnil.Games{g}.CanTake()).To(BeFalse())
+		})
+
+		It("Must offer admins to cancel the reservation", func() {
+			g := inventory[0]
+			admin := acnil.Member{Permissions: acnil.PermissionAdmin}
+			Expect(ToOneDimension(g.ButtonsForPage(admin, 2, "").InlineKeyboard)).ToNot(ContainElement(WithButtonText("Cancelar reserva")))
+
+			g.Reserve(event)
+			Expect(ToOneDimension(g.ButtonsForPage(admin, 2, "").InlineKeyboard)).To(ContainElement(WithButtonText("Cancelar reserva")))
+			Expect(ToOneDimension(g.ButtonsForPage(acnil.Member{}, 2, "").InlineKeyboard)).ToNot(ContainElement(WithButtonText("Cancelar reserva")))
+		})
+	})
+
+	Describe("Publishing games", func() {
+		var (
+			ctrl          *gomock.Controller
+			mockInventory *mock_acnil.MockGameDatabase
+			mockCatalogue *mock_acnil.MockCatalogueDatabase
+			event         *acnil.JuegatronEvent
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			mockInventory = mock_acnil.NewMockGameDatabase(ctrl)
+			mockCatalogue = mock_acnil.NewMockCatalogueDatabase(ctrl)
+			event = &acnil.JuegatronEvent{
+				Info:      acnil.EventInfo{ID: "juegatron", Name: "Juegatron", End: time.Date(2023, 11, 5, 0, 0, 0, 0, time.UTC)},
+				Catalogue: mockCatalogue,
+			}
+		})
+
+		It("Must copy the new games keeping IDs and BGG data, and reserve all of them", func() {
+			mockCatalogue.EXPECT().List(gomock.Any()).Return([]acnil.Game{{ID: "3", Name: "Catan Junior"}}, nil)
+			mockCatalogue.EXPECT().Append(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, games ...acnil.Game) error {
+				Expect(games).To(HaveLen(2))
+				Expect(games[0].ID).To(Equal("1"))
+				Expect(games[0].BGG).To(Equal("13"))
+				Expect(games[0].MinPlayers).To(Equal(3))
+				Expect(games[0].Location).To(BeEmpty())
+				Expect(games[1].ID).To(Equal("2"))
+				Expect(games[1].IsAvailable()).To(BeTrue())
+				return nil
+			})
+			mockInventory.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, games ...acnil.Game) error {
+				Expect(games).To(HaveLen(3))
+				for _, g := range games {
+					Expect(g.ReservedFor).To(Equal("Juegatron"))
+					Expect(g.ReservedUntil).To(Equal(event.Info.End))
+				}
+				// The inventory keeps the loan
+				Expect(games[1].Holder).To(Equal("Pepe"))
+				return nil
+			})
+
+			result, err := acnil.PublishToEvent(context.Background(), mockInventory, event, inventory)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Published).To(HaveLen(2))
+			Expect(result.AlreadyListed).To(HaveLen(1))
+			Expect(result.Held).To(Equal([]acnil.Game{inventory[1]}))
+			Expect(result.String()).To(ContainSubstring("Virus"))
+		})
+
+		It("Must not publish to events without end date", func() {
+			event.Info.End = time.Time{}
+			_, err := acnil.PublishToEvent(context.Background(), mockInventory, event, inventory)
+			Expect(err).To(MatchError(acnil.ErrEventDates))
+		})
+
+		It("Must not write to the catalogue if all the games are already there", func() {
+			mockCatalogue.EXPECT().List(gomock.Any()).Return(inventory, nil)
+			mockInventory.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+			result, err := acnil.PublishToEvent(context.Background(), mockInventory, event, inventory)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Published).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/sirupsen/logrus"
+)
+
+// JuegatronChecks compares the sheet of every active event with its loan log.
+// The differences are sent to the volunteers of the event, or to the admins if anyone can lend games.
+// The same differences are reported only once
+type JuegatronChecks struct {
+	Events    EventDatabase
+	Open      EventOpener
+	MembersDB MembersDatabase
+	Bot       Sender
+
+	mu       sync.Mutex
+	reported map[string]string
+}
+
+// Do checks every active event once
+func (c *JuegatronChecks) Do(ctx context.Context) error {
+	log := logrus.WithField(ilog.FieldHandler, "Juegatron Checks")
+
+	events, err := c.Events.List(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to list events, %w", err)
+	}
+	for _, info := range events {
+		if !info.IsActive(time.Now()) {
+			continue
+		}
+		mismatches, err := c.Open(info).Audit.Do(ctx)
+		if err != nil {
+			log.WithError(err).WithField("Event", info.ID).Error("Failed to check juegatron state")
+			continue
+		}
+		if err := c.report(ctx, info, mismatches); err != nil {
+			log.WithError(err).WithField("Event", info.ID).Error("Failed to report juegatron mismatches")
+		}
+	}
+	return nil
+}
+
+// Run checks the events periodically until the context is cancelled.
+// The registry is loaded on each tick, so new events are checked without restarting
+func (c *JuegatronChecks) Run(ctx context.Context, interval time.Duration) {
+	log := logrus.WithField(ilog.FieldHandler, "Juegatron Checks Run")
+
+	if err := c.Do(ctx); err != nil {
+		log.WithError(err).Error("Failed to check juegatron events")
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Do(ctx); err != nil {
+					log.WithError(err).Error("Failed to check juegatron events")
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (c *JuegatronChecks) report(ctx context.Context, info EventInfo, mismatches []JuegatronMismatch) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reported == nil {
+		c.reported = map[string]string{}
+	}
+
+	if len(mismatches) == 0 {
+		delete(c.reported, info.ID)
+		return nil
+	}
+	msg := JuegatronMismatchMessage(info, mismatches)
+	if c.reported[info.ID] == msg {
+		return nil
+	}
+
+	members, err := c.MembersDB.List(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get list of members, %w", err)
+	}
+	for _, m := range members {
+		if !juegatronCheckRecipient(info, m) {
+			continue
+		}
+		if _, err := c.Bot.Send(&m, msg); err != nil {
+			return err
+		}
+	}
+	c.reported[info.ID] = msg
+	return nil
+}
+
+// juegatronCheckRecipient returns true if the member must know about the mismatches of the event
+func juegatronCheckRecipient(info EventInfo, m Member) bool {
+	if strings.TrimSpace(info.Volunteers) == "" {
+		return m.Permissions == PermissionAdmin
+	}
+	return info.IsVolunteer(m)
+}
+
+// JuegatronMismatchMessage explains the differences between the sheet and the loan log, the log is always right
+func JuegatronMismatchMessage(info EventInfo, mismatches []JuegatronMismatch) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "⚠️ La hoja de %s no coincide con el registro de préstamos. El bot usa el registro, revisa estos juegos:\n", info.Name)
+	for _, m := range mismatches {
+		line := fmt.Sprintf("%s %s: en la hoja %s, en el registro %s\n", m.ID, m.Name, juegatronHolderText(m.SheetHolder), juegatronHolderText(m.LogHolder))
+		if b.Len()+len(line) > 4000 {
+			b.WriteString("...")
+			break
+		}
+		b.WriteString(line)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func juegatronHolderText(holder string) string {
+	if strings.TrimSpace(holder) == "" {
+		return "disponible"
+	}
+	return fmt.Sprintf("lo tiene %s", strings.TrimSpace(holder))
+}
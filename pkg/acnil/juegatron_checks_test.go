@@ -0,0 +1,94 @@
+package acnil_test
+
+import (
+	"context"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	"github.com/acnil/acnil-bot/pkg/acnil/mock_acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v3"
+)
+
+var _ = Describe("Juegatron checks", func() {
+	var (
+		ctx                 context.Context
+		ctrl                *gomock.Controller
+		mockMembersDatabase *mock_acnil.MockMembersDatabase
+		mockSender          *mock_acnil.MockSender
+		loanLog             *memoryJuegatronLog
+		catalogue           *memoryCatalogue
+		checks              *acnil.JuegatronChecks
+		members             []acnil.Member
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		ctrl = gomock.NewController(GinkgoT())
+		mockMembersDatabase = mock_acnil.NewMockMembersDatabase(ctrl)
+		mockSender = mock_acnil.NewMockSender(ctrl)
+		loanLog = &memoryJuegatronLog{}
+		catalogue = &memoryCatalogue{
+			{ID: "1", Name: "Catan", Holder: "Alice"},
+			{ID: "2", Name: "Virus"},
+		}
+		members = []acnil.Member{
+			{Nickname: "Admin", TelegramID: "1", Permissions: acnil.PermissionAdmin},
+			{Nickname: "Pepe", TelegramID: "2"},
+			{Nickname: "Juan", TelegramID: "3"},
+		}
+		mockMembersDatabase.EXPECT().List(gomock.Any()).Return(members, nil).AnyTimes()
+
+		checks = &acnil.JuegatronChecks{
+			Events: acnil.StaticEventDatabase{{ID: "juegatron-2023", Name: "Juegatron 2023", SheetID: "sheet"}},
+			Open: func(info acnil.EventInfo) *acnil.JuegatronEvent {
+				return &acnil.JuegatronEvent{
+					Info:      info,
+					Audit:     &acnil.JuegatronAudit{AuditDB: loanLog, GameDB: catalogue},
+					Catalogue: catalogue,
+				}
+			},
+			MembersDB: mockMembersDatabase,
+			Bot:       mockSender,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("Must not send anything if the sheet matches the log", func() {
+		loanLog.entries = []acnil.JuegatronAuditEntry{{ID: "1", Holder: "Alice"}}
+		Expect(checks.Do(ctx)).To(Succeed())
+	})
+
+	It("Must tell the admins about the mismatches once if anyone can lend games", func() {
+		mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(to tele.Recipient, what interface{}, opts ...interface{}) (*tele.Message, error) {
+			Expect(to.Recipient()).To(Equal("1"))
+			Expect(what).To(ContainSubstring("Juegatron 2023"))
+			Expect(what).To(ContainSubstring("1 Catan: en la hoja lo tiene Alice, en el registro disponible"))
+			return nil, nil
+		})
+		Expect(checks.Do(ctx)).To(Succeed())
+		Expect(checks.Do(ctx)).To(Succeed())
+	})
+
+	It("Must tell the volunteers and admins of the event", func() {
+		checks.Events = acnil.StaticEventDatabase{{ID: "juegatron-2023", Name: "Juegatron 2023", SheetID: "sheet", Volunteers: "Pepe"}}
+		sent := []string{}
+		mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(to tele.Recipient, what interface{}, opts ...interface{}) (*tele.Message, error) {
+			sent = append(sent, to.Recipient())
+			return nil, nil
+		}).Times(2)
+		Expect(checks.Do(ctx)).To(Succeed())
+		Expect(sent).To(ConsistOf("1", "2"))
+	})
+
+	It("Must report again if the mismatches change", func() {
+		mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+		Expect(checks.Do(ctx)).To(Succeed())
+		loanLog.entries = []acnil.JuegatronAuditEntry{{ID: "1", Holder: "Alice"}, {ID: "2", Holder: "Bob"}}
+		Expect(checks.Do(ctx)).To(Succeed())
+	})
+})
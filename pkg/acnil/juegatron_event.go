@@ -0,0 +1,387 @@
+package acnil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/acnil/acnil-bot/pkg/sheetsparser"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/sheets/v4"
+	tele "gopkg.in/telebot.v3"
+)
+
+var ErrEventNotFound = errors.New("event not found")
+
+// EventInfo describes an event where the club lends games, like the Juegatron
+type EventInfo struct {
+	// Row represents the row definition on google sheets
+	Row string
+
+	ID    string    `col:"0"`
+	Name  string    `col:"1"`
+	Start time.Time `col:"2"`
+	End   time.Time `col:"3"`
+	// SheetID is the spreadsheet with the games, loans and attendees of the event
+	SheetID string `col:"4"`
+	// Volunteers is a comma separated list of the nicknames or telegram IDs of the members that can lend games, empty means anyone
+	Volunteers string `col:"5"`
+}
+
+// IsActive returns true if the event is running at the given time. Both dates are inclusive and optional
+func (e EventInfo) IsActive(now time.Time) bool {
+	if !e.Start.IsZero() && now.Before(e.Start) {
+		return false
+	}
+	if !e.End.IsZero() && !now.Before(e.End.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// IsVolunteer returns true if the member can lend games in the event. Admins can always do it
+func (e EventInfo) IsVolunteer(member Member) bool {
+	if member.Permissions == PermissionAdmin || strings.TrimSpace(e.Volunteers) == "" {
+		return true
+	}
+	return member.IsListed(e.Volunteers)
+}
+
+// MaxEventIDLength keeps the event ID in the data of the juegatron buttons, telegram allows 64 bytes
+const MaxEventIDLength = 16
+
+var ErrInvalidEventID = errors.New("invalid event ID")
+
+// ValidateEventID rejects the IDs that can't be stored in the member state or the data of a button, where "|" separates the fields
+func ValidateEventID(id string) error {
+	if strings.Contains(id, "|") {
+		return fmt.Errorf("%w %q, it can't contain \"|\"", ErrInvalidEventID, id)
+	}
+	if len(id) > MaxEventIDLength {
+		return fmt.Errorf("%w %q, it can't be longer than %d characters", ErrInvalidEventID, id, MaxEventIDLength)
+	}
+	return nil
+}
+
+var ErrEventDates = errors.New("invalid event dates")
+
+// ValidateEventDates rejects the events without start or end date, an event without dates would be active all year
+func ValidateEventDates(e EventInfo) error {
+	if e.Start.IsZero() || e.End.IsZero() {
+		return fmt.Errorf("%w, %s must have start and end dates", ErrEventDates, e.ID)
+	}
+	if e.End.Before(e.Start) {
+		return fmt.Errorf("%w, %s ends before it starts", ErrEventDates, e.ID)
+	}
+	return nil
+}
+
+type Events []EventInfo
+
+// Get returns the event with the given ID
+func (events Events) Get(id string) (EventInfo, bool) {
+	for _, e := range events {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return EventInfo{}, false
+}
+
+// Upcoming returns the events that haven't finished yet, including the ones running now
+func (events Events) Upcoming(now time.Time) Events {
+	upcoming := Events{}
+	for _, e := range events {
+		if e.End.IsZero() || now.Before(e.End.AddDate(0, 0, 1)) {
+			upcoming = append(upcoming, e)
+		}
+	}
+	return upcoming
+}
+
+// Available returns the events running now where the member can lend games
+func (events Events) Available(member Member, now time.Time) Events {
+	available := Events{}
+	for _, e := range events {
+		if e.IsActive(now) && e.IsVolunteer(member) {
+			available = append(available, e)
+		}
+	}
+	return available
+}
+
+// JuegatronEvent gives access to the catalogue, the loan log and the attendee registry of a single event
+type JuegatronEvent struct {
+	Info      EventInfo
+	Audit     *JuegatronAudit
+	Attendees AttendeeDatabase
+	Catalogue CatalogueDatabase
+}
+
+// EventOpener builds the databases of an event
+type EventOpener func(info EventInfo) *JuegatronEvent
+
+// NewSheetEventOpener stores each event in its own spreadsheet
+func NewSheetEventOpener(srv *sheets.Service) EventOpener {
+	return func(info EventInfo) *JuegatronEvent {
+		return &JuegatronEvent{
+			Info: info,
+			Audit: &JuegatronAudit{
+				AuditDB: NewJuegatronSheetAuditDatabase(srv, info.SheetID),
+				GameDB:  NewGameDatabase(srv, info.SheetID),
+			},
+			Attendees: NewAttendeeDatabase(srv, info.SheetID),
+			Catalogue: NewGameDatabase(srv, info.SheetID),
+		}
+	}
+}
+
+// StaticEventDatabase is a fixed list of events, useful when the registry is not stored in a sheet
+type StaticEventDatabase Events
+
+func (db StaticEventDatabase) List(ctx context.Context) ([]EventInfo, error) {
+	return db, nil
+}
+
+// SheetEventDatabase reads the event registry from a sheet tab, cached for CacheDuration
+type SheetEventDatabase struct {
+	SRV           *sheets.Service
+	ReadRange     string
+	Sheet         string
+	SheetID       string
+	CacheDuration time.Duration
+
+	mu       sync.Mutex
+	cache    []EventInfo
+	cacheErr error
+	cachedAt time.Time
+}
+
+func NewEventDatabase(srv *sheets.Service, sheetID string) *SheetEventDatabase {
+	return &SheetEventDatabase{
+		SRV:           srv,
+		ReadRange:     "A:F",
+		Sheet:         "Eventos",
+		SheetID:       sheetID,
+		CacheDuration: 5 * time.Minute,
+	}
+}
+
+func (db *SheetEventDatabase) fullReadRange() string {
+	return fmt.Sprintf("%s!%s", db.Sheet, db.ReadRange)
+}
+
+func (db *SheetEventDatabase) rowReadRange(row int) string {
+	return fmt.Sprintf("%s!%d:%d", db.Sheet, row, row)
+}
+
+// List returns the cached registry. Errors and empty tabs are cached as well,
+// so the callers falling back to defaults don't call the sheet on every message
+func (db *SheetEventDatabase) List(ctx context.Context) ([]EventInfo, error) {
+	db.mu.Lock()
+	cache, cacheErr, cachedAt := db.cache, db.cacheErr, db.cachedAt
+	db.mu.Unlock()
+	if !cachedAt.IsZero() && time.Since(cachedAt) < db.CacheDuration {
+		return cache, cacheErr
+	}
+
+	events, err := db.list(ctx)
+	if ctx.Err() != nil {
+		// The request was cancelled, that says nothing about the sheet
+		return events, err
+	}
+
+	db.mu.Lock()
+	db.cache, db.cacheErr, db.cachedAt = events, err, time.Now()
+	db.mu.Unlock()
+	return events, err
+}
+
+func (db *SheetEventDatabase) list(ctx context.Context) ([]EventInfo, error) {
+	resp, err := db.SRV.Spreadsheets.Values.Get(db.SheetID, db.fullReadRange()).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve events from sheet: %w", err)
+	}
+	events := []EventInfo{}
+
+	if len(resp.Values) == 0 {
+		return events, nil
+	}
+
+	for i, row := range resp.Values[1:] {
+		if len(row) < 1 {
+			continue
+		}
+		e := EventInfo{
+			Row: db.rowReadRange(i + 2),
+		}
+		if err := sheetsparser.Unmarshal(row, &e); err != nil {
+			return nil, err
+		}
+		if e.ID == "" || e.SheetID == "" {
+			continue
+		}
+		if err := ValidateEventID(e.ID); err != nil {
+			logrus.WithError(err).WithField("Event", e.ID).Warn("Skipping event with an invalid ID")
+			continue
+		}
+		if err := ValidateEventDates(e); err != nil {
+			logrus.WithError(err).WithField("Event", e.ID).Warn("Skipping event without dates")
+			continue
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// juegatronEvent opens the databases of the event with the given ID
+func (h *Handler) juegatronEvent(ctx context.Context, id string) (*JuegatronEvent, error) {
+	if h.Events == nil {
+		return nil, fmt.Errorf("%w, %s", ErrEventNotFound, id)
+	}
+	events, err := h.Events.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list events, %w", err)
+	}
+	info, ok := Events(events).Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w, %s", ErrEventNotFound, id)
+	}
+	return h.OpenEvent(info), nil
+}
+
+// InJuegatronEvent loads the event of the button pressed, or the event the member is working on if it is not a button.
+// Buttons carry the event ID, so they keep working whatever the member is doing now
+func (h *Handler) InJuegatronEvent(next func(tele.Context, Member, *JuegatronEvent) error) func(tele.Context, Member) error {
+	return func(c tele.Context, member Member) error {
+		log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "InJuegatronEvent"), c.Sender())
+
+		_, eventID, fromButton := juegatronButtonData(c)
+		if !fromButton {
+			eventID = member.State.JuegatronEventID()
+		}
+		log = log.WithField("Event", eventID)
+
+		event, err := h.juegatronEvent(context.Background(), eventID)
+		if errors.Is(err, ErrEventNotFound) {
+			if c.Callback() != nil {
+				c.Respond()
+			}
+			if fromButton || !member.State.IsJuegatron() {
+				log.WithError(err).Info("Expired juegatron button")
+				return c.Send("Este botón ha caducado, el evento ya no existe o no estás en modo Juegatron")
+			}
+			log.WithError(err).Warn("Member is not in an event")
+			member.State.Clear()
+			if err := h.MembersDB.Update(context.Background(), member); err != nil {
+				log.WithError(err).Error("Failed to update member DB")
+			}
+			return c.Send("No encuentro el evento en el que estabas, vuelve a entrar en modo Juegatron", h.mainMenu(c, member))
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to load event")
+			return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+		}
+		if !event.Info.IsVolunteer(member) {
+			log.Warn("Member is not a volunteer of the event")
+			if c.Callback() != nil {
+				c.Respond()
+			}
+			return c.Send("No eres voluntario de este evento")
+		}
+		return next(c, member, event)
+	}
+}
+
+// juegatronButtonData splits the data of a juegatron button in the data of the button and the event ID, that always goes last.
+// fromButton is false for text messages and for buttons sent before they carried the event ID
+func juegatronButtonData(c tele.Context) (data string, eventID string, fromButton bool) {
+	if c.Callback() == nil {
+		return "", "", false
+	}
+	data, eventID, fromButton = cutLast(c.Data(), "|")
+	return data, eventID, fromButton
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s string, sep string) (before string, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func eventButtons(unique string, events Events) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	rows := []tele.Row{}
+	for _, e := range events {
+		rows = append(rows, selector.Row(
+			selector.Data(e.Name, unique, e.ID),
+		))
+	}
+	selector.Inline(rows...)
+	return selector
+}
+
+func (h *Handler) OnJuegatron(c tele.Context) error {
+	return h.IsAuthorized(h.onJuegatron)(c)
+}
+
+// onJuegatron enters event mode, asking for the event if there are several running
+func (h *Handler) onJuegatron(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Juegatron"), c.Sender())
+
+	if h.Events == nil {
+		return c.Send("No hay ningún evento en curso en el que puedas prestar juegos")
+	}
+	events, err := h.Events.List(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to list events")
+		return c.Send("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+
+	available := Events(events).Available(member, time.Now())
+	switch len(available) {
+	case 0:
+		return c.Send("No hay ningún evento en curso en el que puedas prestar juegos")
+	case 1:
+		return h.enterJuegatronEvent(c, member, available[0])
+	default:
+		return c.Send("¿En qué evento estás?", eventButtons("juegatron-event", available))
+	}
+}
+
+func (h *Handler) OnJuegatronEvent(c tele.Context) error {
+	return h.IsAuthorized(h.onJuegatronEvent)(c)
+}
+
+func (h *Handler) onJuegatronEvent(c tele.Context, member Member) error {
+	defer c.Respond()
+
+	events, err := h.Events.List(context.Background())
+	if err != nil {
+		return c.Edit("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+	info, ok := Events(events).Get(c.Data())
+	if !ok || !info.IsActive(time.Now()) || !info.IsVolunteer(member) {
+		return c.Edit("Ese evento ya no está disponible")
+	}
+	c.Edit(fmt.Sprintf("Evento: %s", info.Name))
+	return h.enterJuegatronEvent(c, member, info)
+}
+
+func (h *Handler) enterJuegatronEvent(c tele.Context, member Member, info EventInfo) error {
+	member.State.SetJuegatron(info.ID)
+	err := h.MembersDB.Update(context.Background(), member)
+	if err != nil {
+		c.Send("Wops! Algo ha ido mal. Inténtalo de nuevo")
+		return fmt.Errorf("Failed to update DB, %w", err)
+	}
+
+	return c.Send(fmt.Sprintf("Listo! Ahora estas en modo juegatron en %s. Puedes buscar juegos diciéndome parte del nombre.\nPor ejemplo, puedes buscar el \"Virus\" diciendo \"vir\".\nSi me dices un DNI/NIE te enseño los juegos que tiene esa persona.", info.Name), juegatronReplyMarkup())
+}
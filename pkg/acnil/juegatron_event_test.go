@@ -0,0 +1,109 @@
+package acnil_test
+
+import (
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Events", func() {
+	var (
+		now    time.Time
+		member acnil.Member
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2023, 11, 4, 18, 0, 0, 0, time.UTC)
+		member = acnil.Member{Nickname: "Pepe", Permissions: acnil.PermissionYes}
+	})
+
+	DescribeTable("An event is active",
+		func(event acnil.EventInfo, active bool) {
+			Expect(event.IsActive(now)).To(Equal(active))
+		},
+		Entry("without dates", acnil.EventInfo{}, true),
+		Entry("on the last day", acnil.EventInfo{Start: time.Date(2023, 11, 3, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 11, 4, 0, 0, 0, 0, time.UTC)}, true),
+		Entry("before it starts", acnil.EventInfo{Start: time.Date(2023, 11, 5, 0, 0, 0, 0, time.UTC)}, false),
+		Entry("after it ends", acnil.EventInfo{End: time.Date(2023, 11, 3, 0, 0, 0, 0, time.UTC)}, false),
+	)
+
+	It("Must only allow the volunteers of the event", func() {
+		event := acnil.EventInfo{Volunteers: "Ana, pepe"}
+		Expect(event.IsVolunteer(member)).To(BeTrue())
+		Expect(event.IsVolunteer(acnil.Member{Nickname: "Bob"})).To(BeFalse())
+		Expect(event.IsVolunteer(acnil.Member{Nickname: "Bob", Permissions: acnil.PermissionAdmin})).To(BeTrue())
+	})
+
+	It("Must recognise renamed volunteers and volunteers listed by telegram ID", func() {
+		event := acnil.EventInfo{Volunteers: "Ana, 1234"}
+		Expect(event.IsVolunteer(acnil.Member{Nickname: "Anita", PreviousNicknames: []string{"Ana"}})).To(BeTrue())
+		Expect(event.IsVolunteer(acnil.Member{Nickname: "Bob", TelegramID: "1234"})).To(BeTrue())
+		Expect(event.IsVolunteer(acnil.Member{Nickname: "Bob", TelegramID: "5678"})).To(BeFalse())
+	})
+
+	It("Must list the events available to the member", func() {
+		events := acnil.Events{
+			{ID: "1"},
+			{ID: "2", Volunteers: "Ana"},
+			{ID: "3", End: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}
+		available := events.Available(member, now)
+		Expect(available).To(HaveLen(1))
+		Expect(available[0].ID).To(Equal("1"))
+	})
+
+	It("Must keep the event in the member state while lending a game", func() {
+		state := acnil.MemberState{}
+		game := acnil.Game{ID: "12", Name: "Catan"}
+
+		state.SetJuegatronWaitingForName("juegatron-2023", game)
+		Expect(state.JuegatronEventID()).To(Equal("juegatron-2023"))
+		Expect(state.JuegatronWaitingForName()).To(Equal(game))
+
+		state.SetJuegatronNewAttendee("juegatron-2023", game, "12345678Z", false)
+		dni, g, correction := state.JuegatronNewAttendee()
+		Expect(state.JuegatronEventID()).To(Equal("juegatron-2023"))
+		Expect(dni).To(Equal("12345678Z"))
+		Expect(g).To(Equal(game))
+		Expect(correction).To(BeFalse())
+	})
+
+	It("Must only tell the juegatron states apart", func() {
+		state := acnil.MemberState{}
+		Expect(state.IsJuegatron()).To(BeFalse())
+		state.SetRename()
+		Expect(state.IsJuegatron()).To(BeFalse())
+		state.SetJuegatronCorrecting("juegatron-2023", acnil.Game{ID: "12"})
+		Expect(state.IsJuegatron()).To(BeTrue())
+	})
+
+	It("Must reject events without start or end dates", func() {
+		start := time.Date(2023, 11, 4, 0, 0, 0, 0, time.UTC)
+		Expect(acnil.ValidateEventDates(acnil.EventInfo{ID: "j", Start: start, End: start.AddDate(0, 0, 1)})).To(Succeed())
+		Expect(acnil.ValidateEventDates(acnil.EventInfo{ID: "j", Start: start})).To(MatchError(acnil.ErrEventDates))
+		Expect(acnil.ValidateEventDates(acnil.EventInfo{ID: "j", End: start})).To(MatchError(acnil.ErrEventDates))
+		Expect(acnil.ValidateEventDates(acnil.EventInfo{ID: "j", Start: start, End: start.AddDate(0, 0, -1)})).To(MatchError(acnil.ErrEventDates))
+	})
+
+	It("Must reject event IDs that don't fit in the state or the buttons", func() {
+		Expect(acnil.ValidateEventID("juegatron-2023")).To(Succeed())
+		Expect(acnil.ValidateEventID("juegatron|2023")).To(MatchError(acnil.ErrInvalidEventID))
+		Expect(acnil.ValidateEventID("juegatron-2023-de-invierno")).To(MatchError(acnil.ErrInvalidEventID))
+	})
+
+	It("Must keep the game in the member state while correcting a loan", func() {
+		state := acnil.MemberState{}
+		game := acnil.Game{ID: "12", Name: "Catan"}
+
+		state.SetJuegatronCorrecting("juegatron-2023", game)
+		Expect(state.Is(acnil.StateActionJuegatronCorrecting)).To(BeTrue())
+		Expect(state.JuegatronWaitingForName()).To(Equal(game))
+
+		state.SetJuegatronNewAttendee("juegatron-2023", game, "12345678Z", true)
+		_, g, correction := state.JuegatronNewAttendee()
+		Expect(g).To(Equal(game))
+		Expect(correction).To(BeTrue())
+	})
+})
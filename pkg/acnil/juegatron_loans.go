@@ -0,0 +1,120 @@
+package acnil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/events"
+)
+
+var ErrCatalogueDelete = errors.New("games can't be removed from the catalogue from the bot, remove the row from the sheet")
+
+// Loans runs the loans of the event on the events package. The loans are written to the loan log on behalf of the actor
+func (e *JuegatronEvent) Loans(actor Member) *events.Event {
+	return events.New(
+		JuegatronCatalogueDatabase{Catalogue: e.Catalogue},
+		&JuegatronLoanDatabase{Audit: e.Audit, Actor: actor},
+	)
+}
+
+// JuegatronCatalogueDatabase exposes the catalogue of an event as an events.GameDatabase
+type JuegatronCatalogueDatabase struct {
+	Catalogue CatalogueDatabase
+}
+
+func (db JuegatronCatalogueDatabase) List(ctx context.Context) ([]events.Game, error) {
+	catalogue, err := db.Catalogue.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	games := make([]events.Game, 0, len(catalogue))
+	for _, g := range catalogue {
+		games = append(games, events.Game{
+			Row:      g.Row,
+			ID:       g.ID,
+			Name:     g.Name,
+			Comments: g.Comments,
+		})
+	}
+	return games, nil
+}
+
+func (db JuegatronCatalogueDatabase) Append(ctx context.Context, game events.Game) error {
+	return db.Catalogue.Append(ctx, Game{
+		ID:       game.ID,
+		Name:     game.Name,
+		Comments: game.Comments,
+	})
+}
+
+// Delete is not supported, the catalogue sheet is only appended by the bot
+func (db JuegatronCatalogueDatabase) Delete(ctx context.Context, game events.Game) error {
+	return fmt.Errorf("%w, %s", ErrCatalogueDelete, game.ID)
+}
+
+// JuegatronLoanDatabase exposes the loan log of an event as an events.LoanDatabase.
+// List replays the log and returns the active loans, Append and Update write new entries, the log is never rewritten
+type JuegatronLoanDatabase struct {
+	Audit *JuegatronAudit
+	// Actor is the volunteer written in the new entries
+	Actor Member
+}
+
+func (db *JuegatronLoanDatabase) List(ctx context.Context) (events.Loans, error) {
+	entries, err := db.Audit.AuditDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list juegatron loans, %w", err)
+	}
+	active := replayJuegatron(entries)
+
+	ids := make([]string, 0, len(active))
+	for id := range active {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	loans := events.Loans{}
+	for _, id := range ids {
+		entry := active[id]
+		loan := events.Loan{
+			Row:      entry.Row,
+			ID:       entry.Row,
+			FullName: entry.Holder,
+			GameID:   entry.ID,
+		}
+		if loan.ID == "" {
+			loan.ID = entry.ID
+		}
+		if t, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+			loan.Time = t
+		}
+		loans = append(loans, loan)
+	}
+	return loans, nil
+}
+
+func (db *JuegatronLoanDatabase) Append(ctx context.Context, loan events.Loan) error {
+	return db.Audit.AuditDB.Append(ctx, []JuegatronAuditEntry{{
+		ID:     loan.GameID,
+		Holder: loan.FullName,
+		Actor:  db.Actor.Nickname,
+	}})
+}
+
+// Update writes a return if the loan is returned, or a correction of the holder otherwise
+func (db *JuegatronLoanDatabase) Update(ctx context.Context, loan events.Loan) error {
+	entry := JuegatronAuditEntry{
+		ID:     loan.GameID,
+		Holder: loan.FullName,
+		Actor:  db.Actor.Nickname,
+		Action: JuegatronActionCorrection,
+	}
+	if loan.Returned {
+		entry.Holder = JuegatronReturnedHolder
+		entry.Action = ""
+	}
+	return db.Audit.AuditDB.Append(ctx, []JuegatronAuditEntry{entry})
+}
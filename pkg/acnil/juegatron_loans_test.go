@@ -0,0 +1,93 @@
+package acnil_test
+
+import (
+	"context"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	"github.com/acnil/acnil-bot/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type memoryJuegatronLog struct {
+	entries []acnil.JuegatronAuditEntry
+}
+
+func (db *memoryJuegatronLog) List(ctx context.Context) ([]acnil.JuegatronAuditEntry, error) {
+	return db.entries, nil
+}
+
+func (db *memoryJuegatronLog) Append(ctx context.Context, entries []acnil.JuegatronAuditEntry) error {
+	db.entries = append(db.entries, entries...)
+	return nil
+}
+
+type memoryCatalogue []acnil.Game
+
+func (db *memoryCatalogue) List(ctx context.Context) ([]acnil.Game, error) {
+	return *db, nil
+}
+
+func (db *memoryCatalogue) Append(ctx context.Context, games ...acnil.Game) error {
+	*db = append(*db, games...)
+	return nil
+}
+
+var _ = Describe("Juegatron loans", func() {
+	var (
+		ctx       context.Context
+		loanLog   *memoryJuegatronLog
+		catalogue *memoryCatalogue
+		event     *acnil.JuegatronEvent
+		volunteer acnil.Member
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		loanLog = &memoryJuegatronLog{}
+		catalogue = &memoryCatalogue{
+			{ID: "1", Name: "Catan"},
+			{ID: "2", Name: "Virus"},
+		}
+		event = &acnil.JuegatronEvent{
+			Info:      acnil.EventInfo{ID: "juegatron-2023"},
+			Audit:     &acnil.JuegatronAudit{AuditDB: loanLog, GameDB: catalogue},
+			Catalogue: catalogue,
+		}
+		volunteer = acnil.Member{Nickname: "Pepe"}
+	})
+
+	It("Must write the loans to the log", func() {
+		Expect(event.Loans(volunteer).LoanGame(ctx, events.Loan{GameID: "1", FullName: "Alice"})).To(Succeed())
+		Expect(loanLog.entries).To(HaveLen(1))
+		Expect(loanLog.entries[0].Holder).To(Equal("Alice"))
+		Expect(loanLog.entries[0].Actor).To(Equal("Pepe"))
+
+		games, err := event.Audit.State(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(games[0].Holder).To(Equal("Alice"))
+	})
+
+	It("Must not lend a game twice", func() {
+		Expect(event.Loans(volunteer).LoanGame(ctx, events.Loan{GameID: "1", FullName: "Alice"})).To(Succeed())
+		Expect(event.Loans(volunteer).LoanGame(ctx, events.Loan{GameID: "1", FullName: "Bob"})).To(MatchError(events.ErrGameLoaned))
+	})
+
+	It("Must not lend games that are not in the catalogue", func() {
+		Expect(event.Loans(volunteer).LoanGame(ctx, events.Loan{GameID: "3", FullName: "Alice"})).To(MatchError(events.ErrGameNotFound))
+	})
+
+	It("Must write the returns to the log", func() {
+		loanLog.entries = []acnil.JuegatronAuditEntry{{ID: "2", Holder: "Alice", Actor: "Juan"}}
+		Expect(event.Loans(volunteer).ReturnGame(ctx, "2")).To(Succeed())
+		Expect(loanLog.entries).To(HaveLen(2))
+		Expect(loanLog.entries[1].IsReturn()).To(BeTrue())
+		Expect(loanLog.entries[1].Actor).To(Equal("Pepe"))
+
+		Expect(event.Loans(volunteer).ReturnGame(ctx, "2")).To(MatchError(events.ErrGameNotLoaned))
+	})
+
+	It("Must not remove games from the catalogue", func() {
+		Expect(event.Loans(volunteer).RemoveGame(ctx, "1")).To(MatchError(acnil.ErrCatalogueDelete))
+	})
+})
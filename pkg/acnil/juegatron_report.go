@@ -0,0 +1,163 @@
+package acnil
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JuegatronLostHolder is the holder written to the log when a game is not returned at the end of the Juegatron
+const JuegatronLostHolder = "perdido"
+
+// IsLost returns true if the entry marks the game as lost
+func (e JuegatronAuditEntry) IsLost() bool {
+	return strings.EqualFold(e.Holder, JuegatronLostHolder)
+}
+
+// JuegatronLoan is a game that has not been returned, with the log entry that gave it away
+type JuegatronLoan struct {
+	Game  Game
+	Entry JuegatronAuditEntry
+}
+
+// Time returns when the game was taken, zero if the log doesn't have a valid timestamp
+func (l JuegatronLoan) Time() time.Time {
+	t, err := time.Parse(time.RFC3339, l.Entry.Timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (l JuegatronLoan) timeText() string {
+	t := l.Time()
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("02/01 15:04")
+}
+
+// JuegatronReport lists the games that are still held or lost at the end of the Juegatron
+type JuegatronReport []JuegatronLoan
+
+// NewJuegatronReport replays the loan log and keeps the games that have an active loan.
+// Loans are sorted by holder and time
+func NewJuegatronReport(catalogue []Game, entries []JuegatronAuditEntry) JuegatronReport {
+	active := replayJuegatron(entries)
+
+	report := JuegatronReport{}
+	for _, g := range catalogue {
+		entry, ok := active[g.ID]
+		if !ok {
+			continue
+		}
+		g.Holder = entry.Holder
+		report = append(report, JuegatronLoan{Game: g, Entry: entry})
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].Game.Holder != report[j].Game.Holder {
+			return Norm(report[i].Game.Holder) < Norm(report[j].Game.Holder)
+		}
+		return report[i].Entry.Timestamp < report[j].Entry.Timestamp
+	})
+	return report
+}
+
+// Held returns the games that are still held by an attendee
+func (r JuegatronReport) Held() JuegatronReport {
+	held := JuegatronReport{}
+	for _, l := range r {
+		if !l.Entry.IsLost() {
+			held = append(held, l)
+		}
+	}
+	return held
+}
+
+// Lost returns the games that were marked as lost
+func (r JuegatronReport) Lost() JuegatronReport {
+	lost := JuegatronReport{}
+	for _, l := range r {
+		if l.Entry.IsLost() {
+			lost = append(lost, l)
+		}
+	}
+	return lost
+}
+
+// ByHolder groups the loans by holder, keeping the order of the report
+func (r JuegatronReport) ByHolder() []JuegatronReport {
+	groups := []JuegatronReport{}
+	for _, l := range r {
+		if len(groups) > 0 && groups[len(groups)-1][0].Game.Holder == l.Game.Holder {
+			groups[len(groups)-1] = append(groups[len(groups)-1], l)
+			continue
+		}
+		groups = append(groups, JuegatronReport{l})
+	}
+	return groups
+}
+
+func (r JuegatronReport) String() string {
+	b := &strings.Builder{}
+	held := r.Held()
+	fmt.Fprintf(b, "Juegos sin devolver (%d)\n", len(held))
+	for _, group := range held.ByHolder() {
+		fmt.Fprintf(b, "\n👤 %s (%d)\n", group[0].Game.Holder, len(group))
+		for _, l := range group {
+			fmt.Fprintf(b, "%s: %s", l.Game.ID, l.Game.Name)
+			if t := l.timeText(); t != "" {
+				fmt.Fprintf(b, ", desde %s", t)
+			}
+			if l.Entry.Actor != "" {
+				fmt.Fprintf(b, ", lo prestó %s", l.Entry.Actor)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	lost := r.Lost()
+	if len(lost) > 0 {
+		fmt.Fprintf(b, "\nJuegos perdidos (%d)\n", len(lost))
+		for _, l := range lost {
+			fmt.Fprintf(b, "%s: %s\n", l.Game.ID, l.Game.Name)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// CSV returns the report as a csv document
+func (r JuegatronReport) CSV() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Write([]string{"ID", "Nombre", "Estado", "Lo tiene", "Desde", "Prestado por"})
+	for _, l := range r {
+		status := "prestado"
+		holder := l.Game.Holder
+		if l.Entry.IsLost() {
+			status = JuegatronLostHolder
+			holder = ""
+		}
+		w.Write([]string{l.Game.ID, l.Game.Name, status, holder, l.Entry.Timestamp, l.Entry.Actor})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Report returns the games that have not been returned yet
+func (a *JuegatronAudit) Report(ctx context.Context) (JuegatronReport, error) {
+	catalogue, err := a.GameDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list juegatron games, %w", err)
+	}
+	entries, err := a.AuditDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list juegatron loans, %w", err)
+	}
+	return NewJuegatronReport(catalogue, entries), nil
+}
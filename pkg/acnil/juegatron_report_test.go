@@ -0,0 +1,64 @@
+package acnil_test
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Juegatron report", func() {
+	var report acnil.JuegatronReport
+
+	BeforeEach(func() {
+		catalogue := []acnil.Game{
+			{ID: "1", Name: "Catan"},
+			{ID: "2", Name: "Virus"},
+			{ID: "3", Name: "Dixit"},
+			{ID: "4", Name: "Azul"},
+			{ID: "5", Name: "Carcassonne"},
+		}
+		report = acnil.NewJuegatronReport(catalogue, []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Bob [X1234567L]", Actor: "Pepe", Timestamp: "2023-11-04T11:00:00Z"},
+			{ID: "2", Holder: "Alice [12345678Z]", Actor: "Pepe", Timestamp: "2023-11-04T10:00:00Z"},
+			{ID: "3", Holder: "Alice [12345678Z]", Actor: "Ana", Timestamp: "2023-11-04T12:00:00Z"},
+			{ID: "4", Holder: "Alice [12345678Z]", Actor: "Ana", Timestamp: "2023-11-04T12:30:00Z"},
+			{ID: "4", Holder: acnil.JuegatronReturnedHolder, Actor: "Ana", Timestamp: "2023-11-04T13:00:00Z"},
+			{ID: "5", Holder: acnil.JuegatronLostHolder, Actor: "Ana", Timestamp: "2023-11-04T20:00:00Z"},
+		})
+	})
+
+	It("Must list the games that were not returned", func() {
+		Expect(report.Held()).To(HaveLen(3))
+		Expect(report.Lost()).To(HaveLen(1))
+		Expect(report.Lost()[0].Game.Name).To(Equal("Carcassonne"))
+	})
+
+	It("Must group the games by holder", func() {
+		groups := report.Held().ByHolder()
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[0][0].Game.Holder).To(Equal("Alice [12345678Z]"))
+		Expect(groups[0]).To(HaveLen(2))
+		Expect(groups[0][0].Game.Name).To(Equal("Virus"))
+		Expect(groups[0][1].Entry.Actor).To(Equal("Ana"))
+		Expect(groups[1][0].Game.Name).To(Equal("Catan"))
+	})
+
+	It("Must show who lent each game", func() {
+		Expect(report.String()).To(ContainSubstring("3: Dixit"))
+		Expect(report.String()).To(ContainSubstring("lo prestó Ana"))
+		Expect(report.String()).ToNot(ContainSubstring("Azul"))
+	})
+
+	It("Must be exported as csv", func() {
+		data, err := report.CSV()
+		Expect(err).To(BeNil())
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		Expect(err).To(BeNil())
+		Expect(records).To(HaveLen(len(report) + 1))
+		Expect(records).To(ContainElement([]string{"1", "Catan", "prestado", "Bob [X1234567L]", "2023-11-04T11:00:00Z", "Pepe"}))
+		Expect(records).To(ContainElement([]string{"5", "Carcassonne", "perdido", "", "2023-11-04T20:00:00Z", "Ana"}))
+	})
+})
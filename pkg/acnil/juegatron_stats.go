@@ -0,0 +1,329 @@
+package acnil
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/charts"
+)
+
+// JuegatronGameStats measures how much a game was used during the Juegatron
+type JuegatronGameStats struct {
+	Game  Game
+	Loans int
+	// Duration is the total time the game was lent, only for loans with a known return
+	Duration time.Duration
+	// Sessions is the number of loans with a known duration
+	Sessions int
+}
+
+// AverageDuration returns the average time the game was out, zero if no loan has been returned yet
+func (s JuegatronGameStats) AverageDuration() time.Duration {
+	if s.Sessions == 0 {
+		return 0
+	}
+	return s.Duration / time.Duration(s.Sessions)
+}
+
+// JuegatronHourStats is the maximum number of games lent at the same time during an hour
+type JuegatronHourStats struct {
+	Hour time.Time
+	Peak int
+}
+
+// JuegatronVolunteerStats counts the loans handled by a volunteer
+type JuegatronVolunteerStats struct {
+	Actor   string
+	Loans   int
+	Returns int
+}
+
+// JuegatronStats summarises the loan log of the Juegatron
+type JuegatronStats struct {
+	// Games that were taken at least once, most lent first
+	Games      []JuegatronGameStats
+	NeverTaken []Game
+	Hours      []JuegatronHourStats
+	Volunteers []JuegatronVolunteerStats
+}
+
+// juegatronSession is a finished loan, kept to reopen it if the entry that closed it is undone
+type juegatronSession struct {
+	Start    time.Time
+	Duration time.Duration
+	// Measured is true if the duration was added to the game stats
+	Measured bool
+	// Return is false if the loan was closed by losing the game
+	Return bool
+}
+
+// NewJuegatronStats replays the loan log on top of the catalogue.
+// Entries without a valid timestamp are counted, but are not used to measure durations or peaks
+func NewJuegatronStats(catalogue []Game, entries []JuegatronAuditEntry) JuegatronStats {
+	games := map[string]*JuegatronGameStats{}
+	for _, g := range catalogue {
+		games[g.ID] = &JuegatronGameStats{Game: g}
+	}
+	volunteers := map[string]*JuegatronVolunteerStats{}
+
+	open := map[string]time.Time{}
+	closed := map[string]juegatronSession{}
+	held := map[string]bool{}
+	hours := map[time.Time]int{}
+	var lastHour time.Time
+	current := 0
+
+	for _, entry := range entries {
+		stats, ok := games[entry.ID]
+		if entry.ID == "" || !ok {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, entry.Timestamp)
+		hasTime := err == nil
+		if hasTime {
+			hour := t.Truncate(time.Hour)
+			// Hours without activity keep the games that were already lent
+			for !lastHour.IsZero() && lastHour.Before(hour) {
+				lastHour = lastHour.Add(time.Hour)
+				if hours[lastHour] < current {
+					hours[lastHour] = current
+				}
+			}
+			lastHour = hour
+		}
+
+		volunteer, ok := volunteers[entry.Actor]
+		if !ok {
+			volunteer = &JuegatronVolunteerStats{Actor: entry.Actor}
+			volunteers[entry.Actor] = volunteer
+		}
+
+		switch {
+		case entry.Action == JuegatronActionCorrection:
+			// The loan goes on, only the holder changes
+		case entry.Action == JuegatronActionUndo && held[entry.ID]:
+			if !entry.IsReturn() {
+				// Undoing a correction only restores the holder
+				break
+			}
+			// The take never happened, only the volunteer that made it can undo it
+			volunteer.Loans--
+			stats.Loans--
+			delete(open, entry.ID)
+			held[entry.ID] = false
+			current--
+		case entry.Action == JuegatronActionUndo:
+			// The return never happened, the loan goes on from its original start
+			session, ok := closed[entry.ID]
+			if !ok {
+				break
+			}
+			if session.Return {
+				volunteer.Returns--
+			}
+			if session.Measured {
+				stats.Duration -= session.Duration
+				stats.Sessions--
+			}
+			if !session.Start.IsZero() {
+				open[entry.ID] = session.Start
+			}
+			delete(closed, entry.ID)
+			held[entry.ID] = true
+			current++
+		default:
+			// Any entry closes the previous loan of the game
+			session := juegatronSession{Return: entry.IsReturn()}
+			if start, ok := open[entry.ID]; ok {
+				session.Start = start
+				if hasTime {
+					session.Duration = t.Sub(start)
+					session.Measured = true
+					stats.Duration += session.Duration
+					stats.Sessions++
+				}
+			}
+			delete(open, entry.ID)
+			if held[entry.ID] {
+				closed[entry.ID] = session
+				current--
+			}
+			held[entry.ID] = false
+
+			switch {
+			case entry.IsReturn():
+				volunteer.Returns++
+			case entry.IsLost():
+			default:
+				volunteer.Loans++
+				stats.Loans++
+				held[entry.ID] = true
+				current++
+				if hasTime {
+					open[entry.ID] = t
+				}
+			}
+		}
+
+		if hasTime && hours[lastHour] < current {
+			hours[lastHour] = current
+		}
+	}
+
+	result := JuegatronStats{}
+	for _, g := range catalogue {
+		stats := games[g.ID]
+		if stats.Loans == 0 {
+			result.NeverTaken = append(result.NeverTaken, g)
+			continue
+		}
+		result.Games = append(result.Games, *stats)
+	}
+	sort.SliceStable(result.Games, func(i, j int) bool {
+		return result.Games[i].Loans > result.Games[j].Loans
+	})
+
+	for hour, peak := range hours {
+		result.Hours = append(result.Hours, JuegatronHourStats{Hour: hour, Peak: peak})
+	}
+	sort.Slice(result.Hours, func(i, j int) bool {
+		return result.Hours[i].Hour.Before(result.Hours[j].Hour)
+	})
+
+	for _, v := range volunteers {
+		if v.Actor == "" {
+			continue
+		}
+		result.Volunteers = append(result.Volunteers, *v)
+	}
+	sort.Slice(result.Volunteers, func(i, j int) bool {
+		a, b := result.Volunteers[i], result.Volunteers[j]
+		if a.Loans+a.Returns != b.Loans+b.Returns {
+			return a.Loans+a.Returns > b.Loans+b.Returns
+		}
+		return a.Actor < b.Actor
+	})
+	return result
+}
+
+// Top returns the n most lent games
+func (s JuegatronStats) Top(n int) []JuegatronGameStats {
+	if len(s.Games) < n {
+		return s.Games
+	}
+	return s.Games[:n]
+}
+
+// Peak returns the hour with the most games lent at the same time
+func (s JuegatronStats) Peak() JuegatronHourStats {
+	peak := JuegatronHourStats{}
+	for _, h := range s.Hours {
+		if h.Peak > peak.Peak {
+			peak = h
+		}
+	}
+	return peak
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dmin", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func (s JuegatronStats) String() string {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "📈 Juegos más prestados\n")
+	for _, g := range s.Top(10) {
+		fmt.Fprintf(b, "%s: %s, %d préstamos", g.Game.ID, g.Game.Name, g.Loans)
+		if g.Sessions > 0 {
+			fmt.Fprintf(b, ", %s de media", formatDuration(g.AverageDuration()))
+		}
+		b.WriteString("\n")
+	}
+
+	if peak := s.Peak(); peak.Peak > 0 {
+		fmt.Fprintf(b, "\n⏰ Máximo de juegos prestados a la vez: %d, a las %s\n", peak.Peak, peak.Hour.Local().Format("15:04 del 02/01"))
+	}
+
+	if len(s.Volunteers) > 0 {
+		fmt.Fprintf(b, "\n🙋 Actividad por voluntario\n")
+		for _, v := range s.Volunteers {
+			fmt.Fprintf(b, "%s: %d préstamos, %d devoluciones\n", v.Actor, v.Loans, v.Returns)
+		}
+	}
+
+	fmt.Fprintf(b, "\n💤 Juegos que nadie ha cogido (%d)\n", len(s.NeverTaken))
+	for _, g := range s.NeverTaken {
+		fmt.Fprintf(b, "%s: %s\n", g.ID, g.Name)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// CSV returns one row per game of the catalogue, including the games that were never taken
+func (s JuegatronStats) CSV() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Write([]string{"ID", "Nombre", "Préstamos", "Duración media (min)"})
+	for _, g := range s.Games {
+		w.Write([]string{g.Game.ID, g.Game.Name, strconv.Itoa(g.Loans), strconv.Itoa(int(g.AverageDuration().Minutes()))})
+	}
+	for _, g := range s.NeverTaken {
+		w.Write([]string{g.ID, g.Name, "0", "0"})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Charts returns png images with the most lent games and the number of games lent along the day
+func (s JuegatronStats) Charts() ([][]byte, error) {
+	images := [][]byte{}
+
+	top := []charts.Bar{}
+	for _, g := range s.Top(20) {
+		top = append(top, charts.Bar{Label: g.Game.Name, Value: float64(g.Loans)})
+	}
+	if len(top) > 0 {
+		img, err := charts.PNG("Juegos mas prestados", top)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+
+	hours := []charts.Bar{}
+	for _, h := range s.Hours {
+		hours = append(hours, charts.Bar{Label: h.Hour.Local().Format("02/01 15:04"), Value: float64(h.Peak)})
+	}
+	if len(hours) > 0 {
+		img, err := charts.PNG("Juegos prestados a la vez", hours)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// Stats returns the statistics of the Juegatron loan log
+func (a *JuegatronAudit) Stats(ctx context.Context) (JuegatronStats, error) {
+	catalogue, err := a.GameDB.List(ctx)
+	if err != nil {
+		return JuegatronStats{}, fmt.Errorf("Failed to list juegatron games, %w", err)
+	}
+	entries, err := a.AuditDB.List(ctx)
+	if err != nil {
+		return JuegatronStats{}, fmt.Errorf("Failed to list juegatron loans, %w", err)
+	}
+	return NewJuegatronStats(catalogue, entries), nil
+}
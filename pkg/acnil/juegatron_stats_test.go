@@ -0,0 +1,117 @@
+package acnil_test
+
+import (
+	"encoding/csv"
+	"strings"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Juegatron stats", func() {
+	var stats acnil.JuegatronStats
+
+	BeforeEach(func() {
+		catalogue := []acnil.Game{
+			{ID: "1", Name: "Catan"},
+			{ID: "2", Name: "Virus"},
+			{ID: "3", Name: "Dixit"},
+		}
+		stats = acnil.NewJuegatronStats(catalogue, []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Actor: "Pepe", Timestamp: "2023-11-04T10:00:00Z"},
+			{ID: "2", Holder: "Bob", Actor: "Ana", Timestamp: "2023-11-04T10:30:00Z"},
+			{ID: "1", Holder: acnil.JuegatronReturnedHolder, Actor: "Ana", Timestamp: "2023-11-04T11:00:00Z"},
+			{ID: "1", Holder: "Carol", Actor: "Pepe", Timestamp: "2023-11-04T13:00:00Z"},
+			{ID: "1", Holder: acnil.JuegatronReturnedHolder, Actor: "Pepe", Timestamp: "2023-11-04T13:30:00Z"},
+			{ID: "2", Holder: acnil.JuegatronReturnedHolder, Actor: "Ana", Timestamp: "2023-11-04T14:30:00Z"},
+		})
+	})
+
+	It("Must sort the games by number of loans", func() {
+		Expect(stats.Games).To(HaveLen(2))
+		Expect(stats.Games[0].Game.Name).To(Equal("Catan"))
+		Expect(stats.Games[0].Loans).To(Equal(2))
+	})
+
+	It("Must compute the average duration of each game", func() {
+		Expect(stats.Games[0].AverageDuration()).To(Equal(45 * time.Minute))
+		Expect(stats.Games[1].AverageDuration()).To(Equal(4 * time.Hour))
+	})
+
+	It("Must list the games nobody took", func() {
+		Expect(stats.NeverTaken).To(HaveLen(1))
+		Expect(stats.NeverTaken[0].Name).To(Equal("Dixit"))
+	})
+
+	It("Must find the peak of concurrent loans per hour", func() {
+		Expect(stats.Hours).To(HaveLen(5))
+		Expect(stats.Peak().Peak).To(Equal(2))
+		Expect(stats.Peak().Hour).To(Equal(time.Date(2023, 11, 4, 10, 0, 0, 0, time.UTC)))
+		// Virus is still out at noon, although nothing happened that hour
+		Expect(stats.Hours[2].Peak).To(Equal(1))
+	})
+
+	It("Must count the activity of each volunteer", func() {
+		Expect(stats.Volunteers).To(Equal([]acnil.JuegatronVolunteerStats{
+			{Actor: "Ana", Loans: 1, Returns: 2},
+			{Actor: "Pepe", Loans: 2, Returns: 1},
+		}))
+	})
+
+	It("Must export all the games as csv", func() {
+		data, err := stats.CSV()
+		Expect(err).To(BeNil())
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		Expect(err).To(BeNil())
+		Expect(records).To(HaveLen(4))
+		Expect(records).To(ContainElement([]string{"1", "Catan", "2", "45"}))
+		Expect(records).To(ContainElement([]string{"3", "Dixit", "0", "0"}))
+	})
+
+	It("Must draw the charts", func() {
+		images, err := stats.Charts()
+		Expect(err).To(BeNil())
+		Expect(images).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Juegatron stats with undone entries", func() {
+	var stats acnil.JuegatronStats
+
+	BeforeEach(func() {
+		catalogue := []acnil.Game{
+			{ID: "1", Name: "Catan"},
+			{ID: "2", Name: "Virus"},
+		}
+		stats = acnil.NewJuegatronStats(catalogue, []acnil.JuegatronAuditEntry{
+			{ID: "1", Holder: "Alice", Actor: "Pepe", Timestamp: "2023-11-04T10:00:00Z"},
+			{ID: "2", Holder: "Bob", Actor: "Pepe", Timestamp: "2023-11-04T10:10:00Z"},
+			{ID: "2", Holder: acnil.JuegatronReturnedHolder, Actor: "Pepe", Action: acnil.JuegatronActionUndo, Timestamp: "2023-11-04T10:11:00Z"},
+			{ID: "1", Holder: acnil.JuegatronReturnedHolder, Actor: "Ana", Timestamp: "2023-11-04T10:30:00Z"},
+			{ID: "1", Holder: "Alice", Actor: "Ana", Action: acnil.JuegatronActionUndo, Timestamp: "2023-11-04T10:31:00Z"},
+			{ID: "1", Holder: "Carol", Actor: "Ana", Action: acnil.JuegatronActionCorrection, Timestamp: "2023-11-04T10:40:00Z"},
+			{ID: "1", Holder: acnil.JuegatronReturnedHolder, Actor: "Ana", Timestamp: "2023-11-04T11:00:00Z"},
+		})
+	})
+
+	It("Must not count undone takes", func() {
+		Expect(stats.Games).To(HaveLen(1))
+		Expect(stats.NeverTaken).To(HaveLen(1))
+		Expect(stats.NeverTaken[0].ID).To(Equal("2"))
+	})
+
+	It("Must keep the loan open when the return is undone", func() {
+		Expect(stats.Games[0].Loans).To(Equal(1))
+		Expect(stats.Games[0].Sessions).To(Equal(1))
+		Expect(stats.Games[0].AverageDuration()).To(Equal(time.Hour))
+	})
+
+	It("Must not count undone activity nor corrections", func() {
+		Expect(stats.Volunteers).To(ConsistOf(
+			acnil.JuegatronVolunteerStats{Actor: "Pepe", Loans: 1, Returns: 0},
+			acnil.JuegatronVolunteerStats{Actor: "Ana", Loans: 0, Returns: 1},
+		))
+	})
+})
@@ -0,0 +1,171 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/sheetsparser"
+	"google.golang.org/api/sheets/v4"
+)
+
+type Location string
+
+const (
+	LocationGamonal Location = "Gamonal"
+	LocationCentro  Location = "Centro"
+)
+
+// DefaultLocations is the registry used when the locations sheet is not available
+var DefaultLocations = Locations{
+	{Name: LocationGamonal},
+	{Name: LocationCentro},
+}
+
+// LocationInfo describes a storage room where the games are kept
+type LocationInfo struct {
+	// Row represents the row definition on google sheets
+	Row string
+
+	Name    Location `col:"0"`
+	Address string   `col:"1"`
+	// Custodians is a comma separated list of the nicknames or telegram IDs of the members in charge of the location
+	Custodians string `col:"2"`
+}
+
+// IsCustodian returns true if the member is in charge of the location
+func (l LocationInfo) IsCustodian(member Member) bool {
+	return member.IsListed(l.Custodians)
+}
+
+// Key identifies the location in the buttons, the name may not fit in the 64 bytes of the callback data
+func (l LocationInfo) Key() string {
+	return shortHash(Norm(string(l.Name)))
+}
+
+type Locations []LocationInfo
+
+// Find returns the location that matches the given name
+func (locations Locations) Find(name string) (LocationInfo, bool) {
+	for _, l := range locations {
+		if strings.EqualFold(strings.TrimSpace(name), string(l.Name)) {
+			return l, true
+		}
+	}
+	return LocationInfo{}, false
+}
+
+// FindKey returns the location of the given key. Buttons sent before they carried the key have the name of the location
+func (locations Locations) FindKey(key string) (LocationInfo, bool) {
+	for _, l := range locations {
+		if l.Key() == key {
+			return l, true
+		}
+	}
+	return locations.Find(key)
+}
+
+// Contains returns true if the given name is a registered location
+func (locations Locations) Contains(name string) bool {
+	_, ok := locations.Find(name)
+	return ok
+}
+
+func (g Game) IsInLocation(location Location) bool {
+	return strings.EqualFold(strings.TrimSpace(g.Location), string(location))
+}
+
+// StaticLocationDatabase is a fixed list of locations, useful when the registry is not stored in a sheet
+type StaticLocationDatabase Locations
+
+func (db StaticLocationDatabase) List(ctx context.Context) ([]LocationInfo, error) {
+	return db, nil
+}
+
+// SheetLocationDatabase reads the location registry from a sheet tab.
+// As the main menu is built from the registry, the list is cached for CacheDuration
+type SheetLocationDatabase struct {
+	SRV           *sheets.Service
+	ReadRange     string
+	Sheet         string
+	SheetID       string
+	CacheDuration time.Duration
+
+	mu       sync.Mutex
+	cache    []LocationInfo
+	cacheErr error
+	cachedAt time.Time
+}
+
+func NewLocationDatabase(srv *sheets.Service, sheetID string) *SheetLocationDatabase {
+	return &SheetLocationDatabase{
+		SRV:           srv,
+		ReadRange:     "A:C",
+		Sheet:         "Ubicaciones",
+		SheetID:       sheetID,
+		CacheDuration: 5 * time.Minute,
+	}
+}
+
+func (db *SheetLocationDatabase) fullReadRange() string {
+	return fmt.Sprintf("%s!%s", db.Sheet, db.ReadRange)
+}
+
+func (db *SheetLocationDatabase) rowReadRange(row int) string {
+	return fmt.Sprintf("%s!%d:%d", db.Sheet, row, row)
+}
+
+// List returns the cached registry. Errors and empty tabs are cached as well,
+// so the callers falling back to defaults don't call the sheet on every message
+func (db *SheetLocationDatabase) List(ctx context.Context) ([]LocationInfo, error) {
+	db.mu.Lock()
+	cache, cacheErr, cachedAt := db.cache, db.cacheErr, db.cachedAt
+	db.mu.Unlock()
+	if !cachedAt.IsZero() && time.Since(cachedAt) < db.CacheDuration {
+		return cache, cacheErr
+	}
+
+	locations, err := db.list(ctx)
+	if ctx.Err() != nil {
+		// The request was cancelled, that says nothing about the sheet
+		return locations, err
+	}
+
+	db.mu.Lock()
+	db.cache, db.cacheErr, db.cachedAt = locations, err, time.Now()
+	db.mu.Unlock()
+	return locations, err
+}
+
+func (db *SheetLocationDatabase) list(ctx context.Context) ([]LocationInfo, error) {
+	resp, err := db.SRV.Spreadsheets.Values.Get(db.SheetID, db.fullReadRange()).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve locations from sheet: %w", err)
+	}
+	locations := []LocationInfo{}
+
+	if len(resp.Values) == 0 {
+		return locations, nil
+	}
+
+	for i, row := range resp.Values[1:] {
+		if len(row) < 1 {
+			continue
+		}
+		l := LocationInfo{
+			Row: db.rowReadRange(i + 2),
+		}
+		err := sheetsparser.Unmarshal(row, &l)
+		if err != nil {
+			return nil, err
+		}
+		if l.Name == "" {
+			continue
+		}
+		locations = append(locations, l)
+	}
+
+	return locations, nil
+}
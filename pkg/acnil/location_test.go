@@ -0,0 +1,69 @@
+package acnil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+var _ = Describe("Location registry", func() {
+	var (
+		ctx      context.Context
+		calls    int
+		status   int
+		response string
+		server   *httptest.Server
+		db       *acnil.SheetLocationDatabase
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		calls = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(response))
+		}))
+		DeferCleanup(server.Close)
+
+		srv, err := sheets.NewService(ctx, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).ToNot(HaveOccurred())
+		db = acnil.NewLocationDatabase(srv, "sheet")
+	})
+
+	It("Must cache the errors", func() {
+		status, response = http.StatusNotFound, `{"error": {"code": 404, "message": "Unable to parse range"}}`
+		_, err := db.List(ctx)
+		Expect(err).To(HaveOccurred())
+		_, err = db.List(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("Must cache empty tabs", func() {
+		status, response = http.StatusOK, `{"range": "Ubicaciones!A1:C1"}`
+		Expect(db.List(ctx)).To(BeEmpty())
+		Expect(db.List(ctx)).To(BeEmpty())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("Must not cache cancelled requests", func() {
+		status, response = http.StatusOK, `{"range": "Ubicaciones!A1:C2", "values": [["Nombre"], ["Gamonal"]]}`
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := db.List(cancelled)
+		Expect(err).To(HaveOccurred())
+
+		locations, err := db.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(locations).To(HaveLen(1))
+		Expect(locations[0].Name).To(Equal(acnil.LocationGamonal))
+	})
+})
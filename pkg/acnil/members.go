@@ -48,6 +48,8 @@ type Member struct {
 	State            MemberState       `col:"3"`
 	TelegramName     string            `col:"4"`
 	TelegramUsername string            `col:"5"`
+	// PreviousNicknames are the names used before renaming, the audit still has them as Holder
+	PreviousNicknames []string `col:"6"`
 }
 
 const (
@@ -63,10 +65,55 @@ func (m *Member) Recipient() string {
 	return m.TelegramID
 }
 
+// Names returns the nickname and the previous nicknames of the member
+func (m Member) Names() []string {
+	return append([]string{m.Nickname}, m.PreviousNicknames...)
+}
+
+// HasName returns true if the name is the nickname or a previous nickname of the member, ignoring accents and case
+func (m Member) HasName(name string) bool {
+	for _, n := range m.Names() {
+		if Norm(strings.TrimSpace(n)) == Norm(strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsListed returns true if the member is in a comma separated list of nicknames or telegram IDs.
+// Previous nicknames are accepted, so the list keeps working after the member is renamed
+func (m Member) IsListed(list string) bool {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if m.HasName(item) || (m.TelegramID != "" && item == m.TelegramID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename changes the nickname and keeps the current one as a previous nickname
+func (m *Member) Rename(nickname string) {
+	previous := []string{}
+	for _, n := range m.PreviousNicknames {
+		if Norm(n) != Norm(m.Nickname) && Norm(n) != Norm(nickname) {
+			previous = append(previous, n)
+		}
+	}
+	if Norm(m.Nickname) != Norm(nickname) {
+		previous = append(previous, m.Nickname)
+	}
+	m.PreviousNicknames = previous
+	m.Nickname = nickname
+}
+
 func NewMembersDatabase(srv *sheets.Service, sheetID string) *SheetMembersDatabase {
 	return &SheetMembersDatabase{
 		SRV:       srv,
-		ReadRange: "A:F",
+		ReadRange: "A:G",
 		Sheet:     "Miembros Telegram",
 		SheetID:   sheetID,
 	}
@@ -184,9 +231,17 @@ type StateAction string
 const (
 	StateActionRename                  StateAction = "rename"
 	StateActionUpdateComment           StateAction = "update-comment"
+	StateActionAddAlias                StateAction = "add-alias"
+	StateActionLinkHolder              StateAction = "link-holder"
 	StateGetGamesTakenByUser           StateAction = "get-games-taken-by-user"
 	StateActionJuegatron               StateAction = "juegatron"
 	StateActionJuegatronWaitingForName StateAction = "juegatron-waiting-for-name"
+	StateActionJuegatronNewAttendee    StateAction = "juegatron-new-attendee"
+	StateActionJuegatronCorrecting     StateAction = "juegatron-correcting"
+	StateActionEventPublish            StateAction = "event-publish"
+	StateActionStocktake               StateAction = "stocktake"
+	StateActionStocktakeFinished       StateAction = "stocktake-finished"
+	StateActionPendingStart            StateAction = "pending-start"
 )
 
 type MemberState struct {
@@ -212,15 +267,112 @@ func (s *MemberState) SetUpdateComment(g Game) {
 	s.Data = g.LineData()
 }
 
+// SetAddAlias waits for the new names of the game
+func (s *MemberState) SetAddAlias(g Game) {
+	s.Action = StateActionAddAlias
+	s.Data = g.LineData()
+}
+
+// SetLinkHolder waits for the member that holds the game
+func (s *MemberState) SetLinkHolder(g Game) {
+	s.Action = StateActionLinkHolder
+	s.Data = g.LineData()
+}
+
 func (s *MemberState) SetGetGamesTakenByUser() {
 	s.Action = StateGetGamesTakenByUser
 }
 
-func (s *MemberState) SetJuegatron() {
+// SetJuegatron enters the event mode. The data of the juegatron states always starts with the event ID
+func (s *MemberState) SetJuegatron(eventID string) {
 	s.Action = StateActionJuegatron
+	s.Data = eventID
+}
+
+// JuegatronEventID returns the event the member is working on
+func (s *MemberState) JuegatronEventID() string {
+	return strings.SplitN(s.Data, "|", 2)[0]
 }
 
-func (s *MemberState) SetJuegatronWaitingForName(g Game) {
+// IsJuegatron returns true if the member is working on an event, the data of the state has the event ID
+func (s *MemberState) IsJuegatron() bool {
+	switch s.Action {
+	case StateActionJuegatron, StateActionJuegatronWaitingForName, StateActionJuegatronNewAttendee, StateActionJuegatronCorrecting:
+		return true
+	}
+	return false
+}
+
+func (s *MemberState) SetJuegatronWaitingForName(eventID string, g Game) {
 	s.Action = StateActionJuegatronWaitingForName
-	s.Data = g.LineData()
+	s.Data = strings.Join([]string{eventID, g.LineData()}, "|")
+}
+
+// SetJuegatronCorrecting waits for the attendee that really has the game
+func (s *MemberState) SetJuegatronCorrecting(eventID string, g Game) {
+	s.Action = StateActionJuegatronCorrecting
+	s.Data = strings.Join([]string{eventID, g.LineData()}, "|")
+}
+
+// JuegatronWaitingForName returns the game stored by SetJuegatronWaitingForName or SetJuegatronCorrecting
+func (s *MemberState) JuegatronWaitingForName() Game {
+	fields := strings.SplitN(s.Data, "|", 2)
+	if len(fields) < 2 {
+		return Game{}
+	}
+	return NewGameFromLineData(fields[1])
+}
+
+// SetJuegatronNewAttendee waits for the name of an attendee that is not registered yet.
+// correction tells if the attendee is taking the game or is the real holder of a loan being corrected
+func (s *MemberState) SetJuegatronNewAttendee(eventID string, g Game, dni string, correction bool) {
+	s.Action = StateActionJuegatronNewAttendee
+	s.Data = strings.Join([]string{eventID, dni, strconv.FormatBool(correction), g.LineData()}, "|")
+}
+
+// JuegatronNewAttendee returns the values stored by SetJuegatronNewAttendee
+func (s *MemberState) JuegatronNewAttendee() (dni string, g Game, correction bool) {
+	fields := strings.SplitN(s.Data, "|", 4)
+	if len(fields) < 4 {
+		return "", Game{}, false
+	}
+	correction, _ = strconv.ParseBool(fields[2])
+	return fields[1], NewGameFromLineData(fields[3]), correction
+}
+
+// SetEventPublish waits for the selection of games to publish in the event
+func (s *MemberState) SetEventPublish(eventID string) {
+	s.Action = StateActionEventPublish
+	s.Data = eventID
+}
+
+// SetEventPublishSelection keeps the selection until the admin confirms it
+func (s *MemberState) SetEventPublishSelection(eventID string, selection string) {
+	s.Action = StateActionEventPublish
+	s.Data = strings.Join([]string{eventID, selection}, "|")
+}
+
+// EventPublish returns the values stored by SetEventPublish and SetEventPublishSelection, selection is empty until it is written
+func (s *MemberState) EventPublish() (eventID string, selection string) {
+	fields := strings.SplitN(s.Data, "|", 2)
+	if len(fields) < 2 {
+		return fields[0], ""
+	}
+	return fields[0], fields[1]
+}
+
+func (s *MemberState) SetStocktake(stocktake Stocktake) {
+	s.Action = StateActionStocktake
+	s.Data = stocktake.Data()
+}
+
+// SetStocktakeFinished keeps the result of the stocktake until the corrections are applied
+func (s *MemberState) SetStocktakeFinished() {
+	s.Action = StateActionStocktakeFinished
+}
+
+// SetPendingStart remembers the deep link used by a member waiting for access, so it can be opened once approved
+func (s *MemberState) SetPendingStart(payload StartPayload) {
+	s.Action = StateActionPendingStart
+	s.Data = payload.String()
 }
@@ -228,6 +228,191 @@ func (mr *MockSenderMockRecorder) Send(to, what any, opts ...any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockSender)(nil).Send), varargs...)
 }
 
+// MockLocationDatabase is a mock of LocationDatabase interface.
+type MockLocationDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockLocationDatabaseMockRecorder
+}
+
+// MockLocationDatabaseMockRecorder is the mock recorder for MockLocationDatabase.
+type MockLocationDatabaseMockRecorder struct {
+	mock *MockLocationDatabase
+}
+
+// NewMockLocationDatabase creates a new mock instance.
+func NewMockLocationDatabase(ctrl *gomock.Controller) *MockLocationDatabase {
+	mock := &MockLocationDatabase{ctrl: ctrl}
+	mock.recorder = &MockLocationDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLocationDatabase) EXPECT() *MockLocationDatabaseMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockLocationDatabase) List(ctx context.Context) ([]acnil.LocationInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]acnil.LocationInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockLocationDatabaseMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockLocationDatabase)(nil).List), ctx)
+}
+
+// MockAttendeeDatabase is a mock of AttendeeDatabase interface.
+type MockAttendeeDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttendeeDatabaseMockRecorder
+}
+
+// MockAttendeeDatabaseMockRecorder is the mock recorder for MockAttendeeDatabase.
+type MockAttendeeDatabaseMockRecorder struct {
+	mock *MockAttendeeDatabase
+}
+
+// NewMockAttendeeDatabase creates a new mock instance.
+func NewMockAttendeeDatabase(ctrl *gomock.Controller) *MockAttendeeDatabase {
+	mock := &MockAttendeeDatabase{ctrl: ctrl}
+	mock.recorder = &MockAttendeeDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttendeeDatabase) EXPECT() *MockAttendeeDatabaseMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockAttendeeDatabase) Append(ctx context.Context, attendee acnil.Attendee) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, attendee)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockAttendeeDatabaseMockRecorder) Append(ctx, attendee any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAttendeeDatabase)(nil).Append), ctx, attendee)
+}
+
+// List mocks base method.
+func (m *MockAttendeeDatabase) List(ctx context.Context) ([]acnil.Attendee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]acnil.Attendee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAttendeeDatabaseMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAttendeeDatabase)(nil).List), ctx)
+}
+
+// MockEventDatabase is a mock of EventDatabase interface.
+type MockEventDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventDatabaseMockRecorder
+}
+
+// MockEventDatabaseMockRecorder is the mock recorder for MockEventDatabase.
+type MockEventDatabaseMockRecorder struct {
+	mock *MockEventDatabase
+}
+
+// NewMockEventDatabase creates a new mock instance.
+func NewMockEventDatabase(ctrl *gomock.Controller) *MockEventDatabase {
+	mock := &MockEventDatabase{ctrl: ctrl}
+	mock.recorder = &MockEventDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventDatabase) EXPECT() *MockEventDatabaseMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockEventDatabase) List(ctx context.Context) ([]acnil.EventInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]acnil.EventInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockEventDatabaseMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockEventDatabase)(nil).List), ctx)
+}
+
+// MockCatalogueDatabase is a mock of CatalogueDatabase interface.
+type MockCatalogueDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockCatalogueDatabaseMockRecorder
+}
+
+// MockCatalogueDatabaseMockRecorder is the mock recorder for MockCatalogueDatabase.
+type MockCatalogueDatabaseMockRecorder struct {
+	mock *MockCatalogueDatabase
+}
+
+// NewMockCatalogueDatabase creates a new mock instance.
+func NewMockCatalogueDatabase(ctrl *gomock.Controller) *MockCatalogueDatabase {
+	mock := &MockCatalogueDatabase{ctrl: ctrl}
+	mock.recorder = &MockCatalogueDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCatalogueDatabase) EXPECT() *MockCatalogueDatabaseMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockCatalogueDatabase) Append(ctx context.Context, games ...acnil.Game) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range games {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Append", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockCatalogueDatabaseMockRecorder) Append(ctx any, games ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, games...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockCatalogueDatabase)(nil).Append), varargs...)
+}
+
+// List mocks base method.
+func (m *MockCatalogueDatabase) List(ctx context.Context) ([]acnil.Game, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]acnil.Game)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockCatalogueDatabaseMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCatalogueDatabase)(nil).List), ctx)
+}
+
 // MockROAudit is a mock of ROAudit interface.
 type MockROAudit struct {
 	ctrl     *gomock.Controller
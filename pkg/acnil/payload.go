@@ -0,0 +1,268 @@
+package acnil
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// PayloadVersion is written first in every payload, so buttons sent with an older format are never misread
+const PayloadVersion = "1"
+
+// payloadSeparator separates the fields of a payload. The data of a button may have more values after the payload, separated by "|"
+const payloadSeparator = ":"
+
+var (
+	ErrInvalidPayload   = errors.New("invalid payload")
+	ErrPayloadVersion   = errors.New("unknown payload version")
+	ErrPayloadSignature = errors.New("invalid payload signature")
+)
+
+// payloadSignature signs the fields of the payload together with the action of the button and the value that follows the payload,
+// so the data can't be moved to another button or get a different value. It is empty if there is no secret
+func payloadSignature(secret string, action string, value string, fields ...string) string {
+	if secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{action, strings.Join(fields, payloadSeparator), value}, "|")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:4])
+}
+
+func checkPayloadSignature(secret string, signature string, action string, value string, fields ...string) error {
+	if secret == "" {
+		return nil
+	}
+	if !hmac.Equal([]byte(signature), []byte(payloadSignature(secret, action, value, fields...))) {
+		return ErrPayloadSignature
+	}
+	return nil
+}
+
+// callbackAction is the unique of the button pressed. It is only needed to check the signature, it is empty without a secret
+func (h *Handler) callbackAction(c tele.Context) string {
+	if h.PayloadSecret == "" || c.Callback() == nil {
+		return ""
+	}
+	return c.Callback().Unique
+}
+
+// shortHash is a 4 character hash, enough to compare a value without storing it. The hash of an empty text is empty
+func shortHash(text string) string {
+	if text == "" {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(text))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:3])
+}
+
+// GamePayload identifies the game of a card button and the holder the card was showing.
+// Name and Holder are hashes, the callback data is limited to 64 bytes
+type GamePayload struct {
+	ID     string
+	Name   string
+	Holder string
+}
+
+func NewGamePayload(g Game) GamePayload {
+	return GamePayload{
+		ID:     g.ID,
+		Name:   shortHash(Norm(g.Name)),
+		Holder: shortHash(Norm(strings.TrimSpace(g.Holder))),
+	}
+}
+
+// Encode writes the payload as "version:name:holder:signature:id".
+// action and value are the unique of the button and the value that follows the payload, they are only signed
+func (p GamePayload) Encode(secret string, action string, value string) string {
+	return strings.Join([]string{PayloadVersion, p.Name, p.Holder, payloadSignature(secret, action, value, PayloadVersion, p.Name, p.Holder, p.ID), p.ID}, payloadSeparator)
+}
+
+// DecodeGamePayload reads the payload written by Encode and checks its signature
+func DecodeGamePayload(secret string, action string, data string, value string) (GamePayload, error) {
+	fields := strings.SplitN(data, payloadSeparator, 5)
+	if fields[0] != PayloadVersion {
+		return GamePayload{}, fmt.Errorf("%w %q", ErrPayloadVersion, fields[0])
+	}
+	if len(fields) != 5 {
+		return GamePayload{}, fmt.Errorf("%w %q", ErrInvalidPayload, data)
+	}
+	p := GamePayload{ID: fields[4], Name: fields[1], Holder: fields[2]}
+	if err := checkPayloadSignature(secret, fields[3], action, value, PayloadVersion, p.Name, p.Holder, p.ID); err != nil {
+		return GamePayload{}, err
+	}
+	return p, nil
+}
+
+// Is returns true if the payload belongs to the game
+func (p GamePayload) Is(g Game) bool {
+	return g.ID == p.ID && shortHash(Norm(g.Name)) == p.Name
+}
+
+// HolderChanged returns true if the game is not held by the same member as when the button was sent
+func (p GamePayload) HolderChanged(g Game) bool {
+	return NewGamePayload(g).Holder != p.Holder
+}
+
+// Find looks for the game of the payload. get is called with the ID only, like GameDatabase.Get or Games.Get,
+// and the name tells apart games with the same ID. The game is nil if it doesn't exist anymore
+func (p GamePayload) Find(get func(id string, name string) (*Game, error)) (*Game, error) {
+	g, err := get(p.ID, "")
+	candidates := []Game{}
+	var mmErr MultipleMatchesError
+	switch {
+	case errors.As(err, &mmErr):
+		candidates = mmErr.Matches
+	case err != nil:
+		return nil, err
+	case g != nil:
+		candidates = append(candidates, *g)
+	}
+
+	found := []Game{}
+	for _, c := range candidates {
+		if p.Is(c) {
+			found = append(found, c)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, MultipleMatchesError{Matches: found}
+	}
+}
+
+// gameButton builds a button of the card of the game, the data is the payload of the game and the value, if any
+func gameButton(selector *tele.ReplyMarkup, secret string, g Game, text string, action string, value ...string) tele.Btn {
+	data := append([]string{NewGamePayload(g).Encode(secret, action, strings.Join(value, "|"))}, value...)
+	return selector.Data(text, action, data...)
+}
+
+// gamePayloadFromCallback reads the payload of the card button pressed and the value that follows it, if any.
+// Buttons sent before payloads existed don't have one, the game is read from the card text instead
+func (h *Handler) gamePayloadFromCallback(c tele.Context) (payload GamePayload, value string, err error) {
+	data, value, _ := strings.Cut(c.Data(), "|")
+	if !strings.Contains(data, payloadSeparator) {
+		g, err := NewGameFromCard(c.Message().Text)
+		if err != nil {
+			return GamePayload{}, "", err
+		}
+		return NewGamePayload(g), data, nil
+	}
+	payload, err = DecodeGamePayload(h.PayloadSecret, h.callbackAction(c), data, value)
+	return payload, value, err
+}
+
+// GamesPayloadVersion is the version of the payload of the messages with several games, it carries the IDs of the games since version 2
+const GamesPayloadVersion = "2"
+
+// gamesPayloadIDSeparator separates the IDs of the games in the payload
+const gamesPayloadIDSeparator = ","
+
+// GamesPayload is the payload of the buttons of a message with several games.
+// It has the IDs of the games and a hash of the games and their holders, to detect any change since the message was sent
+type GamesPayload struct {
+	IDs   []string
+	State string
+}
+
+func NewGamesPayload(games []Game) GamesPayload {
+	ids := []string{}
+	lines := []string{}
+	for _, g := range games {
+		p := NewGamePayload(g)
+		ids = append(ids, g.ID)
+		lines = append(lines, strings.Join([]string{p.ID, p.Name, p.Holder}, payloadSeparator))
+	}
+	sort.Strings(lines)
+	return GamesPayload{IDs: ids, State: shortHash(strings.Join(lines, "\n"))}
+}
+
+// CanEncode returns false if any ID is empty or has a separator, those games can't be found by the payload
+func (p GamesPayload) CanEncode() bool {
+	for _, id := range p.IDs {
+		if id == "" || strings.ContainsAny(id, gamesPayloadIDSeparator+payloadSeparator+"|") {
+			return false
+		}
+	}
+	return len(p.IDs) > 0
+}
+
+// Encode writes the payload as "version:state:signature:ids", the ids are separated by ",".
+// action and value are the unique of the button and the value that follows the payload, they are only signed
+func (p GamesPayload) Encode(secret string, action string, value string) string {
+	ids := strings.Join(p.IDs, gamesPayloadIDSeparator)
+	return strings.Join([]string{GamesPayloadVersion, p.State, payloadSignature(secret, action, value, GamesPayloadVersion, p.State, ids), ids}, payloadSeparator)
+}
+
+// DecodeGamesPayload reads the payload written by Encode and checks its signature
+func DecodeGamesPayload(secret string, action string, data string, value string) (GamesPayload, error) {
+	fields := strings.Split(data, payloadSeparator)
+	if fields[0] != GamesPayloadVersion {
+		return GamesPayload{}, fmt.Errorf("%w %q", ErrPayloadVersion, fields[0])
+	}
+	if len(fields) != 4 || fields[3] == "" {
+		return GamesPayload{}, fmt.Errorf("%w %q", ErrInvalidPayload, data)
+	}
+	if err := checkPayloadSignature(secret, fields[2], action, value, GamesPayloadVersion, fields[1], fields[3]); err != nil {
+		return GamesPayload{}, err
+	}
+	return GamesPayload{IDs: strings.Split(fields[3], gamesPayloadIDSeparator), State: fields[1]}, nil
+}
+
+// Changed returns true if any of the games has changed its holder, or the list is different
+func (p GamesPayload) Changed(games []Game) bool {
+	return NewGamesPayload(games).State != p.State
+}
+
+// GameNotFoundError is returned when a game of the payload doesn't exist anymore
+type GameNotFoundError struct {
+	ID string
+}
+
+func (err GameNotFoundError) Error() string {
+	return fmt.Sprintf("No he encontrado el juego %s, ¿Se ha modificado el excel? vuelve a realizar la búsqueda", err.ID)
+}
+
+// Find returns the games of the payload, in the same order. It fails with GameNotFoundError if a game doesn't exist anymore,
+// or MultipleMatchesError if several games have the same ID
+func (p GamesPayload) Find(games Games) ([]Game, error) {
+	found := []Game{}
+	for _, id := range p.IDs {
+		g, err := games.Get(id, "")
+		if err != nil {
+			return nil, err
+		}
+		if g == nil {
+			return nil, GameNotFoundError{ID: id}
+		}
+		found = append(found, *g)
+	}
+	return found, nil
+}
+
+// bulkPayloadFromCallback reads the payload of the bulk button pressed and the value that follows it, if any
+func (h *Handler) bulkPayloadFromCallback(c tele.Context) (payload GamesPayload, value string, err error) {
+	data, value, _ := strings.Cut(c.Data(), "|")
+	payload, err = DecodeGamesPayload(h.PayloadSecret, h.callbackAction(c), data, value)
+	return payload, value, err
+}
+
+// gameGetter adapts GameDatabase.Get to GamePayload.Find
+func (h *Handler) gameGetter(ctx context.Context) func(id string, name string) (*Game, error) {
+	return func(id string, name string) (*Game, error) {
+		return h.GameDB.Get(ctx, id, name)
+	}
+}
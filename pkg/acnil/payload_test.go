@@ -0,0 +1,146 @@
+package acnil_test
+
+import (
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/acnil/acnil-bot/pkg/acnil/matchers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Payload", func() {
+	var game acnil.Game
+
+	BeforeEach(func() {
+		game = acnil.Game{ID: "1234", Name: "Aventureros al tren", Holder: "Pepe", Location: string(acnil.LocationGamonal)}
+	})
+
+	It("Must decode the encoded game", func() {
+		p, err := acnil.DecodeGamePayload("", "take", acnil.NewGamePayload(game).Encode("", "take", ""), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).To(Equal(acnil.NewGamePayload(game)))
+		Expect(p.Is(game)).To(BeTrue())
+		Expect(p.HolderChanged(game)).To(BeFalse())
+	})
+
+	It("Must reject unknown versions", func() {
+		_, err := acnil.DecodeGamePayload("", "take", "2:abcd:efgh::1234", "")
+		Expect(err).To(MatchError(acnil.ErrPayloadVersion))
+	})
+
+	It("Must detect the holder has changed", func() {
+		p := acnil.NewGamePayload(game)
+		game.Holder = "Juan"
+		Expect(p.HolderChanged(game)).To(BeTrue())
+		game.Holder = ""
+		Expect(p.HolderChanged(game)).To(BeTrue())
+		game.Holder = " pepe "
+		Expect(p.HolderChanged(game)).To(BeFalse())
+	})
+
+	It("Must tell apart games with the same ID", func() {
+		other := acnil.Game{ID: "1234", Name: "Catan"}
+		get := func(id string, name string) (*acnil.Game, error) {
+			Expect(id).To(Equal("1234"))
+			return nil, acnil.MultipleMatchesError{Matches: []acnil.Game{other, game}}
+		}
+		g, err := acnil.NewGamePayload(game).Find(get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(g.Name).To(Equal(game.Name))
+	})
+
+	It("Must not find a game that has been renamed", func() {
+		get := func(id string, name string) (*acnil.Game, error) {
+			return &acnil.Game{ID: "1234", Name: "Catan"}, nil
+		}
+		g, err := acnil.NewGamePayload(game).Find(get)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(g).To(BeNil())
+	})
+
+	It("Must detect changes in a list of games", func() {
+		other := acnil.Game{ID: "2", Name: "Catan"}
+		p := acnil.NewGamesPayload([]acnil.Game{game, other})
+		Expect(p.Changed([]acnil.Game{other, game})).To(BeFalse())
+
+		decoded, err := acnil.DecodeGamesPayload("", "take-all", p.Encode("", "take-all", ""), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(p))
+
+		other.Holder = "Juan"
+		Expect(p.Changed([]acnil.Game{game, other})).To(BeTrue())
+		Expect(p.Changed([]acnil.Game{game})).To(BeTrue())
+	})
+
+	It("Must find the games of a list by their IDs", func() {
+		other := acnil.Game{ID: "2", Name: "Catan"}
+		p := acnil.NewGamesPayload([]acnil.Game{game, other})
+
+		found, err := p.Find(acnil.Games{other, {ID: "3", Name: "Carcassonne"}, game})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(Equal([]acnil.Game{game, other}))
+
+		_, err = p.Find(acnil.Games{game})
+		Expect(err).To(MatchError(acnil.GameNotFoundError{ID: "2"}))
+
+		_, err = p.Find(acnil.Games{game, other, {ID: "2", Name: "Dixit"}})
+		Expect(err).To(BeAssignableToTypeOf(acnil.MultipleMatchesError{}))
+	})
+
+	It("Must not encode lists with games without ID", func() {
+		Expect(acnil.NewGamesPayload([]acnil.Game{game, {Name: "Catan"}}).CanEncode()).To(BeFalse())
+		Expect(acnil.NewGamesPayload([]acnil.Game{game, {ID: "1,2", Name: "Catan"}}).CanEncode()).To(BeFalse())
+		Expect(acnil.NewGamesPayload([]acnil.Game{game}).CanEncode()).To(BeTrue())
+	})
+
+	Describe("With a secret", func() {
+		const secret = "secret"
+
+		It("Must reject forged payloads", func() {
+			data := acnil.NewGamePayload(game).Encode(secret, "take", "")
+			_, err := acnil.DecodeGamePayload(secret, "take", data, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			forged := acnil.NewGamePayload(game)
+			forged.ID = "1"
+			data = data[:len(data)-len(game.ID)] + forged.ID
+			_, err = acnil.DecodeGamePayload(secret, "take", data, "")
+			Expect(err).To(MatchError(acnil.ErrPayloadSignature))
+		})
+
+		It("Must reject payloads moved to other button or value", func() {
+			data := acnil.NewGamePayload(game).Encode(secret, "return-location", "Centro")
+			_, err := acnil.DecodeGamePayload(secret, "return-location", data, "Centro")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = acnil.DecodeGamePayload(secret, "switch-location", data, "Centro")
+			Expect(err).To(MatchError(acnil.ErrPayloadSignature))
+			_, err = acnil.DecodeGamePayload(secret, "return-location", data, "Gamonal")
+			Expect(err).To(MatchError(acnil.ErrPayloadSignature))
+		})
+
+		It("Must reject payloads signed with other secret", func() {
+			data := acnil.NewGamesPayload([]acnil.Game{game}).Encode(secret, "take-all", "")
+			_, err := acnil.DecodeGamesPayload("other", "take-all", data, "")
+			Expect(err).To(MatchError(acnil.ErrPayloadSignature))
+		})
+
+		It("Must fit the callback data of every card button", func() {
+			member := acnil.Member{Nickname: "Pepe", Permissions: acnil.PermissionAdmin}
+			locations := append(acnil.Locations{{Name: acnil.Location(strings.Repeat("l", 64))}}, acnil.DefaultLocations...)
+			buttons := ToOneDimension(game.ButtonsForPage(member, 1, secret).InlineKeyboard)
+			buttons = append(buttons, ToOneDimension(game.ButtonsForPage(member, 2, secret).InlineKeyboard)...)
+			buttons = append(buttons, ToOneDimension(game.ReturnButtons(locations, secret).InlineKeyboard)...)
+			buttons = append(buttons, ToOneDimension(game.LocationButtons(locations, secret).InlineKeyboard)...)
+			buttons = append(buttons, ToOneDimension(game.JuegatronButtons(strings.Repeat("e", acnil.MaxEventIDLength), secret).InlineKeyboard)...)
+			buttons = append(buttons, ToOneDimension(game.JuegatronUndoButtons(strings.Repeat("e", acnil.MaxEventIDLength), secret).InlineKeyboard)...)
+			game.ReservedFor = "Juegatron"
+			buttons = append(buttons, ToOneDimension(game.ButtonsForPage(member, 2, secret).InlineKeyboard)...)
+			Expect(buttons).ToNot(BeEmpty())
+			for _, b := range buttons {
+				Expect(len("\f"+b.Unique+"|"+b.Data)).To(BeNumerically("<=", 64), b.Unique)
+			}
+		})
+	})
+})
@@ -0,0 +1,334 @@
+package acnil
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/ilog"
+	"github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v3"
+)
+
+// RecommendWeight is the complexity the players prefer
+type RecommendWeight string
+
+const (
+	RecommendWeightAny    RecommendWeight = ""
+	RecommendWeightLight  RecommendWeight = "ligero"
+	RecommendWeightMedium RecommendWeight = "medio"
+	RecommendWeightHeavy  RecommendWeight = "duro"
+)
+
+// target is the BGG weight that fits best the complexity
+func (w RecommendWeight) target() float64 {
+	switch w {
+	case RecommendWeightLight:
+		return 1.5
+	case RecommendWeightMedium:
+		return 2.5
+	case RecommendWeightHeavy:
+		return 3.5
+	}
+	return 0
+}
+
+// RecommendRequest is what the players have told the bot. Zero values mean any
+type RecommendRequest struct {
+	Players int
+	// Time is the available time in minutes
+	Time     float64
+	Location Location
+	Weight   RecommendWeight
+}
+
+// Recommendation is a suggested game with its score, higher is better
+type Recommendation struct {
+	Game  Game
+	Score float64
+	// Loans is the number of times the game has been lent recently
+	Loans int
+}
+
+// Weights of each part of the score, they add up to 1
+const (
+	recommendPlayersWeight = 0.2
+	recommendTimeWeight    = 0.2
+	recommendWeightWeight  = 0.2
+	recommendRatingWeight  = 0.25
+	recommendHistoryWeight = 0.15
+)
+
+// LoanCounts counts how many times each game has been lent in the audit entries.
+// The audit stores a snapshot on every change, so loans are told apart by holder and take date
+func LoanCounts(entries []AuditEntry) map[string]int {
+	seen := map[string]bool{}
+	counts := map[string]int{}
+	for _, e := range entries {
+		if e.Holder == "" {
+			continue
+		}
+		key := strings.Join([]string{e.ID, e.Holder, e.TakeDate.Format("2006-01-02")}, "|")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		counts[e.ID]++
+	}
+	return counts
+}
+
+// Recommend returns the available games that suit the request, best first.
+// Games that don't fit the players or the time are discarded, the rest are scored on how well they fit,
+// their BGG rating and how little they have been played in the club
+func Recommend(games []Game, loans map[string]int, req RecommendRequest, limit int) []Recommendation {
+	result := []Recommendation{}
+	for _, g := range games {
+		if !g.IsAvailable() || g.IsInTransit() || g.IsReserved() {
+			continue
+		}
+		if req.Location != "" && !g.IsInLocation(req.Location) {
+			continue
+		}
+		if req.Players > 0 && (req.Players < g.MinPlayers || req.Players > g.MaxPlayers) {
+			continue
+		}
+		if req.Time > 0 && (g.Playingtime == 0 || g.Playingtime > req.Time) {
+			continue
+		}
+
+		score := recommendPlayersWeight*playersFit(g, req.Players) +
+			recommendTimeWeight*timeFit(g, req.Time) +
+			recommendWeightWeight*weightFit(g, req.Weight) +
+			recommendRatingWeight*g.AvgRate/10 +
+			recommendHistoryWeight/float64(1+loans[g.ID])
+
+		result = append(result, Recommendation{Game: g, Score: score, Loans: loans[g.ID]})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// playersFit is 1 when the players are in the middle of the range of the game
+func playersFit(g Game, players int) float64 {
+	if players == 0 {
+		return 1
+	}
+	if g.MaxPlayers == 0 {
+		return 0
+	}
+	middle := float64(g.MinPlayers+g.MaxPlayers) / 2
+	return 1 - math.Abs(float64(players)-middle)/float64(g.MaxPlayers-g.MinPlayers+1)
+}
+
+// timeFit is 1 when the game uses all the available time
+func timeFit(g Game, available float64) float64 {
+	if available == 0 {
+		return 1
+	}
+	return g.Playingtime / available
+}
+
+// weightFit is 1 when the game has the preferred complexity
+func weightFit(g Game, weight RecommendWeight) float64 {
+	if weight == RecommendWeightAny {
+		return 1
+	}
+	if g.AvgWeight == 0 {
+		return 0
+	}
+	return math.Max(0, 1-math.Abs(g.AvgWeight-weight.target())/2)
+}
+
+// Line describes the recommendation in the shortlist
+func (r Recommendation) Line() string {
+	g := r.Game
+	details := []string{}
+	if g.MaxPlayers > 0 {
+		details = append(details, fmt.Sprintf("%d-%d jugadores", g.MinPlayers, g.MaxPlayers))
+	}
+	if g.Playingtime > 0 {
+		details = append(details, fmt.Sprintf("%.0fm", g.Playingtime))
+	}
+	if g.AvgWeight > 0 {
+		details = append(details, fmt.Sprintf("peso %.1f", g.AvgWeight))
+	}
+	if g.AvgRate > 0 {
+		details = append(details, fmt.Sprintf("⭐ %.1f", g.AvgRate))
+	}
+	switch r.Loans {
+	case 0:
+		details = append(details, "nadie lo ha jugado aún")
+	case 1:
+		details = append(details, "prestado 1 vez")
+	default:
+		details = append(details, fmt.Sprintf("prestado %d veces", r.Loans))
+	}
+	return fmt.Sprintf("%s: %s\n    %s", g.ID, g.Name, strings.Join(details, ", "))
+}
+
+// recommendAny is written in the request data for the questions answered with "any"
+const recommendAny = "*"
+
+// recommendQuestions is the number of questions asked before recommending
+const recommendQuestions = 4
+
+// Data encodes the answers given so far, one field per question
+func (req RecommendRequest) Data(answered int) string {
+	fields := []string{
+		strconv.Itoa(req.Players),
+		strconv.FormatFloat(req.Time, 'f', -1, 64),
+		string(req.Location),
+		string(req.Weight),
+	}
+	for i := range fields {
+		if fields[i] == "" || fields[i] == "0" {
+			fields[i] = recommendAny
+		}
+	}
+	return strings.Join(fields[:answered], "|")
+}
+
+// ParseRecommendRequest reads the data written by Data and returns the number of questions answered
+func ParseRecommendRequest(data string) (RecommendRequest, int) {
+	req := RecommendRequest{}
+	if data == "" {
+		return req, 0
+	}
+	fields := strings.Split(data, "|")
+	for i, field := range fields {
+		if field == recommendAny {
+			continue
+		}
+		switch i {
+		case 0:
+			req.Players, _ = strconv.Atoi(field)
+		case 1:
+			req.Time, _ = strconv.ParseFloat(field, 64)
+		case 2:
+			req.Location = Location(field)
+		case 3:
+			req.Weight = RecommendWeight(field)
+		}
+	}
+	return req, len(fields)
+}
+
+// recommendQuestion returns the next question and its answers given the answers so far
+func recommendQuestion(req RecommendRequest, answered int, locations Locations) (string, *tele.ReplyMarkup) {
+	selector := &tele.ReplyMarkup{}
+	answer := func(text string, value string) tele.Btn {
+		data := value
+		if answered > 0 {
+			data = req.Data(answered) + "|" + value
+		}
+		return selector.Data(text, "recommend", data)
+	}
+
+	switch answered {
+	case 0:
+		selector.Inline(
+			selector.Row(answer("1", "1"), answer("2", "2"), answer("3", "3"), answer("4", "4")),
+			selector.Row(answer("5", "5"), answer("6", "6"), answer("7", "7"), answer("8+", "8")),
+		)
+		return "🎲 ¿A qué jugamos? ¿Cuántos sois?", selector
+	case 1:
+		selector.Inline(
+			selector.Row(answer("30m", "30"), answer("1h", "60"), answer("1h 30m", "90")),
+			selector.Row(answer("2h", "120"), answer("3h", "180"), answer("Sin prisa", recommendAny)),
+		)
+		return "¿Cuánto tiempo tenéis?", selector
+	case 2:
+		rows := []tele.Row{}
+		for _, l := range locations {
+			rows = append(rows, selector.Row(answer(string(l.Name), string(l.Name))))
+		}
+		rows = append(rows, selector.Row(answer("Cualquiera", recommendAny)))
+		selector.Inline(rows...)
+		return "¿Dónde estáis?", selector
+	default:
+		selector.Inline(
+			selector.Row(answer("Ligero", string(RecommendWeightLight)), answer("Medio", string(RecommendWeightMedium)), answer("Duro", string(RecommendWeightHeavy))),
+			selector.Row(answer("Me da igual", recommendAny)),
+		)
+		return "¿Qué complejidad preferís?", selector
+	}
+}
+
+// maxRecommendations is the length of the shortlist
+const maxRecommendations = 5
+
+// recommendHistory is how far back the loans are counted
+const recommendHistory = 365 * 24 * time.Hour
+
+func (h *Handler) OnRecommend(c tele.Context) error {
+	return h.IsAuthorized(h.onRecommend)(c)
+}
+
+// onRecommend starts the recommendation questions
+func (h *Handler) onRecommend(c tele.Context, member Member) error {
+	question, answers := recommendQuestion(RecommendRequest{}, 0, h.locations(context.Background()))
+	return c.Send(question, answers)
+}
+
+func (h *Handler) OnRecommendAnswer(c tele.Context) error {
+	return h.IsAuthorized(h.onRecommendAnswer)(c)
+}
+
+// onRecommendAnswer asks the next question, or sends the shortlist once everything is answered
+func (h *Handler) onRecommendAnswer(c tele.Context, member Member) error {
+	log := ilog.WithTelegramUser(logrus.WithField(ilog.FieldHandler, "Recommend"), c.Sender())
+	defer c.Respond()
+
+	req, answered := ParseRecommendRequest(c.Data())
+	if answered < recommendQuestions {
+		question, answers := recommendQuestion(req, answered, h.locations(context.Background()))
+		return c.Edit(question, answers)
+	}
+	log = log.WithField("Request", c.Data())
+
+	ctx, cancel := GetContext(c)
+	defer cancel()
+
+	games, err := h.GameDB.List(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to list games")
+		return c.Edit("Wops! Algo ha ido mal, vuelve a intentarlo en unos momentos")
+	}
+
+	loans := map[string]int{}
+	if h.Audit != nil {
+		entries, err := h.Audit.Find(ctx, Query{From: time.Now().Add(-recommendHistory)})
+		if err != nil {
+			// The history only changes the order, the recommendation is still useful without it
+			log.WithError(err).Warn("Failed to load loan history")
+		}
+		loans = LoanCounts(entries)
+	}
+
+	recommendations := Recommend(games, loans, req, maxRecommendations)
+	if len(recommendations) == 0 {
+		log.Info("Nothing to recommend")
+		return c.Edit("No he encontrado ningún juego disponible que encaje, prueba con otras respuestas o /jugamos para empezar de nuevo")
+	}
+	log.WithField("Results", len(recommendations)).Info("Sending recommendations")
+
+	lines := []string{"🎲 Os recomiendo:"}
+	for i, r := range recommendations {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, r.Line()))
+	}
+	c.Edit(strings.Join(lines, "\n"))
+	for _, r := range recommendations {
+		if err := c.Send(r.Game.Card(), r.Game.Buttons(member, h.PayloadSecret)); err != nil {
+			log.Error(err)
+		}
+	}
+	return nil
+}
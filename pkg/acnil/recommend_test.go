@@ -0,0 +1,73 @@
+package acnil_test
+
+import (
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Game recommendations", func() {
+	var games []acnil.Game
+
+	BeforeEach(func() {
+		games = []acnil.Game{
+			{ID: "1", Name: "Catan", Location: "Centro", MinPlayers: 3, MaxPlayers: 4, Playingtime: 90, AvgWeight: 2.3, AvgRate: 7.1},
+			{ID: "2", Name: "Virus", Location: "Gamonal", MinPlayers: 2, MaxPlayers: 6, Playingtime: 20, AvgWeight: 1.2, AvgRate: 6.5},
+			{ID: "3", Name: "Dixit", Location: "Gamonal", MinPlayers: 3, MaxPlayers: 6, Playingtime: 30, AvgWeight: 1.2, AvgRate: 7.1},
+			{ID: "4", Name: "Twilight Imperium", Location: "Centro", MinPlayers: 3, MaxPlayers: 6, Playingtime: 480, AvgWeight: 4.3, AvgRate: 8.6},
+			{ID: "5", Name: "Prestado", Location: "Centro", Holder: "Pepe", MinPlayers: 2, MaxPlayers: 6, Playingtime: 30, AvgRate: 9},
+		}
+	})
+
+	names := func(list []acnil.Recommendation) []string {
+		out := []string{}
+		for _, r := range list {
+			out = append(out, r.Game.Name)
+		}
+		return out
+	}
+
+	It("Must only recommend available games that fit players, time and location", func() {
+		list := acnil.Recommend(games, nil, acnil.RecommendRequest{Players: 5, Time: 60, Location: "Gamonal"}, 0)
+		Expect(names(list)).To(ConsistOf("Virus", "Dixit"))
+	})
+
+	It("Must rank games by how well they fit the complexity", func() {
+		list := acnil.Recommend(games, nil, acnil.RecommendRequest{Players: 3, Weight: acnil.RecommendWeightHeavy}, 0)
+		Expect(names(list)[0]).To(Equal("Twilight Imperium"))
+
+		list = acnil.Recommend(games, nil, acnil.RecommendRequest{Players: 3, Weight: acnil.RecommendWeightLight}, 0)
+		Expect(names(list)[:2]).To(ConsistOf("Virus", "Dixit"))
+	})
+
+	It("Must boost games that have been played less", func() {
+		request := acnil.RecommendRequest{Players: 4, Time: 30}
+		Expect(names(acnil.Recommend(games, nil, request, 1))).To(Equal([]string{"Dixit"}))
+
+		loans := map[string]int{"3": 10}
+		Expect(names(acnil.Recommend(games, loans, request, 1))).To(Equal([]string{"Virus"}))
+	})
+
+	It("Must count each loan once from the audit", func() {
+		take := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+		counts := acnil.LoanCounts([]acnil.AuditEntry{
+			{ID: "1", Holder: "Pepe", TakeDate: take},
+			{ID: "1", Holder: "Pepe", TakeDate: take, Comments: "Updated while lent"},
+			{ID: "1"},
+			{ID: "1", Holder: "Ana", TakeDate: take.AddDate(0, 0, 7)},
+			{ID: "2", Holder: "Ana", TakeDate: take},
+		})
+		Expect(counts).To(Equal(map[string]int{"1": 2, "2": 1}))
+	})
+
+	It("Must keep the answers between questions", func() {
+		request := acnil.RecommendRequest{Players: 4, Location: "Gamonal"}
+		Expect(request.Data(3)).To(Equal("4|*|Gamonal"))
+
+		parsed, answered := acnil.ParseRecommendRequest("4|*|Gamonal|medio")
+		Expect(answered).To(Equal(4))
+		Expect(parsed).To(Equal(acnil.RecommendRequest{Players: 4, Location: "Gamonal", Weight: acnil.RecommendWeightMedium}))
+	})
+})
@@ -0,0 +1,202 @@
+package acnil
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scores given to each kind of match, a fuzzy match is scaled by its similarity so it always ranks below a substring
+const (
+	scoreExact     = 1.0
+	scorePrefix    = 0.9
+	scoreWordStart = 0.8
+	scoreContains  = 0.7
+	scoreFuzzy     = 0.6
+)
+
+const (
+	// fuzzyMatchSimilarity is the similarity needed to return a game when nothing contains the text
+	fuzzyMatchSimilarity = 0.7
+	// fuzzySuggestSimilarity is the similarity needed to suggest a game when nothing matches
+	fuzzySuggestSimilarity = 0.4
+	// fuzzyMinLength avoids matching short words with anything that has a couple of letters in common
+	fuzzyMinLength = 4
+)
+
+// SearchResult is a game found by Games.Search with its score, 1 is an exact match
+type SearchResult struct {
+	Game  Game
+	Score float64
+}
+
+// IsFuzzy returns true if the name of the game doesn't contain the text that was searched
+func (r SearchResult) IsFuzzy() bool {
+	return r.Score < scoreContains
+}
+
+// Search scores every game against the text and returns the ones with some similarity, best first.
+// The aliases of the game are scored too, if one of them is better than the name it is set in MatchedAlias.
+// Games with the same score keep the order of the list
+func (games Games) Search(text string) []SearchResult {
+	query := searchNorm(text)
+	results := []SearchResult{}
+	if query == "" {
+		return results
+	}
+	for _, g := range games {
+		score := SearchScore(query, searchNorm(g.Name))
+		for _, alias := range g.SearchAliases() {
+			if aliasScore := SearchScore(query, searchNorm(alias)); aliasScore > score {
+				score = aliasScore
+				g.MatchedAlias = alias
+			}
+		}
+		if score <= 0 {
+			continue
+		}
+		results = append(results, SearchResult{Game: g, Score: score})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+// SearchAliases returns the aliases and alternate names of the game, without repetitions or names equal to the name of the game
+func (g Game) SearchAliases() []string {
+	seen := map[string]bool{searchNorm(g.Name): true}
+	aliases := []string{}
+	for _, alias := range append(append([]string{}, g.Aliases...), g.AlternateNames...) {
+		key := searchNorm(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// AddAliases appends the aliases that the game doesn't have yet, it returns the ones added
+func (g *Game) AddAliases(aliases ...string) []string {
+	known := map[string]bool{}
+	for _, alias := range append([]string{g.Name}, g.SearchAliases()...) {
+		known[searchNorm(alias)] = true
+	}
+	added := []string{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := searchNorm(alias)
+		if key == "" || known[key] {
+			continue
+		}
+		known[key] = true
+		g.Aliases = append(g.Aliases, alias)
+		added = append(added, alias)
+	}
+	return added
+}
+
+// Suggest returns the closest game when Find doesn't return anything
+func (games Games) Suggest(text string) (Game, bool) {
+	results := games.Search(text)
+	if len(results) == 0 || results[0].Score < scoreFuzzy*fuzzySuggestSimilarity {
+		return Game{}, false
+	}
+	return results[0].Game, true
+}
+
+// SearchScore compares the normalised text with a normalised game name
+func SearchScore(query string, name string) float64 {
+	switch {
+	case query == name:
+		return scoreExact
+	case strings.HasPrefix(name, query):
+		return scorePrefix
+	case strings.Contains(" "+name, " "+query):
+		return scoreWordStart
+	case strings.Contains(name, query):
+		return scoreContains
+	case len([]rune(query)) < fuzzyMinLength:
+		return 0
+	}
+	return scoreFuzzy * max(trigramSimilarity(query, name), wordSimilarity(query, name))
+}
+
+// searchNorm normalises the text with Norm and replaces punctuation with spaces
+func searchNorm(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, Norm(text))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// wordSimilarity compares the text with each group of consecutive words of the name that has the same number of words,
+// so a typo in a single word of a long name can still be found
+func wordSimilarity(query string, name string) float64 {
+	queryWords := len(strings.Fields(query))
+	words := strings.Fields(name)
+	best := editSimilarity(query, name)
+	for i := 0; i+queryWords <= len(words); i++ {
+		best = max(best, editSimilarity(query, strings.Join(words[i:i+queryWords], " ")))
+	}
+	return best
+}
+
+// editSimilarity is 1 minus the edit distance relative to the longest text
+func editSimilarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// trigramSimilarity is the Dice coefficient of the trigrams of both texts, it tolerates words in a different order
+func trigramSimilarity(a string, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(ta)+len(tb))
+}
+
+func trigrams(text string) map[string]bool {
+	result := map[string]bool{}
+	for _, word := range strings.Fields(text) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			result[string(runes[i:i+3])] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package acnil_test
+
+import (
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Game search", func() {
+	var games acnil.Games
+
+	BeforeEach(func() {
+		games = acnil.Games{
+			{ID: "1", Name: "Catan: El juego de cartas"},
+			{ID: "2", Name: "Carcassonne"},
+			{ID: "3", Name: "Catan"},
+			{ID: "4", Name: "Los colonos de Catán"},
+			{ID: "5", Name: "7 Wonders: Duel"},
+			{ID: "6", Name: "Dixit"},
+			{ID: "7", Name: "Uno"},
+		}
+	})
+
+	names := func(list []acnil.Game) []string {
+		out := []string{}
+		for _, g := range list {
+			out = append(out, g.Name)
+		}
+		return out
+	}
+
+	It("Must rank exact, prefix and word matches first", func() {
+		Expect(names(games.Find("catan"))).To(Equal([]string{
+			"Catan",
+			"Catan: El juego de cartas",
+			"Los colonos de Catán",
+		}))
+	})
+
+	It("Must ignore accents, case and punctuation", func() {
+		Expect(names(games.Find("7 WONDERS DUEL"))).To(Equal([]string{"7 Wonders: Duel"}))
+	})
+
+	It("Must find games with typos", func() {
+		Expect(names(games.FindSimilar("carcasone"))).To(Equal([]string{"Carcassonne"}))
+		Expect(names(games.FindSimilar("dixt"))).To(Equal([]string{"Dixit"}))
+	})
+
+	It("Must only find games that contain the text when typos are not allowed", func() {
+		Expect(games.Find("carcasone")).To(BeEmpty())
+		Expect(names(games.Find("carcassonne"))).To(Equal([]string{"Carcassonne"}))
+	})
+
+	It("Must find a typo in a single word of a long name", func() {
+		Expect(names(games.FindSimilar("colonos de katan"))).To(Equal([]string{"Los colonos de Catán"}))
+	})
+
+	It("Must not return fuzzy matches when the text is contained in a name", func() {
+		Expect(names(games.FindSimilar("duel"))).To(Equal([]string{"7 Wonders: Duel"}))
+	})
+
+	It("Must not fuzzy match short words", func() {
+		Expect(games.FindSimilar("dos")).To(BeEmpty())
+	})
+
+	It("Must suggest the closest game when nothing matches", func() {
+		Expect(games.FindSimilar("karkason")).To(BeEmpty())
+		g, ok := games.Suggest("karkason")
+		Expect(ok).To(BeTrue())
+		Expect(g.Name).To(Equal("Carcassonne"))
+
+		_, ok = games.Suggest("zzzzzzzz")
+		Expect(ok).To(BeFalse())
+	})
+
+	Describe("Aliases", func() {
+		BeforeEach(func() {
+			games = append(games,
+				acnil.Game{ID: "8", Name: "Aventureros al tren", AlternateNames: []string{"Ticket to Ride", "Zug um Zug"}},
+				acnil.Game{ID: "9", Name: "Ticket to Ride: Europa"},
+				acnil.Game{ID: "10", Name: "Virus", Aliases: []string{"El del virus"}},
+			)
+		})
+
+		It("Must find games by their alternate names", func() {
+			found := games.Find("ticket to ride")
+			Expect(names(found)).To(Equal([]string{"Aventureros al tren", "Ticket to Ride: Europa"}))
+			Expect(found[0].MatchedAlias).To(Equal("Ticket to Ride"))
+			Expect(found[1].MatchedAlias).To(BeEmpty())
+			Expect(found[0].Card()).To(ContainSubstring(`Encontrado como "Ticket to Ride"`))
+		})
+
+		It("Must prefer the name if it matches better", func() {
+			found := games.Find("aventureros")
+			Expect(names(found)).To(Equal([]string{"Aventureros al tren"}))
+			Expect(found[0].MatchedAlias).To(BeEmpty())
+			Expect(found[0].Card()).ToNot(ContainSubstring("Encontrado como"))
+		})
+
+		It("Must find games by the aliases added by the admins", func() {
+			found := games.Find("el del virus")
+			Expect(names(found)).To(Equal([]string{"Virus"}))
+			Expect(found[0].MatchedAlias).To(Equal("El del virus"))
+		})
+
+		It("Must tolerate typos in the aliases", func() {
+			Expect(names(games.FindSimilar("zug um zog"))).To(Equal([]string{"Aventureros al tren"}))
+		})
+
+		It("Must only add new aliases", func() {
+			g := games[7]
+			Expect(g.AddAliases("Ticket to ride", " Aventureros ", "aventureros al tren", "")).To(Equal([]string{"Aventureros"}))
+			Expect(g.Aliases).To(Equal([]string{"Aventureros"}))
+			Expect(g.SearchAliases()).To(Equal([]string{"Aventureros", "Ticket to Ride", "Zug um Zug"}))
+		})
+	})
+
+	It("Must score the kind of match", func() {
+		Expect(acnil.SearchScore("catan", "catan")).To(BeNumerically(">", acnil.SearchScore("catan", "catan junior")))
+		Expect(acnil.SearchScore("catan", "catan junior")).To(BeNumerically(">", acnil.SearchScore("catan", "los colonos de catan")))
+		Expect(acnil.SearchScore("catan", "los colonos de catan")).To(BeNumerically(">", acnil.SearchScore("katan", "catan")))
+		Expect(acnil.SearchScore("abc", "xyz")).To(BeZero())
+	})
+})
@@ -4,13 +4,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"unicode"
 
+	"github.com/acnil/acnil-bot/pkg/accents"
 	"github.com/acnil/acnil-bot/pkg/sheetsparser"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/text/runes"
-	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
 	"google.golang.org/api/sheets/v4"
 )
 
@@ -24,7 +21,7 @@ type SheetGameDatabase struct {
 func NewGameDatabase(srv *sheets.Service, sheetID string) *SheetGameDatabase {
 	return &SheetGameDatabase{
 		SRV:       srv,
-		ReadRange: "A:T",
+		ReadRange: "A:AE",
 		Sheet:     "Juegos de mesa",
 		SheetID:   sheetID,
 	}
@@ -119,14 +116,28 @@ func (db *SheetGameDatabase) Update(ctx context.Context, games ...Game) error {
 	return nil
 }
 
-// Norm normalises a string for comparison
-func Norm(in string) string {
-	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
-	dst := make([]byte, len(in))
-	ndst, _, err := t.Transform(dst, []byte(in), true)
+// Append adds the games at the end of the sheet.
+// ID and Name are read only on updates, but they are written here because the rows are new
+func (db *SheetGameDatabase) Append(ctx context.Context, games ...Game) error {
+	rows := [][]interface{}{}
+	for _, game := range games {
+		row, err := sheetsparser.Marshal(&game)
+		if err != nil {
+			return fmt.Errorf("Failed to marshal game, %w", err)
+		}
+		row[0] = game.ID
+		row[1] = game.Name
+		rows = append(rows, row)
+	}
+
+	_, err := db.SRV.Spreadsheets.Values.Append(db.SheetID, db.fullReadRange(), &sheets.ValueRange{Values: rows}).ValueInputOption("USER_ENTERED").Context(ctx).Do()
 	if err != nil {
-		logrus.Printf("ERROR: %s\n", err)
-		return strings.ToLower(in)
+		return fmt.Errorf("Unable to append data to sheet: %v", err)
 	}
-	return strings.ToLower(string(dst[:ndst]))
+	return nil
+}
+
+// Norm normalises a string for comparison
+func Norm(in string) string {
+	return strings.ToLower(accents.Remove(in))
 }
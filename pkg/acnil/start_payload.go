@@ -0,0 +1,64 @@
+package acnil
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Telegram deep links only accept these characters in the start parameter, up to 64 of them
+var validStartPayload = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+const (
+	// StartPayloadLocationPrefix is the prefix of the /start payload that lists a location
+	StartPayloadLocationPrefix = "loc_"
+)
+
+// StartPayload is the parameter received with /start when a member opens a deep link such as t.me/<bot>?start=game_0042
+type StartPayload struct {
+	Prefix string
+	Value  string
+}
+
+// ParseStartPayload splits the payload in its prefix and value. Returns false if the payload is not known
+func ParseStartPayload(payload string) (StartPayload, bool) {
+	payload = strings.TrimSpace(payload)
+	if !validStartPayload.MatchString(payload) {
+		return StartPayload{}, false
+	}
+	for _, prefix := range []string{StartPayloadGamePrefix, StartPayloadLocationPrefix} {
+		if strings.HasPrefix(payload, prefix) && len(payload) > len(prefix) {
+			return StartPayload{Prefix: prefix, Value: strings.TrimPrefix(payload, prefix)}, true
+		}
+	}
+	return StartPayload{}, false
+}
+
+func (p StartPayload) String() string {
+	return p.Prefix + p.Value
+}
+
+// locationSlug converts the name of the location to the characters allowed in a start payload
+func locationSlug(name Location) string {
+	slug := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, Norm(strings.TrimSpace(string(name))))
+	return slug
+}
+
+// DeepLink returns a telegram link that lists the games of the location in the bot
+func (l LocationInfo) DeepLink(botName string) string {
+	return "https://t.me/" + botName + "?start=" + StartPayloadLocationPrefix + locationSlug(l.Name)
+}
+
+// FindBySlug returns the location that matches the value of a start payload
+func (locations Locations) FindBySlug(slug string) (LocationInfo, bool) {
+	for _, l := range locations {
+		if locationSlug(l.Name) == strings.ToLower(slug) {
+			return l, true
+		}
+	}
+	return LocationInfo{}, false
+}
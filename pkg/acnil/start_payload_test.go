@@ -0,0 +1,37 @@
+package acnil_test
+
+import (
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("A start payload", func() {
+	It("Must open a game", func() {
+		payload, ok := acnil.ParseStartPayload("game_0042")
+		Expect(ok).To(BeTrue())
+		Expect(payload.Prefix).To(Equal(acnil.StartPayloadGamePrefix))
+		Expect(payload.Value).To(Equal("0042"))
+	})
+	It("Must match the deep link of a game", func() {
+		link := acnil.Game{ID: "42"}.DeepLink("acnilbot")
+		Expect(link).To(Equal("https://t.me/acnilbot?start=game_42"))
+	})
+	It("Must find a location from its deep link", func() {
+		locations := acnil.Locations{{Name: "Almacén Norte"}}
+		_, value, _ := strings.Cut(locations[0].DeepLink("acnilbot"), "start=")
+		payload, ok := acnil.ParseStartPayload(value)
+		Expect(ok).To(BeTrue())
+		location, ok := locations.FindBySlug(payload.Value)
+		Expect(ok).To(BeTrue())
+		Expect(location.Name).To(Equal(acnil.Location("Almacén Norte")))
+	})
+	It("Must ignore unknown payloads", func() {
+		_, ok := acnil.ParseStartPayload("invite_1234")
+		Expect(ok).To(BeFalse())
+		_, ok = acnil.ParseStartPayload("game_")
+		Expect(ok).To(BeFalse())
+	})
+})
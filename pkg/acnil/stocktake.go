@@ -0,0 +1,182 @@
+package acnil
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stocktake keeps track of the games seen on the shelves during a physical inventory check.
+// It is stored as the data of the member state while the check is running
+type Stocktake struct {
+	// Location is the place being checked right now
+	Location Location `json:"location,omitempty"`
+	// Seen contains the IDs of the games seen on each location
+	Seen map[Location][]string `json:"seen,omitempty"`
+}
+
+func NewStocktakeFromData(data string) (Stocktake, error) {
+	s := Stocktake{}
+	if data == "" {
+		return s, nil
+	}
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return s, fmt.Errorf("invalid stocktake data, %w", err)
+	}
+	return s, nil
+}
+
+func (s Stocktake) Data() string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// MarkSeen records that the game was found in the current location
+func (s *Stocktake) MarkSeen(id string) {
+	if s.Seen == nil {
+		s.Seen = map[Location][]string{}
+	}
+	for location, ids := range s.Seen {
+		for i := range ids {
+			if ids[i] == id {
+				s.Seen[location] = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+	}
+	s.Seen[s.Location] = append(s.Seen[s.Location], id)
+}
+
+// SeenIn returns the location where the game was seen
+func (s Stocktake) SeenIn(id string) (Location, bool) {
+	for location, ids := range s.Seen {
+		for i := range ids {
+			if ids[i] == id {
+				return location, true
+			}
+		}
+	}
+	return "", false
+}
+
+// IsChecked returns true if at least one game has been seen in the location
+func (s Stocktake) IsChecked(location string) bool {
+	for l, ids := range s.Seen {
+		if strings.EqualFold(strings.TrimSpace(location), string(l)) && len(ids) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of games seen so far
+func (s Stocktake) Count() int {
+	count := 0
+	for _, ids := range s.Seen {
+		count += len(ids)
+	}
+	return count
+}
+
+type StocktakeStatus string
+
+const (
+	// StocktakeMissing the game should be in a checked location but was not seen
+	StocktakeMissing StocktakeStatus = "no encontrado"
+	// StocktakeWrongLocation the game was seen in a location different from the one in the sheet
+	StocktakeWrongLocation StocktakeStatus = "ubicación incorrecta"
+	// StocktakeHeld the game is lent to a member
+	StocktakeHeld StocktakeStatus = "prestado"
+)
+
+type StocktakeEntry struct {
+	Game   Game
+	Status StocktakeStatus
+	SeenIn Location
+}
+
+// StocktakeReport is the reconciliation between the games seen and the games in the sheet
+type StocktakeReport []StocktakeEntry
+
+// Report compares the games seen with the list of games.
+// Only the locations where at least one game was seen are checked for missing games
+func (s Stocktake) Report(games []Game) StocktakeReport {
+	report := StocktakeReport{}
+	for _, g := range games {
+		seenIn, seen := s.SeenIn(g.ID)
+		switch {
+		case !g.IsAvailable():
+			report = append(report, StocktakeEntry{Game: g, Status: StocktakeHeld, SeenIn: seenIn})
+		case seen && !g.IsInLocation(seenIn):
+			report = append(report, StocktakeEntry{Game: g, Status: StocktakeWrongLocation, SeenIn: seenIn})
+		case !seen && s.IsChecked(g.Location):
+			report = append(report, StocktakeEntry{Game: g, Status: StocktakeMissing})
+		}
+	}
+	sort.SliceStable(report, func(i, j int) bool { return report[i].Status < report[j].Status })
+	return report
+}
+
+// Filter returns the entries with the given status
+func (r StocktakeReport) Filter(status StocktakeStatus) StocktakeReport {
+	filtered := StocktakeReport{}
+	for _, e := range r {
+		if e.Status == status {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// Corrections returns the games seen in the wrong location, already moved to the location where they were seen
+func (r StocktakeReport) Corrections() Games {
+	games := Games{}
+	for _, e := range r.Filter(StocktakeWrongLocation) {
+		g := e.Game
+		g.CancelTransfer()
+		g.Location = string(e.SeenIn)
+		games = append(games, g)
+	}
+	return games
+}
+
+func (r StocktakeReport) String() string {
+	b := &strings.Builder{}
+	sections := []struct {
+		title  string
+		status StocktakeStatus
+	}{
+		{"Juegos no encontrados", StocktakeMissing},
+		{"Juegos en otra ubicación", StocktakeWrongLocation},
+		{"Juegos prestados", StocktakeHeld},
+	}
+	for _, section := range sections {
+		entries := r.Filter(section.status)
+		fmt.Fprintf(b, "%s (%d)\n", section.title, len(entries))
+		for _, e := range entries {
+			switch e.Status {
+			case StocktakeWrongLocation:
+				fmt.Fprintf(b, "%s: %s → visto en %s\n", e.Game.Line(), e.Game.Location, e.SeenIn)
+			default:
+				fmt.Fprintf(b, "%s\n", e.Game.Line())
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// CSV returns the report as a csv document
+func (r StocktakeReport) CSV() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Write([]string{"ID", "Nombre", "Estado", "Ubicación", "Visto en", "Prestado a"})
+	for _, e := range r {
+		w.Write([]string{e.Game.ID, e.Game.Name, string(e.Status), e.Game.Location, string(e.SeenIn), e.Game.Holder})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,99 @@
+package acnil_test
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/acnil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stocktake", func() {
+	var (
+		games     []acnil.Game
+		stocktake acnil.Stocktake
+	)
+
+	BeforeEach(func() {
+		games = []acnil.Game{
+			{ID: "1", Name: "Seen", Location: string(acnil.LocationGamonal)},
+			{ID: "2", Name: "Missing", Location: string(acnil.LocationGamonal)},
+			{ID: "3", Name: "Moved", Location: string(acnil.LocationCentro)},
+			{ID: "4", Name: "Held", Location: string(acnil.LocationGamonal), Holder: "Someone"},
+			{ID: "5", Name: "Not checked", Location: "Almacén"},
+		}
+		stocktake = acnil.Stocktake{Location: acnil.LocationGamonal}
+		stocktake.MarkSeen("1")
+		stocktake.MarkSeen("3")
+	})
+
+	It("Must survive being stored in the member state", func() {
+		loaded, err := acnil.NewStocktakeFromData(stocktake.Data())
+		Expect(err).To(BeNil())
+		Expect(loaded).To(Equal(stocktake))
+	})
+
+	It("Must remember where each game was seen", func() {
+		location, ok := stocktake.SeenIn("3")
+		Expect(ok).To(BeTrue())
+		Expect(location).To(Equal(acnil.LocationGamonal))
+		Expect(stocktake.Count()).To(Equal(2))
+	})
+
+	It("Must move a game seen twice to the last location", func() {
+		stocktake.Location = acnil.LocationCentro
+		stocktake.MarkSeen("3")
+		location, _ := stocktake.SeenIn("3")
+		Expect(location).To(Equal(acnil.LocationCentro))
+		Expect(stocktake.Count()).To(Equal(2))
+	})
+
+	Describe("The report", func() {
+		var report acnil.StocktakeReport
+		BeforeEach(func() {
+			report = stocktake.Report(games)
+		})
+
+		It("Must contain games expected in a checked location but not seen", func() {
+			missing := report.Filter(acnil.StocktakeMissing)
+			Expect(missing).To(HaveLen(1))
+			Expect(missing[0].Game.Name).To(Equal("Missing"))
+		})
+
+		It("Must contain games seen in the wrong location", func() {
+			wrong := report.Filter(acnil.StocktakeWrongLocation)
+			Expect(wrong).To(HaveLen(1))
+			Expect(wrong[0].Game.Name).To(Equal("Moved"))
+			Expect(wrong[0].SeenIn).To(Equal(acnil.LocationGamonal))
+		})
+
+		It("Must contain held games", func() {
+			held := report.Filter(acnil.StocktakeHeld)
+			Expect(held).To(HaveLen(1))
+			Expect(held[0].Game.Name).To(Equal("Held"))
+		})
+
+		It("Must not report games from locations that were not checked", func() {
+			for _, e := range report {
+				Expect(e.Game.Name).ToNot(Equal("Not checked"))
+			}
+		})
+
+		It("Must propose to move the games to where they were seen", func() {
+			corrections := report.Corrections()
+			Expect(corrections).To(HaveLen(1))
+			Expect(corrections[0].ID).To(Equal("3"))
+			Expect(corrections[0].Location).To(Equal(string(acnil.LocationGamonal)))
+		})
+
+		It("Must be exported as csv", func() {
+			data, err := report.CSV()
+			Expect(err).To(BeNil())
+			records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+			Expect(err).To(BeNil())
+			Expect(records).To(HaveLen(len(report) + 1))
+			Expect(records).To(ContainElement([]string{"3", "Moved", string(acnil.StocktakeWrongLocation), "Centro", "Gamonal", ""}))
+		})
+	})
+})
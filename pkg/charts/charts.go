@@ -0,0 +1,107 @@
+// Package charts renders simple horizontal bar charts as png images.
+// Text is drawn with a bitmap font, so accents are removed from the labels.
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/acnil/acnil-bot/pkg/accents"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Bar is a single value of the chart
+type Bar struct {
+	Label string
+	Value float64
+}
+
+const (
+	width      = 800
+	barHeight  = 20
+	barSpacing = 6
+	padding    = 10
+	labelWidth = 260
+	valueWidth = 60
+)
+
+var (
+	face     = basicfont.Face7x13
+	barColor = color.RGBA{R: 0x3b, G: 0x7d, B: 0xd8, A: 0xff}
+)
+
+// Height returns the size in pixels of a chart with n bars
+func Height(n int) int {
+	return 2*padding + face.Height + barSpacing + n*(barHeight+barSpacing)
+}
+
+// PNG draws a horizontal bar chart with a title, one bar per value scaled to the largest one
+func PNG(title string, bars []Bar) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, Height(len(bars))))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+	}
+	text := func(x, y int, s string) {
+		d.Dot = fixed.P(x, y)
+		d.DrawString(s)
+	}
+
+	text(padding, padding+face.Ascent, accents.ASCII(title))
+
+	max := 0.0
+	for _, b := range bars {
+		if b.Value > max {
+			max = b.Value
+		}
+	}
+
+	barArea := width - 2*padding - labelWidth - valueWidth
+	for i, b := range bars {
+		y := padding + face.Height + barSpacing + i*(barHeight+barSpacing)
+		baseline := y + (barHeight+face.Ascent)/2
+
+		text(padding, baseline, truncate(accents.ASCII(b.Label), labelWidth/face.Advance-1))
+
+		length := 0
+		if max > 0 {
+			length = int(float64(barArea) * b.Value / max)
+		}
+		x := padding + labelWidth
+		draw.Draw(img, image.Rect(x, y, x+length, y+barHeight), image.NewUniform(barColor), image.Point{}, draw.Src)
+
+		text(x+length+4, baseline, formatValue(b.Value))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatValue(v float64) string {
+	if v == float64(int(v)) {
+		return fmt.Sprint(int(v))
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return s[:n]
+	}
+	return s[:n-3] + "..."
+}
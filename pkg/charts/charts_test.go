@@ -0,0 +1,47 @@
+package charts
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	bars := []Bar{
+		{Label: "Catán", Value: 12},
+		{Label: "Las mansiones de la locura, segunda edición", Value: 3.5},
+		{Label: "Nunca", Value: 0},
+	}
+	data, err := PNG("Juegos más prestados", bars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != Height(len(bars)) {
+		t.Errorf("unexpected chart size %v", img.Bounds())
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for in, expected := range map[float64]string{
+		3:    "3",
+		2.25: "2.2",
+		0:    "0",
+	} {
+		if got := formatValue(in); got != expected {
+			t.Errorf("formatValue(%v) = %s, expected %s", in, got, expected)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("Carcassonne", 8); got != "Carca..." {
+		t.Errorf("unexpected truncated text %s", got)
+	}
+	if got := truncate("Azul", 8); got != "Azul" {
+		t.Errorf("short text must not change, got %s", got)
+	}
+}
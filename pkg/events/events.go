@@ -2,6 +2,18 @@ package events
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrGameNotFound      = errors.New("game not found")
+	ErrGameAlreadyExists = errors.New("game already exists")
+	ErrGameLoaned        = errors.New("game is loaned")
+	ErrGameNotLoaned     = errors.New("game is not loaned")
 )
 
 type GameDatabase interface {
@@ -28,37 +40,111 @@ func New(GameDB GameDatabase, LoanDB LoanDatabase) *Event {
 	}
 }
 
-// ListGames returns a list of all the available games for loaning
+// ListGames returns a list of all the available games for loaning.
+// Only the games that match the non empty fields of the given game are returned
 func (e *Event) ListGames(ctx context.Context, game Game) ([]Game, error) {
-	panic("Not Implemented Yet")
+	games, err := e.GameDB.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list games, %w", err)
+	}
+	matches := []Game{}
+	for _, g := range games {
+		if g.Matches(game) {
+			matches = append(matches, g)
+		}
+	}
+	return matches, nil
 }
 
 // GetGame returns a single game by ID
 func (e *Event) GetGame(ctx context.Context, gameID string) (Game, error) {
-	panic("Not Implemented Yet")
+	games, err := e.GameDB.List(ctx)
+	if err != nil {
+		return Game{}, fmt.Errorf("failed to list games, %w", err)
+	}
+	g, ok := Games(games).Get(gameID)
+	if !ok {
+		return Game{}, fmt.Errorf("%w, %s", ErrGameNotFound, gameID)
+	}
+	return g, nil
 }
 
 // AddGame Adds a game to the list of available games
 func (e *Event) AddGame(ctx context.Context, game Game) error {
-	panic("Not Implemented Yet")
+	_, err := e.GetGame(ctx, game.ID)
+	if err == nil {
+		return fmt.Errorf("%w, %s", ErrGameAlreadyExists, game.ID)
+	}
+	if !errors.Is(err, ErrGameNotFound) {
+		return err
+	}
+	return e.GameDB.Append(ctx, game)
 }
 
-// RemoveGame Removes a game from the list of available games
+// RemoveGame Removes a game from the list of available games.
+// Games that have not been returned can't be removed
 func (e *Event) RemoveGame(ctx context.Context, gameID string) error {
-	panic("Not Implemented Yet")
+	game, err := e.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	loan, err := e.GetGameLoan(ctx, gameID)
+	if err != nil && !errors.Is(err, ErrGameNotLoaned) {
+		return err
+	}
+	if loan.IsActive() {
+		return fmt.Errorf("%w, %s to %s", ErrGameLoaned, gameID, loan.FullName)
+	}
+	return e.GameDB.Delete(ctx, game)
 }
 
-// GetGameLoan returns the loan status for a given game
+// GetGameLoan returns the loan status for a given game.
+// It is the last loan of the game, that may be already returned
 func (e *Event) GetGameLoan(ctx context.Context, gameID string) (Loan, error) {
-	panic("Not Implemented Yet")
+	loans, err := e.LoanDB.List(ctx)
+	if err != nil {
+		return Loan{}, fmt.Errorf("failed to list loans, %w", err)
+	}
+	gameLoans := loans.ForGame(gameID)
+	if len(gameLoans) == 0 {
+		return Loan{}, fmt.Errorf("%w, %s", ErrGameNotLoaned, gameID)
+	}
+	return gameLoans.Last(), nil
 }
 
 // LoanGame creates a new Loan entry for the game
 func (e *Event) LoanGame(ctx context.Context, loan Loan) error {
-	panic("Not Implemented Yet")
+	if _, err := e.GetGame(ctx, loan.GameID); err != nil {
+		return err
+	}
+	current, err := e.GetGameLoan(ctx, loan.GameID)
+	if err != nil && !errors.Is(err, ErrGameNotLoaned) {
+		return err
+	}
+	if current.IsActive() {
+		return fmt.Errorf("%w, %s to %s", ErrGameLoaned, loan.GameID, current.FullName)
+	}
+
+	loan.Row = ""
+	loan.Returned = false
+	if loan.ID == "" {
+		loan.ID = uuid.NewString()
+	}
+	if loan.Time.IsZero() {
+		loan.Time = time.Now()
+	}
+	return e.LoanDB.Append(ctx, loan)
 }
 
 // ReturnGame updates the loan status to "returned"
 func (e *Event) ReturnGame(ctx context.Context, gameID string) error {
-	panic("Not Implemented Yet")
+	loan, err := e.GetGameLoan(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	if !loan.IsActive() {
+		return fmt.Errorf("%w, %s", ErrGameNotLoaned, gameID)
+	}
+	loan.Returned = true
+	return e.LoanDB.Update(ctx, loan)
 }
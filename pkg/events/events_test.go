@@ -1,4 +1,127 @@
 package events_test
 
+import (
+	"context"
 
+	"github.com/acnil/acnil-bot/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
 
+var _ = Describe("An event", func() {
+	var (
+		ctx    context.Context
+		event  *events.Event
+		loanDB *events.MemoryLoanDatabase
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		loanDB = events.NewMemoryLoanDatabase()
+		event = events.New(events.NewMemoryGameDatabase(
+			events.Game{ID: "1", Name: "Catan"},
+			events.Game{ID: "2", Name: "Carcassonne"},
+		), loanDB)
+	})
+
+	Describe("Games", func() {
+		It("Must list the games matching the filter", func() {
+			games, err := event.ListGames(ctx, events.Game{Name: "cat"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(games).To(HaveLen(1))
+			Expect(games[0].ID).To(Equal("1"))
+		})
+
+		It("Must list all the games with an empty filter", func() {
+			games, err := event.ListGames(ctx, events.Game{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(games).To(HaveLen(2))
+		})
+
+		It("Must get a game by ID", func() {
+			game, err := event.GetGame(ctx, "2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(game.Name).To(Equal("Carcassonne"))
+		})
+
+		It("Must fail to get an unknown game", func() {
+			_, err := event.GetGame(ctx, "3")
+			Expect(err).To(MatchError(events.ErrGameNotFound))
+		})
+
+		It("Must add new games", func() {
+			Expect(event.AddGame(ctx, events.Game{ID: "3", Name: "Azul"})).To(Succeed())
+			game, err := event.GetGame(ctx, "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(game.Name).To(Equal("Azul"))
+		})
+
+		It("Must not add a game twice", func() {
+			Expect(event.AddGame(ctx, events.Game{ID: "1", Name: "Catan"})).To(MatchError(events.ErrGameAlreadyExists))
+		})
+
+		It("Must remove games", func() {
+			Expect(event.RemoveGame(ctx, "1")).To(Succeed())
+			_, err := event.GetGame(ctx, "1")
+			Expect(err).To(MatchError(events.ErrGameNotFound))
+		})
+	})
+
+	Describe("Loans", func() {
+		It("Must report games that have never been loaned", func() {
+			_, err := event.GetGameLoan(ctx, "1")
+			Expect(err).To(MatchError(events.ErrGameNotLoaned))
+		})
+
+		It("Must not loan unknown games", func() {
+			Expect(event.LoanGame(ctx, events.Loan{GameID: "3", FullName: "Alice"})).To(MatchError(events.ErrGameNotFound))
+		})
+
+		Describe("When a game is loaned", func() {
+			BeforeEach(func() {
+				Expect(event.LoanGame(ctx, events.Loan{GameID: "1", FullName: "Alice"})).To(Succeed())
+			})
+
+			It("Must record who has it", func() {
+				loan, err := event.GetGameLoan(ctx, "1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loan.FullName).To(Equal("Alice"))
+				Expect(loan.ID).ToNot(BeEmpty())
+				Expect(loan.Time.IsZero()).To(BeFalse())
+				Expect(loan.IsActive()).To(BeTrue())
+			})
+
+			It("Must not be loaned twice", func() {
+				Expect(event.LoanGame(ctx, events.Loan{GameID: "1", FullName: "Bob"})).To(MatchError(events.ErrGameLoaned))
+			})
+
+			It("Must not be removed", func() {
+				Expect(event.RemoveGame(ctx, "1")).To(MatchError(events.ErrGameLoaned))
+			})
+
+			Describe("And returned", func() {
+				BeforeEach(func() {
+					Expect(event.ReturnGame(ctx, "1")).To(Succeed())
+				})
+
+				It("Must be marked as returned", func() {
+					loan, err := event.GetGameLoan(ctx, "1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(loan.Returned).To(BeTrue())
+				})
+
+				It("Must not be returned twice", func() {
+					Expect(event.ReturnGame(ctx, "1")).To(MatchError(events.ErrGameNotLoaned))
+				})
+
+				It("Must be available to loan again", func() {
+					Expect(event.LoanGame(ctx, events.Loan{GameID: "1", FullName: "Bob"})).To(Succeed())
+					loans, err := loanDB.List(ctx)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(loans).To(HaveLen(2))
+					Expect(loans.ForGame("1").Last().FullName).To(Equal("Bob"))
+				})
+			})
+		})
+	})
+})
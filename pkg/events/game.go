@@ -1,9 +1,37 @@
 package events
 
+import "strings"
+
 // Game available for loaning
 type Game struct {
 	// Row represents the row definition on google sheets
 	Row string
 
-	// TODO
+	ID       string `col:"0"`
+	Name     string `col:"1"`
+	Comments string `col:"2"`
+}
+
+type Games []Game
+
+// Get returns the game with the given ID
+func (games Games) Get(id string) (Game, bool) {
+	for _, g := range games {
+		if g.ID == id {
+			return g, true
+		}
+	}
+	return Game{}, false
+}
+
+// Matches returns true if the game matches all the non empty fields of the filter.
+// The name matches if it contains the name of the filter, ignoring case
+func (g Game) Matches(filter Game) bool {
+	if filter.ID != "" && filter.ID != g.ID {
+		return false
+	}
+	if filter.Name != "" && !strings.Contains(strings.ToLower(g.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+	return true
 }
@@ -8,6 +8,17 @@ func (l Loans) Last() Loan {
 	return l[len(l)-1]
 }
 
+// ForGame returns the loans of the given game, in the same order
+func (l Loans) ForGame(gameID string) Loans {
+	loans := Loans{}
+	for _, loan := range l {
+		if loan.GameID == gameID {
+			loans = append(loans, loan)
+		}
+	}
+	return loans
+}
+
 // Loan represents a game taken by someone
 type Loan struct {
 	// Row represents the row definition on google sheets
@@ -19,3 +30,8 @@ type Loan struct {
 	Returned bool      `col:"3"`
 	Time     time.Time `col:"4"`
 }
+
+// IsActive returns true if the game has not been returned yet
+func (l Loan) IsActive() bool {
+	return l.ID != "" && !l.Returned
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// MemoryGameDatabase keeps the games in memory, useful for tests and short events without a sheet
+type MemoryGameDatabase struct {
+	mu    sync.Mutex
+	games []Game
+}
+
+func NewMemoryGameDatabase(games ...Game) *MemoryGameDatabase {
+	return &MemoryGameDatabase{games: games}
+}
+
+func (db *MemoryGameDatabase) List(ctx context.Context) ([]Game, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Game{}, db.games...), nil
+}
+
+func (db *MemoryGameDatabase) Append(ctx context.Context, game Game) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.games = append(db.games, game)
+	return nil
+}
+
+func (db *MemoryGameDatabase) Delete(ctx context.Context, game Game) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i := range db.games {
+		if db.games[i].ID == game.ID {
+			db.games = append(db.games[:i], db.games[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w, %s", ErrGameNotFound, game.ID)
+}
+
+// MemoryLoanDatabase keeps the loans in memory. The Row of each loan is its position in the list
+type MemoryLoanDatabase struct {
+	mu    sync.Mutex
+	loans Loans
+}
+
+func NewMemoryLoanDatabase() *MemoryLoanDatabase {
+	return &MemoryLoanDatabase{}
+}
+
+func (db *MemoryLoanDatabase) List(ctx context.Context) (Loans, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append(Loans{}, db.loans...), nil
+}
+
+func (db *MemoryLoanDatabase) Append(ctx context.Context, loan Loan) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	loan.Row = strconv.Itoa(len(db.loans))
+	db.loans = append(db.loans, loan)
+	return nil
+}
+
+func (db *MemoryLoanDatabase) Update(ctx context.Context, loan Loan) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i, err := strconv.Atoi(loan.Row)
+	if err != nil || i < 0 || i >= len(db.loans) {
+		return fmt.Errorf("loan %s has an invalid row %q", loan.ID, loan.Row)
+	}
+	db.loans[i] = loan
+	return nil
+}
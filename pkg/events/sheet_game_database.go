@@ -1,8 +1,16 @@
 package events
 
-import "google.golang.org/api/sheets/v4"
+import (
+	"context"
+	"fmt"
 
+	"github.com/acnil/acnil-bot/pkg/sheetsparser"
+	"google.golang.org/api/sheets/v4"
+)
+
+// SheetGameDatabase stores the games of an event in a sheet tab, one game per row
 type SheetGameDatabase struct {
+	SRV       *sheets.Service
 	ReadRange string
 	Sheet     string
 	SheetID   string
@@ -10,8 +18,84 @@ type SheetGameDatabase struct {
 
 func NewSheetGameDatabase(srv *sheets.Service, sheetID string, sheet string) *SheetGameDatabase {
 	return &SheetGameDatabase{
-		ReadRange: "A:N", // Todo, defined based on number of columns
+		SRV:       srv,
+		ReadRange: "A:C",
 		Sheet:     sheet,
 		SheetID:   sheetID,
 	}
 }
+
+func (db *SheetGameDatabase) fullReadRange() string {
+	return fmt.Sprintf("%s!%s", db.Sheet, db.ReadRange)
+}
+
+func (db *SheetGameDatabase) rowReadRange(row int) string {
+	return fmt.Sprintf("%s!%d:%d", db.Sheet, row, row)
+}
+
+func (db *SheetGameDatabase) List(ctx context.Context) ([]Game, error) {
+	resp, err := db.SRV.Spreadsheets.Values.Get(db.SheetID, db.fullReadRange()).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve data from sheet: %w", err)
+	}
+	games := []Game{}
+
+	if len(resp.Values) == 0 {
+		return games, nil
+	}
+
+	for i, row := range resp.Values[1:] {
+		if len(row) < 1 {
+			continue
+		}
+		g := Game{
+			Row: db.rowReadRange(i + 2),
+		}
+		if err := sheetsparser.Unmarshal(row, &g); err != nil {
+			return nil, err
+		}
+		// Deleted games leave an empty row behind
+		if g.ID == "" {
+			continue
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
+
+func (db *SheetGameDatabase) Append(ctx context.Context, game Game) error {
+	row, err := sheetsparser.Marshal(&game)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal game, %w", err)
+	}
+
+	_, err = db.SRV.Spreadsheets.Values.Append(db.SheetID, db.fullReadRange(), &sheets.ValueRange{Values: [][]interface{}{row}}).ValueInputOption("RAW").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Unable to append data to sheet: %w", err)
+	}
+	return nil
+}
+
+// Delete blanks the row of the game, so the rows of the other games don't move
+func (db *SheetGameDatabase) Delete(ctx context.Context, game Game) error {
+	if game.Row == "" {
+		return fmt.Errorf("game %s has no row", game.ID)
+	}
+	empty := Game{}
+	row, err := sheetsparser.Marshal(&empty)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal game, %w", err)
+	}
+	for i := range row {
+		row[i] = ""
+	}
+
+	_, err = db.SRV.Spreadsheets.Values.Update(db.SheetID, game.Row, &sheets.ValueRange{
+		Range:  game.Row,
+		Values: [][]interface{}{row},
+	}).ValueInputOption("RAW").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Unable to delete game from sheet: %w", err)
+	}
+	return nil
+}
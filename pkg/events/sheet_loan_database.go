@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/acnil/acnil-bot/pkg/sheetsparser"
+	"google.golang.org/api/sheets/v4"
+)
+
+// SheetLoanDatabase stores the loans of an event in a sheet tab.
+// Loans are never removed, returning a game only updates the Returned column
+type SheetLoanDatabase struct {
+	SRV       *sheets.Service
+	ReadRange string
+	Sheet     string
+	SheetID   string
+	parser    sheetsparser.SheetParser
+}
+
+func NewSheetLoanDatabase(srv *sheets.Service, sheetID string, sheet string) *SheetLoanDatabase {
+	return &SheetLoanDatabase{
+		SRV:       srv,
+		ReadRange: "A:E",
+		Sheet:     sheet,
+		SheetID:   sheetID,
+		parser: sheetsparser.SheetParser{
+			DateFormat: time.RFC3339,
+		},
+	}
+}
+
+func (db *SheetLoanDatabase) fullReadRange() string {
+	return fmt.Sprintf("%s!%s", db.Sheet, db.ReadRange)
+}
+
+func (db *SheetLoanDatabase) rowReadRange(row int) string {
+	return fmt.Sprintf("%s!%d:%d", db.Sheet, row, row)
+}
+
+func (db *SheetLoanDatabase) List(ctx context.Context) (Loans, error) {
+	resp, err := db.SRV.Spreadsheets.Values.Get(db.SheetID, db.fullReadRange()).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve data from sheet: %w", err)
+	}
+	loans := Loans{}
+
+	if len(resp.Values) == 0 {
+		return loans, nil
+	}
+
+	for i, row := range resp.Values[1:] {
+		if len(row) < 1 {
+			continue
+		}
+		l := Loan{
+			Row: db.rowReadRange(i + 2),
+		}
+		if err := db.parser.Unmarshal(row, &l); err != nil {
+			return nil, err
+		}
+		loans = append(loans, l)
+	}
+	return loans, nil
+}
+
+func (db *SheetLoanDatabase) Append(ctx context.Context, loan Loan) error {
+	row, err := db.parser.Marshal(&loan)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal loan, %w", err)
+	}
+
+	_, err = db.SRV.Spreadsheets.Values.Append(db.SheetID, db.fullReadRange(), &sheets.ValueRange{Values: [][]interface{}{row}}).ValueInputOption("RAW").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Unable to append data to sheet: %w", err)
+	}
+	return nil
+}
+
+func (db *SheetLoanDatabase) Update(ctx context.Context, loan Loan) error {
+	if loan.Row == "" {
+		return fmt.Errorf("loan %s has no row", loan.ID)
+	}
+	row, err := db.parser.Marshal(&loan)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal loan, %w", err)
+	}
+
+	_, err = db.SRV.Spreadsheets.Values.Update(db.SheetID, loan.Row, &sheets.ValueRange{
+		Range:  loan.Row,
+		Values: [][]interface{}{row},
+	}).ValueInputOption("RAW").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Unable to update loan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package labels renders printable sheets of labels with a QR code for each game.
+// Sheets are A4 pages with a grid of 3x8 labels, the usual format of adhesive label sheets.
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/acnil/acnil-bot/pkg/accents"
+	"github.com/go-pdf/fpdf"
+	qrcode "github.com/skip2/go-qrcode"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Label is the information printed for a single game
+type Label struct {
+	ID       string
+	Name     string
+	Location string
+	// URL is encoded in the QR code
+	URL string
+}
+
+const (
+	columns = 3
+	rows    = 8
+	perPage = columns * rows
+
+	// Sizes in millimeters
+	pageWidth   = 210.0
+	pageHeight  = 297.0
+	labelWidth  = pageWidth / columns
+	labelHeight = pageHeight / rows
+	margin      = 3.0
+	qrSize      = labelHeight - 2*margin
+
+	// pxPerMM is the resolution of the PNG sheets, around 250 dpi
+	pxPerMM = 10
+	// textScale is how much the bitmap font is enlarged on the PNG sheets
+	textScale = 3
+)
+
+// Pages splits the labels in groups that fit in a single sheet
+func Pages(labels []Label) [][]Label {
+	pages := [][]Label{}
+	for len(labels) > perPage {
+		pages = append(pages, labels[:perPage])
+		labels = labels[perPage:]
+	}
+	if len(labels) > 0 {
+		pages = append(pages, labels)
+	}
+	return pages
+}
+
+// PDF writes all the labels as a multi page pdf document
+func PDF(w io.Writer, labels []Label) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for _, page := range Pages(labels) {
+		pdf.AddPage()
+		for i, l := range page {
+			x := float64(i%columns) * labelWidth
+			y := float64(i/columns) * labelHeight
+
+			qr, err := qrcode.Encode(l.URL, qrcode.Medium, 256)
+			if err != nil {
+				return fmt.Errorf("failed to encode qr for game %s, %w", l.ID, err)
+			}
+			name := "qr-" + l.ID + "-" + fmt.Sprint(i)
+			options := fpdf.ImageOptions{ImageType: "PNG"}
+			pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(qr))
+			pdf.ImageOptions(name, x+margin, y+margin, qrSize, qrSize, false, options, 0, "")
+
+			textX := x + qrSize + 2*margin
+			textWidth := labelWidth - qrSize - 3*margin
+
+			pdf.SetXY(textX, y+margin+2)
+			pdf.SetFont("Helvetica", "B", 14)
+			pdf.CellFormat(textWidth, 7, tr(l.ID), "", 2, "L", false, 0, "")
+			pdf.SetFont("Helvetica", "", 9)
+			pdf.MultiCell(textWidth, 4, tr(l.Name), "", "L", false)
+			pdf.SetXY(textX, y+labelHeight-margin-5)
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.CellFormat(textWidth, 4, tr(l.Location), "", 0, "L", false, 0, "")
+		}
+	}
+
+	return pdf.Output(w)
+}
+
+// PNG renders each page of labels as a png image
+func PNG(labels []Label) ([][]byte, error) {
+	images := [][]byte{}
+	for _, page := range Pages(labels) {
+		img, err := sheet(page)
+		if err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		if err := png.Encode(buf, img); err != nil {
+			return nil, err
+		}
+		images = append(images, buf.Bytes())
+	}
+	return images, nil
+}
+
+func sheet(labels []Label) (image.Image, error) {
+	mm := func(v float64) int { return int(v * pxPerMM) }
+
+	img := image.NewRGBA(image.Rect(0, 0, mm(pageWidth), mm(pageHeight)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for i, l := range labels {
+		x := mm(float64(i%columns) * labelWidth)
+		y := mm(float64(i/columns) * labelHeight)
+
+		qr, err := qrcode.New(l.URL, qrcode.Medium)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode qr for game %s, %w", l.ID, err)
+		}
+		qr.DisableBorder = true
+		qrImg := qr.Image(mm(qrSize))
+		qrRect := image.Rect(x+mm(margin), y+mm(margin), x+mm(margin+qrSize), y+mm(margin+qrSize))
+		draw.Draw(img, qrRect, qrImg, image.Point{}, draw.Src)
+
+		textRect := image.Rect(qrRect.Max.X+mm(margin), y+mm(margin), x+mm(labelWidth-margin), y+mm(labelHeight-margin))
+		drawText(img, textRect, l)
+	}
+	return img, nil
+}
+
+// drawText writes the label text with a small bitmap font and scales it up to be readable once printed
+func drawText(dst draw.Image, r image.Rectangle, l Label) {
+	small := image.NewRGBA(image.Rect(0, 0, r.Dx()/textScale, r.Dy()/textScale))
+	draw.Draw(small, small.Bounds(), image.White, image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	charsPerLine := small.Bounds().Dx() / face.Advance
+	maxLines := small.Bounds().Dy() / face.Height
+
+	lines := []string{l.ID, ""}
+	lines = append(lines, wrap(accents.ASCII(l.Name), charsPerLine)...)
+	if len(lines) > maxLines-1 {
+		lines = lines[:maxLines-1]
+	}
+	for len(lines) < maxLines-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, accents.ASCII(l.Location))
+
+	d := font.Drawer{
+		Dst:  small,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+	}
+	for i, line := range lines {
+		d.Dot = fixed.P(0, face.Ascent+i*face.Height)
+		d.DrawString(line)
+	}
+
+	xdraw.NearestNeighbor.Scale(dst, r, small, small.Bounds(), draw.Src, nil)
+}
+
+// wrap splits the text in lines of at most width characters, breaking on spaces when possible
+func wrap(text string, width int) []string {
+	if width <= 0 {
+		return []string{text}
+	}
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
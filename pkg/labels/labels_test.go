@@ -0,0 +1,71 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func testLabels(n int) []Label {
+	labels := []Label{}
+	for i := 0; i < n; i++ {
+		labels = append(labels, Label{
+			ID:       fmt.Sprint(i),
+			Name:     "Las mansiones de la locura, segunda edición",
+			Location: "Gamonal",
+			URL:      fmt.Sprintf("https://t.me/acnilbot?start=game_%d", i),
+		})
+	}
+	return labels
+}
+
+func TestPages(t *testing.T) {
+	pages := Pages(testLabels(perPage + 1))
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages but got %d", len(pages))
+	}
+	if len(pages[1]) != 1 {
+		t.Errorf("expected 1 label in the last page but got %d", len(pages[1]))
+	}
+}
+
+func TestPDF(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := PDF(buf, testLabels(30)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "%PDF") {
+		t.Errorf("output is not a pdf document")
+	}
+}
+
+func TestPNG(t *testing.T) {
+	pages, err := PNG(testLabels(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages but got %d", len(pages))
+	}
+	img, err := png.Decode(bytes.NewReader(pages[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int(pageWidth * pxPerMM); img.Bounds().Dx() != want {
+		t.Errorf("expected width %d but got %d", want, img.Bounds().Dx())
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("Las mansiones de la locura", 10)
+	for _, line := range lines {
+		if len(line) > 10 {
+			t.Errorf("line %q is longer than 10 characters", line)
+		}
+	}
+	if strings.Join(lines, " ") != "Las mansiones de la locura" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+}
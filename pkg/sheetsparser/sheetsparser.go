@@ -14,6 +14,9 @@ import (
 var S = "string type"
 var DefaultParser = &SheetParser{}
 
+// ListSeparator splits the values of []string fields in a single cell
+const ListSeparator = ";"
+
 func Unmarshal(in []interface{}, out interface{}) error {
 	return DefaultParser.Unmarshal(in, out)
 }
@@ -103,6 +106,18 @@ func (p *SheetParser) Unmarshal(in []interface{}, out interface{}) error {
 				return fmt.Errorf("couldn't parse float value, %s, %s", in[index], err)
 			}
 			elfield.Set(reflect.ValueOf(n))
+		case elfield.Kind() == reflect.Bool:
+			if index >= len(in) {
+				elfield.SetBool(false)
+				continue
+			}
+			elfield.SetBool(parseBool(in[index]))
+		case elfield.Type() == reflect.TypeOf([]string{}):
+			if index >= len(in) {
+				elfield.Set(reflect.ValueOf([]string(nil)))
+				continue
+			}
+			elfield.Set(reflect.ValueOf(parseList(fmt.Sprint(in[index]))))
 		case elfield.Type() == reflect.TypeOf(&S):
 			if index >= len(in) {
 				continue
@@ -208,6 +223,10 @@ func (p *SheetParser) Marshal(in interface{}) ([]interface{}, error) {
 		case fieldType == reflect.TypeOf(float64(1)):
 			n := r.Field.Float()
 			out[r.Index] = strings.Replace(strconv.FormatFloat(n, 'f', 2, 64), ".", ",", 1)
+		case fieldType.Kind() == reflect.Bool:
+			out[r.Index] = strings.ToUpper(strconv.FormatBool(r.Field.Bool()))
+		case fieldType == reflect.TypeOf([]string{}):
+			out[r.Index] = strings.Join(r.Field.Interface().([]string), ListSeparator+" ")
 		case fieldType.Kind() == reflect.Pointer:
 			v := r.Field.Elem()
 			if v.Kind() == reflect.Invalid {
@@ -234,3 +253,30 @@ func (p *SheetParser) Marshal(in interface{}) ([]interface{}, error) {
 	return out, nil
 
 }
+
+// parseBool accepts the values used by sheet checkboxes and the common ways of writing yes by hand
+func parseBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		switch strings.ToLower(strings.TrimSpace(b)) {
+		case "true", "1", "x", "si", "sí", "yes":
+			return true
+		}
+	}
+	return false
+}
+
+// parseList splits a cell by ListSeparator, empty values are ignored
+func parseList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ListSeparator) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
@@ -424,3 +424,76 @@ func TestStructSerialization_Marshal(t *testing.T) {
 	}
 
 }
+
+type testBool struct {
+	Checked bool `col:"0"`
+	Missing bool `col:"1"`
+}
+
+func TestBool_Unmarshal(t *testing.T) {
+	p := &SheetParser{}
+	for _, value := range []interface{}{"TRUE", "true", "x", "Sí", true} {
+		test := testBool{Missing: true}
+		err := p.Unmarshal([]interface{}{value}, &test)
+		if err != nil {
+			t.Error(err)
+		}
+		if !test.Checked {
+			t.Errorf("%#v must be parsed as true", value)
+		}
+		if test.Missing {
+			t.Errorf("Missing columns must be false")
+		}
+	}
+
+	test := testBool{}
+	err := p.Unmarshal([]interface{}{"FALSE"}, &test)
+	if err != nil {
+		t.Error(err)
+	}
+	if test.Checked {
+		t.Errorf("FALSE must be parsed as false")
+	}
+}
+
+func TestBool_Marshal(t *testing.T) {
+	p := &SheetParser{}
+	out, err := p.Marshal(&testBool{Checked: true})
+	if err != nil {
+		t.Error(err)
+	}
+	if out[0] != "TRUE" || out[1] != "FALSE" {
+		t.Errorf("unexpected output %#v", out)
+	}
+}
+
+type testList struct {
+	Items   []string `col:"0"`
+	Missing []string `col:"1"`
+}
+
+func TestList_Unmarshal(t *testing.T) {
+	p := &SheetParser{}
+	test := testList{Missing: []string{"old"}}
+	err := p.Unmarshal([]interface{}{"Card Game; Fantasy;;  Dice "}, &test)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(test.Items) != 3 || test.Items[0] != "Card Game" || test.Items[1] != "Fantasy" || test.Items[2] != "Dice" {
+		t.Errorf("unexpected items %#v", test.Items)
+	}
+	if test.Missing != nil {
+		t.Errorf("Missing columns must be empty, got %#v", test.Missing)
+	}
+}
+
+func TestList_Marshal(t *testing.T) {
+	p := &SheetParser{}
+	out, err := p.Marshal(&testList{Items: []string{"Card Game", "Fantasy"}})
+	if err != nil {
+		t.Error(err)
+	}
+	if out[0] != "Card Game; Fantasy" || out[1] != "" {
+		t.Errorf("unexpected output %#v", out)
+	}
+}
@@ -10,7 +10,6 @@ terraform -chdir=./tf destroy -input=false --auto-approve \
      -var=bot_token=$TOKEN \
      -var=sheet_id=$SHEET_ID \
      -var=audit_sheet_id=$AUDIT_SHEET_ID \
-     -var=juegatron_sheet_id=$JUEGATRON_SHEET_ID \
      -var=webhook_secret_token=$WEBHOOK_SECRET_TOKEN
 
 curl -H "Content-Type: application/json"  -X POST "https://api.telegram.org/bot$TOKEN/setWebhook" -d "{
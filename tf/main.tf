@@ -52,10 +52,10 @@ variable "audit_sheet_id" {
   sensitive   = true
 }
 
-variable "juegatron_sheet_id" {
-  description = "sheet used for juegatron purposes"
+variable "group_chat_id" {
+  description = "group chat that receives the announcements and the weekly overdue summary, leave empty to disable"
   type        = string
-  sensitive   = true
+  default     = ""
 }
 
 output "function_url" {
@@ -112,7 +112,7 @@ module "bot_handler" {
     SHEETS_PRIVATE_KEY : var.sheets_private_key
     SHEETS_EMAIL : var.sheets_email
     WEBHOOK_SECRET_TOKEN : var.webhook_secret_token
-    JUEGATRON_SHEET_ID : var.juegatron_sheet_id
+    GROUP_CHAT_ID : var.group_chat_id
   }
   cloudwatch_logs_retention_in_days = 14
 }
@@ -139,6 +139,7 @@ module "audit_handler" {
     SHEETS_PRIVATE_KEY_ID : var.sheets_private_key_id
     SHEETS_PRIVATE_KEY : var.sheets_private_key
     SHEETS_EMAIL : var.sheets_email
+    GROUP_CHAT_ID : var.group_chat_id
   }
   cloudwatch_logs_retention_in_days = 14
 
@@ -148,6 +149,14 @@ module "audit_handler" {
       principal  = "events.amazonaws.com"
       source_arn = resource.aws_cloudwatch_event_rule.daily.arn
     }
+    WeeklyRule = {
+      principal  = "events.amazonaws.com"
+      source_arn = resource.aws_cloudwatch_event_rule.weekly.arn
+    }
+    JuegatronRule = {
+      principal  = "events.amazonaws.com"
+      source_arn = resource.aws_cloudwatch_event_rule.juegatron.arn
+    }
   }
 }
 
@@ -175,3 +184,47 @@ resource "aws_lambda_permission" "allow_eventbridge" {
   principal     = "events.amazonaws.com"
   source_arn    = aws_cloudwatch_event_rule.daily.arn
 }
+
+resource "aws_cloudwatch_event_rule" "weekly" {
+  name        = format("%s-acnil-bot-weekly_rule", terraform.workspace)
+  description = "trigger the overdue summary weekly"
+
+  schedule_expression = "cron(0 10 ? * MON *)"
+}
+
+resource "aws_cloudwatch_event_target" "weekly_lambda_target" {
+  rule      = aws_cloudwatch_event_rule.weekly.name
+  target_id = "SendOverdueSummaryToLambda"
+  arn       = module.audit_handler.lambda_function_arn
+  input     = jsonencode({ task = "overdue-summary" })
+}
+
+resource "aws_lambda_permission" "allow_eventbridge_weekly" {
+  statement_id  = "AllowExecutionFromEventBridgeWeekly"
+  action        = "lambda:InvokeFunction"
+  function_name = module.audit_handler.lambda_function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.weekly.arn
+}
+
+resource "aws_cloudwatch_event_rule" "juegatron" {
+  name        = format("%s-acnil-bot-juegatron_rule", terraform.workspace)
+  description = "trigger the juegatron checks hourly, they only do something while an event is active"
+
+  schedule_expression = "rate(1 hour)"
+}
+
+resource "aws_cloudwatch_event_target" "juegatron_lambda_target" {
+  rule      = aws_cloudwatch_event_rule.juegatron.name
+  target_id = "SendJuegatronChecksToLambda"
+  arn       = module.audit_handler.lambda_function_arn
+  input     = jsonencode({ task = "juegatron-checks" })
+}
+
+resource "aws_lambda_permission" "allow_eventbridge_juegatron" {
+  statement_id  = "AllowExecutionFromEventBridgeJuegatron"
+  action        = "lambda:InvokeFunction"
+  function_name = module.audit_handler.lambda_function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.juegatron.arn
+}